@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/M-kos/wb_level2/task_18/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newFileSink(t *testing.T, path string) *FileSink {
+	t.Helper()
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { sink.Close() })
+
+	return sink
+}
+
+func TestRepository(t *testing.T) {
+	sink := newFileSink(t, filepath.Join(t.TempDir(), "audit.log"))
+	service := services.NewEventService(NewRepository(repositories.NewEventRepository(), sink), services.ConflictIgnore)
+	ctx := tracing.WithRequestID(auth.WithUser(context.Background(), 1), "req-1")
+
+	event, err := service.Create(ctx, &domains.Event{UserID: 1, Title: "planning", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	_, err = service.Update(ctx, &domains.Event{ID: event.ID, UserID: 1, Title: "review", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	// a failed change is not recorded
+	_, err = service.Update(ctx, &domains.Event{ID: event.ID, UserID: 1, Title: "stale", Date: date(2026, 3, 11), Version: 1})
+	require.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	require.NoError(t, service.Delete(ctx, event.ID, 0))
+
+	entries, err := sink.AuditEntries(ctx, domains.AuditFilter{EventID: event.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	deleted, updated, created := entries[0], entries[1], entries[2]
+	assert.Equal(t, domains.AuditCreated, created.Action)
+	assert.Nil(t, created.Before)
+	assert.Equal(t, "planning", created.After.Title)
+	assert.Equal(t, 1, created.ActorID)
+	assert.Equal(t, "req-1", created.RequestID)
+
+	assert.Equal(t, domains.AuditUpdated, updated.Action)
+	assert.Equal(t, "planning", updated.Before.Title)
+	assert.Equal(t, "review", updated.After.Title)
+	assert.Equal(t, 2, updated.After.Version)
+
+	assert.Equal(t, domains.AuditDeleted, deleted.Action)
+	assert.Equal(t, "review", deleted.Before.Title)
+	assert.Nil(t, deleted.After)
+	assert.Equal(t, 1, deleted.OwnerID)
+}
+
+func TestRepository_Batch(t *testing.T) {
+	sink := newFileSink(t, filepath.Join(t.TempDir(), "audit.log"))
+	service := services.NewEventService(NewRepository(repositories.NewEventRepository(), sink), services.ConflictIgnore)
+	ctx := auth.WithUser(context.Background(), 1)
+
+	event, err := service.Create(ctx, &domains.Event{UserID: 1, Title: "planning", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	_, err = service.Batch(ctx, []domains.Operation{
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: event.ID, UserID: 1, Title: "review", Date: date(2026, 3, 11)}},
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: event.ID, UserID: 1, Title: "retro", Date: date(2026, 3, 11)}},
+		{Kind: domains.OperationDelete, ID: event.ID},
+	}, true)
+	require.NoError(t, err)
+
+	entries, err := sink.AuditEntries(ctx, domains.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, "review", entries[2].After.Title)
+	assert.Equal(t, "planning", entries[2].Before.Title)
+	assert.Equal(t, "review", entries[1].Before.Title)
+	assert.Equal(t, "retro", entries[0].Before.Title)
+	assert.Equal(t, domains.AuditDeleted, entries[0].Action)
+}
+
+func TestRepository_Trash(t *testing.T) {
+	sink := newFileSink(t, filepath.Join(t.TempDir(), "audit.log"))
+	repo := NewRepository(repositories.NewEventRepository(), sink)
+	service := services.NewEventService(repo, services.ConflictIgnore)
+	ctx := auth.WithUser(context.Background(), 1)
+
+	event, err := service.Create(ctx, &domains.Event{UserID: 1, Title: "planning", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(ctx, event.ID, 0))
+
+	_, err = service.Restore(ctx, event.ID)
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(ctx, event.ID, 0))
+
+	purged, err := services.NewPurger(repo, 0, time.Hour).Purge(context.Background())
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+
+	entries, err := sink.AuditEntries(ctx, domains.AuditFilter{EventID: event.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	restored, purgedEntry := entries[2], entries[0]
+	assert.Equal(t, domains.AuditRestored, restored.Action)
+	assert.Equal(t, 1, restored.Before.Version)
+	assert.False(t, restored.Before.DeletedAt.IsZero())
+	assert.Equal(t, 2, restored.After.Version)
+	assert.Equal(t, 1, restored.ActorID)
+
+	assert.Equal(t, domains.AuditPurged, purgedEntry.Action)
+	assert.Equal(t, "planning", purgedEntry.Before.Title)
+	assert.Nil(t, purgedEntry.After)
+	assert.Equal(t, 0, purgedEntry.ActorID)
+	assert.Equal(t, 1, purgedEntry.OwnerID)
+}
+
+// cancelling is a repository whose changes cancel the request once they are stored,
+// like a client disconnecting right after the commit.
+type cancelling struct {
+	services.EventRepository
+	cancel context.CancelFunc
+}
+
+func (c *cancelling) Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
+	defer c.cancel()
+
+	return c.EventRepository.Create(ctx, newEvent)
+}
+
+func TestRepository_Cancelled(t *testing.T) {
+	sink := newFileSink(t, filepath.Join(t.TempDir(), "audit.log"))
+	ctx, cancel := context.WithCancel(auth.WithUser(context.Background(), 1))
+	repo := NewRepository(&cancelling{EventRepository: repositories.NewEventRepository(), cancel: cancel}, sink)
+
+	event, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "planning", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	require.Error(t, ctx.Err())
+
+	entries, err := sink.AuditEntries(context.Background(), domains.AuditFilter{EventID: event.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, domains.AuditCreated, entries[0].Action)
+	assert.Equal(t, 1, entries[0].ActorID)
+}
+
+func TestFileSink_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ctx := context.Background()
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.AppendAudit(ctx, &domains.AuditEntry{Action: domains.AuditCreated, EventID: 1}))
+	require.NoError(t, sink.AppendAudit(ctx, &domains.AuditEntry{Action: domains.AuditDeleted, EventID: 1}))
+	require.NoError(t, sink.Close())
+
+	// a crash in the middle of an append leaves a torn line
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"ID":3,"Act`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	sink = newFileSink(t, path)
+	entry := &domains.AuditEntry{Action: domains.AuditCreated, EventID: 2}
+	require.NoError(t, sink.AppendAudit(ctx, entry))
+	assert.Equal(t, 3, entry.ID)
+
+	entries, err := sink.AuditEntries(ctx, domains.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 3, entries[0].ID)
+	assert.Equal(t, 2, entries[0].EventID)
+	assert.Equal(t, 2, entries[1].ID)
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// FileSink appends the audit entries to a file as JSON lines. Queries read the whole file,
+// it suits logs which are rotated by the operator rather than queried at high rates.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	// lastID is the id of the last entry in the file
+	lastID int
+}
+
+// NewFileSink opens or creates the file at path, new entries continue the numbering of the entries it holds.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+
+	sink := &FileSink{file: file}
+
+	size, err := sink.scan(func(entry *domains.AuditEntry) {
+		sink.lastID = max(sink.lastID, entry.ID)
+	})
+	if err == nil {
+		// cut off a torn last line, appended entries would continue it otherwise
+		err = file.Truncate(size)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+func (fs *FileSink) AppendAudit(ctx context.Context, entry *domains.AuditEntry) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	entry.ID = fs.lastID + 1
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	// a single write keeps the line whole when other processes append to the file as well
+	if _, err := fs.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+
+	fs.lastID = entry.ID
+
+	return nil
+}
+
+func (fs *FileSink) AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	entries := make([]*domains.AuditEntry, 0)
+
+	_, err := fs.scan(func(entry *domains.AuditEntry) {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(entries)
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
+
+func (fs *FileSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.file.Close()
+}
+
+// scan calls fn with every entry of the file in order and returns the size of its complete lines. It has to be
+// called with the lock held or before the sink is shared. A torn last line left by a crash is skipped.
+func (fs *FileSink) scan(fn func(entry *domains.AuditEntry)) (int64, error) {
+	if _, err := fs.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("read audit log: %w", err)
+	}
+
+	reader := bufio.NewReader(fs.file)
+	var size int64
+
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return size, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read audit log: %w", err)
+		}
+
+		var entry domains.AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return 0, fmt.Errorf("decode audit log line %d: %w", line, err)
+		}
+
+		fn(&entry)
+		size += int64(len(data))
+	}
+}
@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/M-kos/wb_level2/task_18/internal/tracing"
+)
+
+// Sink stores the audit entries. AuditEntries returns the entries matching the filter, newest first.
+type Sink interface {
+	AppendAudit(ctx context.Context, entry *domains.AuditEntry) error
+	AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error)
+}
+
+// Repository records every change made through the wrapped repository in the sink,
+// so the changes of the event service are audited whatever operation makes them.
+type Repository struct {
+	services.EventRepository
+	sink Sink
+	now  func() time.Time
+}
+
+func NewRepository(repo services.EventRepository, sink Sink) *Repository {
+	return &Repository{
+		EventRepository: repo,
+		sink:            sink,
+		now:             time.Now,
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
+	event, err := r.EventRepository.Create(ctx, newEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, domains.AuditCreated, nil, event)
+
+	return event, nil
+}
+
+func (r *Repository) Update(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	before, err := r.EventRepository.Event(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.EventRepository.Update(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, domains.AuditUpdated, before, updated)
+
+	return updated, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int, version int) error {
+	before, err := r.EventRepository.Event(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.EventRepository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	r.record(ctx, domains.AuditDeleted, before, nil)
+
+	return nil
+}
+
+func (r *Repository) Restore(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	before, err := r.EventRepository.TrashedEvent(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := r.EventRepository.Restore(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	r.record(ctx, domains.AuditRestored, before, restored)
+
+	return restored, nil
+}
+
+// Purge records every purged event, the purger runs without an authenticated user so the actor is zero.
+func (r *Repository) Purge(ctx context.Context, before time.Time) ([]*domains.Event, error) {
+	purged, err := r.EventRepository.Purge(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range purged {
+		r.record(ctx, domains.AuditPurged, event, nil)
+	}
+
+	return purged, nil
+}
+
+// Apply records the operations of the batch once it has been applied, an event changed
+// by several operations is recorded as it was before each of them.
+func (r *Repository) Apply(ctx context.Context, operations []domains.Operation) ([]*domains.Event, error) {
+	current := make(map[int]*domains.Event)
+
+	for _, operation := range operations {
+		id := operation.ID
+		if operation.Kind == domains.OperationUpdate {
+			id = operation.Event.ID
+		}
+
+		if id == 0 {
+			continue
+		}
+
+		if _, ok := current[id]; ok {
+			continue
+		}
+
+		// a missing event fails the batch, the repository reports it
+		event, err := r.EventRepository.Event(ctx, id)
+		if err != nil && !errors.Is(err, domains.ErrEventNotFound) {
+			return nil, err
+		}
+
+		current[id] = event
+	}
+
+	events, err := r.EventRepository.Apply(ctx, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		switch operation.Kind {
+		case domains.OperationCreate:
+			r.record(ctx, domains.AuditCreated, nil, events[i])
+			current[events[i].ID] = events[i]
+		case domains.OperationUpdate:
+			r.record(ctx, domains.AuditUpdated, current[events[i].ID], events[i])
+			current[events[i].ID] = events[i]
+		case domains.OperationDelete:
+			r.record(ctx, domains.AuditDeleted, current[operation.ID], nil)
+			current[operation.ID] = nil
+		}
+	}
+
+	return events, nil
+}
+
+// record appends the entry to the sink. The change has been stored already, so a failing sink is only logged
+// and the entry is written even when the request has been cancelled in the meantime.
+func (r *Repository) record(ctx context.Context, action domains.AuditAction, before, after *domains.Event) {
+	entry := &domains.AuditEntry{
+		Action: action,
+		Time:   r.now(),
+		Before: before,
+		After:  after,
+	}
+
+	for _, event := range []*domains.Event{after, before} {
+		if event != nil {
+			entry.EventID, entry.OwnerID = event.ID, event.UserID
+			break
+		}
+	}
+
+	entry.ActorID, _ = auth.UserFromContext(ctx)
+	entry.RequestID, _ = tracing.RequestID(ctx)
+
+	if err := r.sink.AppendAudit(context.WithoutCancel(ctx), entry); err != nil {
+		slog.ErrorContext(ctx, "[Audit] error appending audit entry", "event_id", entry.EventID, "action", action, "error", err)
+	}
+}
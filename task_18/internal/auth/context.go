@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the id of the authenticated user.
+func WithUser(ctx context.Context, userId int) context.Context {
+	return context.WithValue(ctx, userKey{}, userId)
+}
+
+func UserFromContext(ctx context.Context) (int, bool) {
+	userId, ok := ctx.Value(userKey{}).(int)
+
+	return userId, ok
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// NewToken issues an HS256 signed JWT with the user id as its subject.
+func NewToken(secret []byte, userId int, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userId),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	})
+
+	return token.SignedString(secret)
+}
+
+// ParseToken verifies a token issued by NewToken and returns its user id.
+func ParseToken(secret []byte, tokenString string) (int, error) {
+	var claims jwt.RegisteredClaims
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (any, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil || userId <= 0 {
+		return 0, fmt.Errorf("%w: subject is not a user id", ErrInvalidToken)
+	}
+
+	return userId, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("test-secret")
+
+func TestParseToken(t *testing.T) {
+	token, err := NewToken(secret, 42, time.Minute)
+	require.NoError(t, err)
+
+	userId, err := ParseToken(secret, token)
+	require.NoError(t, err)
+	assert.Equal(t, 42, userId)
+}
+
+func TestParseToken_Invalid(t *testing.T) {
+	expired, err := NewToken(secret, 42, -time.Minute)
+	require.NoError(t, err)
+
+	foreign, err := NewToken([]byte("other-secret"), 42, time.Minute)
+	require.NoError(t, err)
+
+	noExpiry, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "42"}).SignedString(secret)
+	require.NoError(t, err)
+
+	badSubject, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "admin",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString(secret)
+	require.NoError(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"expired":     expired,
+		"foreign":     foreign,
+		"no expiry":   noExpiry,
+		"bad subject": badSubject,
+		"unsigned":    unsigned,
+		"garbage":     "not.a.token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseToken(secret, token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
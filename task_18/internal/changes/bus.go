@@ -0,0 +1,176 @@
+package changes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+type Kind string
+
+const (
+	KindCreated Kind = "created"
+	KindUpdated Kind = "updated"
+	KindDeleted Kind = "deleted"
+)
+
+// subscriptionBuffer is how many changes a subscriber may fall behind before it is dropped
+const subscriptionBuffer = 64
+
+// Change is a stored change of an event, IDs grow by one with every change published by the bus.
+type Change struct {
+	ID    uint64
+	Kind  Kind
+	Event *domains.Event
+	At    time.Time
+}
+
+// Bus publishes the changes reported by the event service to the subscribers of the event owner.
+// The latest changes are kept in a bounded buffer, so subscribers can resume after a reconnect.
+type Bus struct {
+	now func() time.Time
+
+	mu          sync.Mutex
+	lastID      uint64
+	buffer      []Change
+	start       int
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBus creates a bus which keeps the last bufferSize changes for replay.
+func NewBus(bufferSize int) *Bus {
+	return &Bus{
+		now:         time.Now,
+		buffer:      make([]Change, max(bufferSize, 1)),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the changes of the events of a user on C.
+// C is closed when the subscriber falls too far behind or the bus is closed.
+type Subscription struct {
+	C <-chan Change
+
+	bus    *Bus
+	userId int
+	ch     chan Change
+}
+
+// Close stops the subscription, it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.remove(s)
+}
+
+func (b *Bus) EventCreated(_ context.Context, event *domains.Event) {
+	b.publish(KindCreated, event)
+}
+
+func (b *Bus) EventUpdated(_ context.Context, event *domains.Event) {
+	b.publish(KindUpdated, event)
+}
+
+func (b *Bus) EventDeleted(_ context.Context, event *domains.Event) {
+	b.publish(KindDeleted, event)
+}
+
+// Subscribe starts a subscription to the changes of the user. When resuming it also returns the
+// buffered changes after lastID, complete is false if some of them are no longer buffered.
+func (b *Bus) Subscribe(userId int, resume bool, lastID uint64) (subscription *Subscription, replay []Change, complete bool) {
+	ch := make(chan Change, subscriptionBuffer)
+	subscription = &Subscription{
+		C:      ch,
+		bus:    b,
+		userId: userId,
+		ch:     ch,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return subscription, nil, false
+	}
+
+	b.subscribers[subscription] = struct{}{}
+
+	if !resume {
+		return subscription, nil, true
+	}
+
+	// an id from the future was issued before a restart
+	complete = lastID <= b.lastID && (b.size == 0 || lastID+1 >= b.at(0).ID)
+
+	for i := range b.size {
+		change := b.at(i)
+		if change.ID > lastID && change.Event.UserID == userId {
+			replay = append(replay, change)
+		}
+	}
+
+	return subscription, replay, complete
+}
+
+// Close ends every subscription, later subscriptions are closed immediately.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscribers {
+		b.remove(subscription)
+	}
+}
+
+func (b *Bus) publish(kind Kind, event *domains.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	change := Change{
+		ID:    b.lastID,
+		Kind:  kind,
+		Event: event,
+		At:    b.now(),
+	}
+
+	if b.size < len(b.buffer) {
+		b.buffer[(b.start+b.size)%len(b.buffer)] = change
+		b.size++
+	} else {
+		b.buffer[b.start] = change
+		b.start = (b.start + 1) % len(b.buffer)
+	}
+
+	for subscription := range b.subscribers {
+		if subscription.userId != event.UserID {
+			continue
+		}
+
+		select {
+		case subscription.ch <- change:
+		default:
+			// a slow subscriber is dropped instead of blocking the service, it resumes from the buffer
+			b.remove(subscription)
+		}
+	}
+}
+
+// at returns the i-th oldest buffered change.
+func (b *Bus) at(i int) Change {
+	return b.buffer[(b.start+i)%len(b.buffer)]
+}
+
+func (b *Bus) remove(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.ch)
+	}
+}
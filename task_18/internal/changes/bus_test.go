@@ -0,0 +1,101 @@
+package changes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(changes []Change) []uint64 {
+	result := make([]uint64, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change.ID)
+	}
+
+	return result
+}
+
+func TestBusDeliversChangesOfTheUser(t *testing.T) {
+	bus := NewBus(10)
+	ctx := context.Background()
+
+	subscription, replay, complete := bus.Subscribe(1, false, 0)
+	defer subscription.Close()
+	assert.Empty(t, replay)
+	assert.True(t, complete)
+
+	bus.EventCreated(ctx, &domains.Event{ID: 1, UserID: 1})
+	bus.EventCreated(ctx, &domains.Event{ID: 2, UserID: 2})
+	bus.EventDeleted(ctx, &domains.Event{ID: 1, UserID: 1})
+
+	first := <-subscription.C
+	assert.Equal(t, KindCreated, first.Kind)
+	assert.Equal(t, uint64(1), first.ID)
+
+	second := <-subscription.C
+	assert.Equal(t, KindDeleted, second.Kind)
+	assert.Equal(t, uint64(3), second.ID)
+}
+
+func TestBusReplay(t *testing.T) {
+	bus := NewBus(3)
+	ctx := context.Background()
+
+	for id := 1; id <= 5; id++ {
+		bus.EventUpdated(ctx, &domains.Event{ID: id, UserID: 1 + id%2})
+	}
+
+	// changes 3, 4 and 5 are buffered, 4 belongs to user 1
+	subscription, replay, complete := bus.Subscribe(1, true, 2)
+	subscription.Close()
+	assert.Equal(t, []uint64{4}, ids(replay))
+	assert.True(t, complete)
+
+	subscription, replay, complete = bus.Subscribe(2, true, 1)
+	subscription.Close()
+	assert.Equal(t, []uint64{3, 5}, ids(replay))
+	assert.False(t, complete)
+
+	subscription, replay, complete = bus.Subscribe(2, true, 42)
+	subscription.Close()
+	assert.Empty(t, replay)
+	assert.False(t, complete)
+}
+
+func TestBusDropsSlowSubscribers(t *testing.T) {
+	bus := NewBus(10)
+	ctx := context.Background()
+
+	subscription, _, _ := bus.Subscribe(1, false, 0)
+
+	for id := range subscriptionBuffer + 1 {
+		bus.EventCreated(ctx, &domains.Event{ID: id, UserID: 1})
+	}
+
+	received := 0
+	for range subscription.C {
+		received++
+	}
+	assert.Equal(t, subscriptionBuffer, received)
+
+	// closing a dropped subscription is a no-op
+	subscription.Close()
+}
+
+func TestBusClose(t *testing.T) {
+	bus := NewBus(10)
+
+	subscription, _, _ := bus.Subscribe(1, false, 0)
+	bus.Close()
+
+	_, ok := <-subscription.C
+	assert.False(t, ok)
+
+	late, _, _ := bus.Subscribe(1, false, 0)
+	_, ok = <-late.C
+	require.False(t, ok)
+	late.Close()
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -10,8 +13,76 @@ import (
 
 const envPrefix = "EVENT_APP"
 
+const (
+	StorageMemory = "memory"
+	StorageSQL    = "sql"
+)
+
+// minJWTSecretLength is the key size of HS256, shorter secrets can be brute-forced from a single token
+const minJWTSecretLength = 32
+
+const (
+	AuditNone       = "none"
+	AuditFile       = "file"
+	AuditRepository = "repository"
+)
+
 type Config struct {
-	Port int `envconfig:"PORT" required:"true"`
+	Port         int    `envconfig:"PORT" required:"true"`
+	JWTSecret    string `envconfig:"JWT_SECRET" required:"true"`
+	Storage      string `envconfig:"STORAGE" default:"memory"`
+	DatabasePath string `envconfig:"DATABASE_PATH" default:"events.db"`
+
+	// DataDir makes the memory storage durable with a write-ahead log and snapshots kept there, empty keeps it volatile.
+	// WALSync is "always", "interval" or "never", see repositories.SyncPolicy
+	DataDir          string        `envconfig:"DATA_DIR"`
+	WALSync          string        `envconfig:"WAL_SYNC" default:"always"`
+	WALSyncInterval  time.Duration `envconfig:"WAL_SYNC_INTERVAL" default:"1s"`
+	SnapshotInterval time.Duration `envconfig:"SNAPSHOT_INTERVAL" default:"5m"`
+
+	ReadTimeout       time.Duration `envconfig:"READ_TIMEOUT" default:"10s"`
+	ReadHeaderTimeout time.Duration `envconfig:"READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `envconfig:"WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout       time.Duration `envconfig:"IDLE_TIMEOUT" default:"60s"`
+	ShutdownTimeout   time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
+	// MaxBodySize limits the body of create and update requests in bytes
+	MaxBodySize int64 `envconfig:"MAX_BODY_SIZE" default:"1048576"`
+
+	ReminderWebhookURL     string        `envconfig:"REMINDER_WEBHOOK_URL"`
+	ReminderWebhookTimeout time.Duration `envconfig:"REMINDER_WEBHOOK_TIMEOUT" default:"5s"`
+
+	// ConflictPolicy is "ignore", "warn" or "reject", see services.ConflictPolicy
+	ConflictPolicy string `envconfig:"CONFLICT_POLICY" default:"warn"`
+
+	// StreamBufferSize is how many changes are kept for clients resuming the event stream
+	StreamBufferSize int           `envconfig:"STREAM_BUFFER_SIZE" default:"1000"`
+	StreamHeartbeat  time.Duration `envconfig:"STREAM_HEARTBEAT" default:"15s"`
+
+	// RateLimit is the default limit of the requests of a client to a route like "120/1m", "0" turns it off.
+	// RateLimitRoutes overrides it for ServeMux patterns, "POST /import:5/1m,GET /export.ics:0"
+	RateLimit       string            `envconfig:"RATE_LIMIT" default:"120/1m"`
+	RateLimitRoutes map[string]string `envconfig:"RATE_LIMIT_ROUTES" default:"POST /create_event:30/1m,POST /api/v1/users/{user_id}/events:30/1m,POST /import:5/1m,POST /events/batch:10/1m"`
+	// RateLimitIdle is how long the limit of an idle client is remembered
+	RateLimitIdle time.Duration `envconfig:"RATE_LIMIT_IDLE" default:"10m"`
+
+	// GRPCPort is the port of the gRPC API, 0 turns it off
+	GRPCPort int `envconfig:"GRPC_PORT" default:"9090"`
+
+	// AuditSink is where the changes of events are recorded: "none", "file" for JSON lines in AuditFile
+	// or "repository" for the storage of the events
+	AuditSink string `envconfig:"AUDIT_SINK" default:"none"`
+	AuditFile string `envconfig:"AUDIT_FILE" default:"audit.log"`
+
+	// TrashRetention is how long deleted events stay in the trash before they are purged, 0 keeps them forever
+	TrashRetention     time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
+	TrashPurgeInterval time.Duration `envconfig:"TRASH_PURGE_INTERVAL" default:"1h"`
+
+	// IdempotencyTTL is how long the response to an Idempotency-Key is replayed, 0 turns the keys off
+	IdempotencyTTL time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+	// IdempotencyMaxKeys and IdempotencyMaxBytes bound the responses stored for a client,
+	// the least recently used ones are dropped first
+	IdempotencyMaxKeys  int   `envconfig:"IDEMPOTENCY_MAX_KEYS" default:"1000"`
+	IdempotencyMaxBytes int64 `envconfig:"IDEMPOTENCY_MAX_BYTES" default:"10485760"`
 }
 
 func Load() (*Config, error) {
@@ -24,6 +95,38 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	if len(cfg.JWTSecret) < minJWTSecretLength {
+		return nil, fmt.Errorf("JWT secret is too short, expected at least %d bytes", minJWTSecretLength)
+	}
+
+	if cfg.Storage != StorageMemory && cfg.Storage != StorageSQL {
+		return nil, fmt.Errorf("unknown storage %q, expected %q or %q", cfg.Storage, StorageMemory, StorageSQL)
+	}
+
+	if !slices.Contains([]string{"always", "interval", "never"}, cfg.WALSync) {
+		return nil, fmt.Errorf("unknown WAL sync policy %q, expected always, interval or never", cfg.WALSync)
+	}
+
+	if !slices.Contains([]string{"ignore", "warn", "reject"}, cfg.ConflictPolicy) {
+		return nil, fmt.Errorf("unknown conflict policy %q, expected ignore, warn or reject", cfg.ConflictPolicy)
+	}
+
+	if !slices.Contains([]string{AuditNone, AuditFile, AuditRepository}, cfg.AuditSink) {
+		return nil, fmt.Errorf("unknown audit sink %q, expected none, file or repository", cfg.AuditSink)
+	}
+
+	if cfg.TrashRetention < 0 || cfg.TrashPurgeInterval <= 0 {
+		return nil, fmt.Errorf("invalid trash retention %s or purge interval %s", cfg.TrashRetention, cfg.TrashPurgeInterval)
+	}
+
+	if cfg.IdempotencyTTL < 0 {
+		return nil, fmt.Errorf("invalid idempotency ttl %s", cfg.IdempotencyTTL)
+	}
+
+	if cfg.IdempotencyMaxKeys <= 0 || cfg.IdempotencyMaxBytes <= 0 {
+		return nil, fmt.Errorf("invalid idempotency limits of %d keys or %d bytes", cfg.IdempotencyMaxKeys, cfg.IdempotencyMaxBytes)
+	}
+
 	return &cfg, nil
 }
 
@@ -0,0 +1,34 @@
+package domains
+
+type AttendeeStatus string
+
+const (
+	AttendeePending   AttendeeStatus = "pending"
+	AttendeeAccepted  AttendeeStatus = "accepted"
+	AttendeeDeclined  AttendeeStatus = "declined"
+	AttendeeTentative AttendeeStatus = "tentative"
+)
+
+// Attendee is a user invited to an event and the answer to the invitation.
+type Attendee struct {
+	UserID int
+	Status AttendeeStatus
+}
+
+// Attendee returns the invitation of the user, ok is false if the user is not invited.
+func (e *Event) Attendee(userId int) (Attendee, bool) {
+	for _, attendee := range e.Attendees {
+		if attendee.UserID == userId {
+			return attendee, true
+		}
+	}
+
+	return Attendee{}, false
+}
+
+// Attends reports whether the user is invited to the event and has not declined.
+func (e *Event) Attends(userId int) bool {
+	attendee, ok := e.Attendee(userId)
+
+	return ok && attendee.Status != AttendeeDeclined
+}
@@ -0,0 +1,74 @@
+package domains
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreated AuditAction = "created"
+	AuditUpdated AuditAction = "updated"
+	AuditDeleted AuditAction = "deleted"
+	// AuditRestored is an event restored from the trash, AuditPurged one removed from it for good
+	AuditRestored AuditAction = "restored"
+	AuditPurged   AuditAction = "purged"
+)
+
+func (a AuditAction) Valid() bool {
+	switch a {
+	case AuditCreated, AuditUpdated, AuditDeleted, AuditRestored, AuditPurged:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuditEntry records a change of an event. Before is nil for created events and After for deleted and purged ones.
+type AuditEntry struct {
+	ID      int
+	Action  AuditAction
+	EventID int
+	// OwnerID is the user the event belongs to, ActorID the authenticated user who changed it, zero if unknown
+	OwnerID   int
+	ActorID   int
+	RequestID string
+	Time      time.Time
+	Before    *Event
+	After     *Event
+}
+
+// AuditFilter selects audit entries, zero fields match every entry.
+type AuditFilter struct {
+	// UserID selects the entries of the events of the user and the changes made by the user
+	UserID  int
+	EventID int
+	ActorID int
+	Action  AuditAction
+	// From and To bound the time of the entries to [From, To)
+	From time.Time
+	To   time.Time
+	// BeforeID selects the entries older than the entry with that id
+	BeforeID int
+	Limit    int
+}
+
+// Matches reports whether the entry is selected by the filter, Limit is not taken into account.
+func (f *AuditFilter) Matches(entry *AuditEntry) bool {
+	switch {
+	case f.UserID != 0 && entry.OwnerID != f.UserID && entry.ActorID != f.UserID:
+		return false
+	case f.EventID != 0 && entry.EventID != f.EventID:
+		return false
+	case f.ActorID != 0 && entry.ActorID != f.ActorID:
+		return false
+	case f.Action != "" && entry.Action != f.Action:
+		return false
+	case !f.From.IsZero() && entry.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !entry.Time.Before(f.To):
+		return false
+	case f.BeforeID != 0 && entry.ID >= f.BeforeID:
+		return false
+	default:
+		return true
+	}
+}
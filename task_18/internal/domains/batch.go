@@ -0,0 +1,34 @@
+package domains
+
+import "fmt"
+
+type OperationKind string
+
+const (
+	OperationCreate OperationKind = "create"
+	OperationUpdate OperationKind = "update"
+	OperationDelete OperationKind = "delete"
+)
+
+// Operation is a change of a batch. Event is the new state of the event for create and update,
+// ID and Version select the event to delete. A non-zero version has to match the stored one.
+type Operation struct {
+	Kind    OperationKind
+	Event   *Event
+	ID      int
+	Version int
+}
+
+// BatchError is returned when an operation of an atomic batch fails, none of the batch is applied then.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
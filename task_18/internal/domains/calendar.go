@@ -0,0 +1,60 @@
+package domains
+
+// Permission is the access of a user to a shared calendar, every level includes the lower ones.
+type Permission string
+
+const (
+	// PermissionRead shows the events of the calendar
+	PermissionRead Permission = "read"
+	// PermissionWrite creates, changes and deletes its events
+	PermissionWrite Permission = "write"
+	// PermissionAdmin renames the calendar and manages its shares
+	PermissionAdmin Permission = "admin"
+)
+
+var permissionLevels = map[Permission]int{
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionAdmin: 3,
+}
+
+func (p Permission) Valid() bool {
+	_, ok := permissionLevels[p]
+
+	return ok
+}
+
+// Allows reports whether p includes the required permission.
+func (p Permission) Allows(required Permission) bool {
+	return p.Valid() && permissionLevels[p] >= permissionLevels[required]
+}
+
+// Calendar groups events of its owner, events outside of any calendar are the personal events of their owner.
+type Calendar struct {
+	ID      int
+	OwnerID int
+	Name    string
+	// Shares are the other users with access to the calendar, the owner is not one of them
+	Shares []Share
+}
+
+type Share struct {
+	UserID     int
+	Permission Permission
+}
+
+// Access returns the permission of the user on the calendar, the owner has every permission.
+// ok is false if the calendar is not shared with the user.
+func (c *Calendar) Access(userId int) (Permission, bool) {
+	if userId == c.OwnerID {
+		return PermissionAdmin, true
+	}
+
+	for _, share := range c.Shares {
+		if share.UserID == userId {
+			return share.Permission, true
+		}
+	}
+
+	return "", false
+}
@@ -2,4 +2,32 @@ package domains
 
 import "errors"
 
-var ErrEventNotFound = errors.New("event not found")
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrEventNotRecurring  = errors.New("event is not recurring")
+	ErrOccurrenceNotFound = errors.New("occurrence not found")
+	ErrInvalidTimeRange   = errors.New("event end is before its start")
+	ErrUnauthenticated    = errors.New("user is not authenticated")
+	ErrForbidden          = errors.New("access to the event is forbidden")
+	ErrVersionMismatch    = errors.New("event was changed by another request")
+	ErrNotInvited         = errors.New("user is not invited to the event")
+	ErrCalendarNotFound   = errors.New("calendar not found")
+	ErrCalendarNotEmpty   = errors.New("calendar still holds events")
+	ErrShareWithOwner     = errors.New("calendar can not be shared with its owner")
+)
+
+// ErrEventConflict is matched by a *ConflictError.
+var ErrEventConflict = errors.New("event overlaps other events")
+
+// ConflictError lists the occurrences of the other events an event overlaps.
+type ConflictError struct {
+	Conflicts []*Event
+}
+
+func (e *ConflictError) Error() string {
+	return ErrEventConflict.Error()
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrEventConflict
+}
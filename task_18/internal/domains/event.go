@@ -3,9 +3,58 @@ package domains
 import "time"
 
 type Event struct {
-	ID          int
-	UserID      int
+	ID     int
+	UserID int
+	// CalendarID is the calendar holding the event, zero for the personal events of the owner.
+	// The events of a calendar belong to the owner of the calendar.
+	CalendarID  int
 	Title       string
 	Description string
-	Date        time.Time
+	// Date is the start of the event in the location of its TimeZone
+	Date time.Time
+	// End is zero for events without a duration
+	End time.Time
+	// TimeZone is the IANA name of the zone the event is scheduled in, empty means UTC
+	TimeZone   string
+	Recurrence *Recurrence
+	// RemindBefore holds the offsets before the start at which reminders are sent
+	RemindBefore []time.Duration
+	// Attendees are the other users invited to the event, the owner is not one of them
+	Attendees []Attendee
+	// Version starts at 1 and grows with every update. Passed to an update or delete
+	// it is the version the change is based on, zero skips the check.
+	Version int
+	// DeletedAt is set on the events in the trash, zero for live events
+	DeletedAt time.Time
+	// UID is the iCalendar UID of an event created over CalDAV and Resource the name of the resource the client
+	// created it as. Both are empty for other events, which are exported as EventUID and {id}.ics.
+	UID      string
+	Resource string
+}
+
+func (e *Event) IsRecurring() bool {
+	return e.Recurrence != nil
+}
+
+func (e *Event) Duration() time.Duration {
+	if e.End.IsZero() {
+		return 0
+	}
+
+	return e.End.Sub(e.Date)
+}
+
+// Overlaps reports whether the event starts in [from, to) or is still going on at from.
+// Recurrence is not taken into account.
+func (e *Event) Overlaps(from, to time.Time) bool {
+	return e.Date.Before(to) && (!e.Date.Before(from) || e.End.After(from))
+}
+
+// NextOccurrence returns the first start of the event strictly after the given time, searching up to limit.
+func (e *Event) NextOccurrence(after, limit time.Time) (time.Time, bool) {
+	if !e.IsRecurring() {
+		return e.Date, e.Date.After(after) && e.Date.Before(limit)
+	}
+
+	return e.Recurrence.Next(e.Date, after, limit)
 }
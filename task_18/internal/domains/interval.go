@@ -0,0 +1,78 @@
+package domains
+
+import (
+	"slices"
+	"time"
+)
+
+// Interval is the half-open time range [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// MergeIntervals returns the union of the intervals as sorted, non-overlapping intervals.
+// Intervals which only touch are joined.
+func MergeIntervals(intervals []Interval) []Interval {
+	sorted := slices.Clone(intervals)
+	slices.SortFunc(sorted, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	result := make([]Interval, 0, len(sorted))
+	for _, interval := range sorted {
+		if !interval.Start.Before(interval.End) {
+			continue
+		}
+
+		if last := len(result) - 1; last >= 0 && !interval.Start.After(result[last].End) {
+			if interval.End.After(result[last].End) {
+				result[last].End = interval.End
+			}
+			continue
+		}
+
+		result = append(result, interval)
+	}
+
+	return result
+}
+
+// FreeSlots returns the gaps of at least length between the merged busy intervals inside [from, to).
+func FreeSlots(busy []Interval, from, to time.Time, length time.Duration) []Interval {
+	result := make([]Interval, 0)
+	start := from
+
+	for _, interval := range append(MergeIntervals(busy), Interval{Start: to, End: to}) {
+		end := interval.Start
+		if end.After(to) {
+			end = to
+		}
+
+		if end.Sub(start) >= length && end.After(start) {
+			result = append(result, Interval{Start: start, End: end})
+		}
+
+		if interval.End.After(start) {
+			start = interval.End
+		}
+		if !start.Before(to) {
+			break
+		}
+	}
+
+	return result
+}
+
+// FreeBusy is the busy time of some users inside a window and the free gaps between it.
+type FreeBusy struct {
+	// Users maps the user ids to their merged busy intervals
+	Users map[int][]Interval
+	// Busy is the union of the busy intervals of all users
+	Busy []Interval
+	Free []Interval
+}
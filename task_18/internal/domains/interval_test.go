@@ -0,0 +1,47 @@
+package domains
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, time.March, 11, hour, minute, 0, 0, time.UTC)
+}
+
+func TestMergeIntervals(t *testing.T) {
+	merged := MergeIntervals([]Interval{
+		{Start: at(13, 0), End: at(14, 0)},
+		{Start: at(9, 0), End: at(10, 0)},
+		{Start: at(9, 30), End: at(11, 0)},
+		{Start: at(11, 0), End: at(11, 30)},
+		{Start: at(12, 0), End: at(12, 0)},
+		{Start: at(13, 15), End: at(13, 45)},
+	})
+
+	assert.Equal(t, []Interval{
+		{Start: at(9, 0), End: at(11, 30)},
+		{Start: at(13, 0), End: at(14, 0)},
+	}, merged)
+}
+
+func TestFreeSlots(t *testing.T) {
+	busy := []Interval{
+		{Start: at(7, 0), End: at(9, 30)},
+		{Start: at(10, 0), End: at(11, 0)},
+		{Start: at(11, 15), End: at(12, 0)},
+		{Start: at(16, 30), End: at(18, 0)},
+	}
+
+	free := FreeSlots(busy, at(9, 0), at(17, 0), 30*time.Minute)
+
+	assert.Equal(t, []Interval{
+		{Start: at(9, 30), End: at(10, 0)},
+		{Start: at(12, 0), End: at(16, 30)},
+	}, free)
+
+	assert.Equal(t, []Interval{{Start: at(9, 0), End: at(17, 0)}}, FreeSlots(nil, at(9, 0), at(17, 0), time.Hour))
+	assert.Empty(t, FreeSlots(busy, at(9, 0), at(17, 0), 5*time.Hour))
+}
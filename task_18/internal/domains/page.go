@@ -0,0 +1,79 @@
+package domains
+
+import (
+	"slices"
+	"time"
+)
+
+// Cursor points at an event in a listing ordered by CompareEvents,
+// occurrences of a recurring event share the id but not the date.
+type Cursor struct {
+	Date time.Time
+	ID   int
+}
+
+// Page selects the events of a listing which come after the cursor, at most Limit of them.
+// The zero Page selects every event.
+type Page struct {
+	After *Cursor
+	Limit int
+}
+
+// EventPage is a page of a listing, Total counts the events of all pages. Next points at the last
+// event of the page, it is nil when no events follow.
+type EventPage struct {
+	Events []*Event
+	Total  int
+	Next   *Cursor
+}
+
+// CompareEvents orders events by date, events starting at the same time by id.
+func CompareEvents(a, b *Event) int {
+	if c := a.Date.Compare(b.Date); c != 0 {
+		return c
+	}
+
+	return a.ID - b.ID
+}
+
+// SortEvents sorts the events in the order of CompareEvents.
+func SortEvents(events []*Event) {
+	slices.SortStableFunc(events, CompareEvents)
+}
+
+// Paginate returns at most limit of the sorted events which come after the cursor, nil starts at the first one
+// and zero limit returns every event. next points at the last returned event, it is nil when no events follow.
+func Paginate(events []*Event, after *Cursor, limit int) (page []*Event, next *Cursor) {
+	if limit == 0 {
+		limit = len(events)
+	}
+
+	start := 0
+	if after != nil {
+		start, _ = slices.BinarySearchFunc(events, after, func(event *Event, cursor *Cursor) int {
+			return CompareEvents(event, &Event{ID: cursor.ID, Date: cursor.Date})
+		})
+
+		// the event of the cursor may have been deleted since, then the search already points behind it
+		if start < len(events) && CompareEvents(events[start], &Event{ID: after.ID, Date: after.Date}) == 0 {
+			start++
+		}
+	}
+
+	end := min(start+limit, len(events))
+	page = events[start:end]
+
+	if end < len(events) && len(page) > 0 {
+		last := page[len(page)-1]
+		next = &Cursor{Date: last.Date, ID: last.ID}
+	}
+
+	return page, next
+}
+
+// PageOf returns the page of the sorted events of a whole listing.
+func PageOf(events []*Event, page Page) *EventPage {
+	result, next := Paginate(events, page.After, page.Limit)
+
+	return &EventPage{Events: result, Total: len(events), Next: next}
+}
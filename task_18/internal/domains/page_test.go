@@ -0,0 +1,46 @@
+package domains
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	events := []*Event{
+		{ID: 3, Date: day(12)},
+		{ID: 1, Date: day(11)},
+		{ID: 2, Date: day(11)},
+		{ID: 1, Date: day(13)},
+	}
+	SortEvents(events)
+
+	page, next := Paginate(events, nil, 2)
+	require.Len(t, page, 2)
+	assert.Equal(t, []int{1, 2}, []int{page[0].ID, page[1].ID})
+	assert.Equal(t, &Cursor{Date: day(11), ID: 2}, next)
+
+	page, next = Paginate(events, next, 2)
+	require.Len(t, page, 2)
+	assert.Equal(t, day(12), page[0].Date)
+	assert.Equal(t, day(13), page[1].Date)
+	assert.Nil(t, next)
+
+	// a deleted event keeps its place in the order
+	page, _ = Paginate(events, &Cursor{Date: day(12), ID: 2}, 10)
+	require.Len(t, page, 2)
+	assert.Equal(t, 3, page[0].ID)
+
+	page, next = Paginate(events, &Cursor{Date: day(14), ID: 1}, 10)
+	assert.Empty(t, page)
+	assert.Nil(t, next)
+
+	// the zero page is the whole listing
+	all := PageOf(events, Page{})
+	assert.Len(t, all.Events, 4)
+	assert.Equal(t, 4, all.Total)
+	assert.Nil(t, all.Next)
+}
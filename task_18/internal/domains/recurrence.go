@@ -0,0 +1,187 @@
+package domains
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+	FrequencyYearly  Frequency = "yearly"
+)
+
+// Recurrence is a subset of the RFC 5545 RRULE. ByDay filters days for the daily
+// frequency, picks days of the week for weekly and days of the month for monthly,
+// it is ignored for yearly. Count and Until are exclusive, zero means unbounded.
+// Exceptions hold the dates of removed occurrences.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	Count      int
+	Until      time.Time
+	Exceptions []time.Time
+}
+
+// Occurrences returns the starts of the series beginning at start that fall into [from, to).
+// Like in RFC 5545, exceptions do not change how Count is applied.
+func (r *Recurrence) Occurrences(start, from, to time.Time) []time.Time {
+	result := make([]time.Time, 0)
+	for occurrence := range r.All(start, from, to) {
+		result = append(result, occurrence)
+	}
+
+	return result
+}
+
+// All yields the starts of the series beginning at start that fall into [from, to) in chronological order,
+// nothing after the caller stops is generated.
+func (r *Recurrence) All(start, from, to time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		interval := max(r.Interval, 1)
+		generated := 0
+
+		for period := 0; ; period++ {
+			periodStart, candidates := r.period(start, period*interval)
+			if !periodStart.Before(to) || (!r.Until.IsZero() && periodStart.After(r.Until)) {
+				return
+			}
+
+			for _, candidate := range candidates {
+				if candidate.Before(start) {
+					continue
+				}
+
+				if !candidate.Before(to) || (!r.Until.IsZero() && candidate.After(r.Until)) {
+					return
+				}
+
+				generated++
+				if r.Count > 0 && generated > r.Count {
+					return
+				}
+
+				if candidate.Before(from) || r.isException(candidate) {
+					continue
+				}
+
+				if !yield(candidate) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Next returns the first occurrence of the series beginning at start strictly after the given time,
+// searching up to limit.
+func (r *Recurrence) Next(start, after, limit time.Time) (time.Time, bool) {
+	for occurrence := range r.All(start, after.Add(time.Nanosecond), limit) {
+		return occurrence, true
+	}
+
+	return time.Time{}, false
+}
+
+// HasOccurrence reports whether the series beginning at start has an occurrence on the day of date.
+func (r *Recurrence) HasOccurrence(start, date time.Time) bool {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, start.Location())
+
+	_, ok := r.Next(start, from.Add(-time.Nanosecond), from.AddDate(0, 0, 1))
+
+	return ok
+}
+
+// period returns the beginning of the n-th period after the one containing start
+// and the candidate occurrences inside it in chronological order.
+func (r *Recurrence) period(start time.Time, n int) (time.Time, []time.Time) {
+	year, month, day := start.Date()
+	hour, minute, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, sec, start.Nanosecond(), start.Location())
+	}
+
+	switch r.Frequency {
+	case FrequencyWeekly:
+		monday := at(year, month, day-mondayOffset(start.Weekday())+7*n)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+
+		offsets := make([]int, 0, len(days))
+		for _, d := range days {
+			offsets = append(offsets, mondayOffset(d))
+		}
+		slices.Sort(offsets)
+
+		candidates := make([]time.Time, 0, len(offsets))
+		for _, offset := range slices.Compact(offsets) {
+			candidates = append(candidates, monday.AddDate(0, 0, offset))
+		}
+
+		return monday, candidates
+	case FrequencyMonthly:
+		first := at(year, month+time.Month(n), 1)
+
+		if len(r.ByDay) == 0 {
+			candidate := at(year, month+time.Month(n), day)
+			if candidate.Month() != first.Month() {
+				return first, nil
+			}
+
+			return first, []time.Time{candidate}
+		}
+
+		candidates := make([]time.Time, 0)
+		for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+			if slices.Contains(r.ByDay, d.Weekday()) {
+				candidates = append(candidates, d)
+			}
+		}
+
+		return first, candidates
+	case FrequencyYearly:
+		first := at(year+n, time.January, 1)
+		candidate := at(year+n, month, day)
+		if candidate.Month() != month {
+			return first, nil
+		}
+
+		return first, []time.Time{candidate}
+	default:
+		candidate := at(year, month, day+n)
+		if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, candidate.Weekday()) {
+			return candidate, nil
+		}
+
+		return candidate, []time.Time{candidate}
+	}
+}
+
+func (r *Recurrence) isException(date time.Time) bool {
+	for _, exception := range r.Exceptions {
+		if sameDay(exception, date) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
+
+// mondayOffset returns the number of days between monday and the weekday.
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
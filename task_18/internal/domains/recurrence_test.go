@@ -0,0 +1,110 @@
+package domains
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		start      time.Time
+		from, to   time.Time
+		expected   []time.Time
+	}{
+		{
+			name:       "daily with interval",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 2},
+			start:      date(2026, 3, 1),
+			from:       date(2026, 3, 4),
+			to:         date(2026, 3, 10),
+			expected:   []time.Time{date(2026, 3, 5), date(2026, 3, 7), date(2026, 3, 9)},
+		},
+		{
+			name:       "daily filtered by day",
+			recurrence: Recurrence{Frequency: FrequencyDaily, ByDay: []time.Weekday{time.Saturday, time.Sunday}},
+			start:      date(2026, 3, 2),
+			from:       date(2026, 3, 1),
+			to:         date(2026, 3, 16),
+			expected:   []time.Time{date(2026, 3, 7), date(2026, 3, 8), date(2026, 3, 14), date(2026, 3, 15)},
+		},
+		{
+			name:       "weekly defaults to the start weekday",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, Interval: 2},
+			start:      date(2026, 3, 4),
+			from:       date(2026, 3, 1),
+			to:         date(2026, 4, 1),
+			expected:   []time.Time{date(2026, 3, 4), date(2026, 3, 18)},
+		},
+		{
+			name:       "weekly by day skips days before start",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, ByDay: []time.Weekday{time.Friday, time.Monday}},
+			start:      date(2026, 3, 4),
+			from:       date(2026, 3, 1),
+			to:         date(2026, 3, 14),
+			expected:   []time.Time{date(2026, 3, 6), date(2026, 3, 9), date(2026, 3, 13)},
+		},
+		{
+			name:       "monthly skips short months",
+			recurrence: Recurrence{Frequency: FrequencyMonthly},
+			start:      date(2026, 1, 31),
+			from:       date(2026, 1, 1),
+			to:         date(2026, 6, 1),
+			expected:   []time.Time{date(2026, 1, 31), date(2026, 3, 31), date(2026, 5, 31)},
+		},
+		{
+			name:       "yearly on leap day",
+			recurrence: Recurrence{Frequency: FrequencyYearly},
+			start:      date(2024, 2, 29),
+			from:       date(2024, 1, 1),
+			to:         date(2029, 1, 1),
+			expected:   []time.Time{date(2024, 2, 29), date(2028, 2, 29)},
+		},
+		{
+			name:       "count includes occurrences before the window",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Count: 5},
+			start:      date(2026, 3, 1),
+			from:       date(2026, 3, 4),
+			to:         date(2026, 4, 1),
+			expected:   []time.Time{date(2026, 3, 4), date(2026, 3, 5)},
+		},
+		{
+			name:       "until is inclusive",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Until: date(2026, 3, 3)},
+			start:      date(2026, 3, 1),
+			from:       date(2026, 3, 1),
+			to:         date(2026, 4, 1),
+			expected:   []time.Time{date(2026, 3, 1), date(2026, 3, 2), date(2026, 3, 3)},
+		},
+		{
+			name:       "exceptions do not extend count",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Count: 3, Exceptions: []time.Time{date(2026, 3, 2)}},
+			start:      date(2026, 3, 1),
+			from:       date(2026, 3, 1),
+			to:         date(2026, 4, 1),
+			expected:   []time.Time{date(2026, 3, 1), date(2026, 3, 3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.recurrence.Occurrences(tt.start, tt.from, tt.to))
+		})
+	}
+}
+
+func TestHasOccurrence(t *testing.T) {
+	recurrence := Recurrence{Frequency: FrequencyWeekly}
+	start := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+
+	assert.True(t, recurrence.HasOccurrence(start, date(2026, 3, 11)))
+	assert.False(t, recurrence.HasOccurrence(start, date(2026, 3, 12)))
+	assert.False(t, recurrence.HasOccurrence(start, date(2026, 2, 25)))
+}
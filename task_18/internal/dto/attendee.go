@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"fmt"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+type AttendeeDto struct {
+	UserID int    `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// RespondEvent is the answer of an invited user to an invitation.
+type RespondEvent struct {
+	UserId int    `json:"user_id" validate:"required"`
+	Status string `json:"status" validate:"required,oneof=accepted declined tentative"`
+}
+
+func (re *RespondEvent) Validate() error {
+	return validateStruct(re)
+}
+
+// validateAttendees checks the ids of the invited users, the owner can not be invited to their own event.
+func validateAttendees(ownerId int, attendees []int) error {
+	result := make(ValidationErrors, 0)
+
+	for i, userId := range attendees {
+		if userId == ownerId {
+			result = append(result, FieldError{
+				Field:   fmt.Sprintf("attendees[%d]", i),
+				Rule:    "ne_owner",
+				Message: "must not be the owner of the event",
+			})
+		}
+	}
+
+	if len(result) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// attendeesToDomain invites the users, the service keeps the answers of users who were invited before.
+func attendeesToDomain(attendees []int) []domains.Attendee {
+	if len(attendees) == 0 {
+		return nil
+	}
+
+	result := make([]domains.Attendee, 0, len(attendees))
+	for _, userId := range attendees {
+		result = append(result, domains.Attendee{UserID: userId, Status: domains.AttendeePending})
+	}
+
+	return result
+}
+
+func attendeesFromDomain(attendees []domains.Attendee) []AttendeeDto {
+	if len(attendees) == 0 {
+		return nil
+	}
+
+	result := make([]AttendeeDto, 0, len(attendees))
+	for _, attendee := range attendees {
+		result = append(result, AttendeeDto{UserID: attendee.UserID, Status: string(attendee.Status)})
+	}
+
+	return result
+}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// AuditEntryDto is a recorded change of an event, Before is omitted for created events and After for deleted ones.
+type AuditEntryDto struct {
+	ID        int       `json:"id"`
+	Action    string    `json:"action"`
+	EventID   int       `json:"event_id"`
+	OwnerID   int       `json:"owner_id"`
+	ActorID   int       `json:"actor_id"`
+	RequestID string    `json:"request_id,omitempty"`
+	At        string    `json:"at"`
+	Before    *EventDto `json:"before,omitempty"`
+	After     *EventDto `json:"after,omitempty"`
+}
+
+type AuditResponse struct {
+	Result []*AuditEntryDto `json:"result"`
+}
+
+func AuditEntryDtoFromDomain(entry *domains.AuditEntry) *AuditEntryDto {
+	auditEntryDto := &AuditEntryDto{
+		ID:        entry.ID,
+		Action:    string(entry.Action),
+		EventID:   entry.EventID,
+		OwnerID:   entry.OwnerID,
+		ActorID:   entry.ActorID,
+		RequestID: entry.RequestID,
+		At:        entry.Time.UTC().Format(time.RFC3339Nano),
+	}
+
+	if entry.Before != nil {
+		auditEntryDto.Before = EventDtoFromDomain(entry.Before)
+	}
+
+	if entry.After != nil {
+		auditEntryDto.After = EventDtoFromDomain(entry.After)
+	}
+
+	return auditEntryDto
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "encoding/json"
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// BatchRequest holds the operations of POST /events/batch. An atomic batch, the default, applies all
+// operations or none, a best-effort one applies every valid operation on its own.
+type BatchRequest struct {
+	Mode       string            `json:"mode,omitempty"`
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// BatchOperation is a create, update or delete. Event is a CreateEvent for create and an UpdateEvent
+// for update, ID selects the event to update or delete and Version is checked like If-Match.
+type BatchOperation struct {
+	Op      string          `json:"op"`
+	ID      int             `json:"id,omitempty"`
+	Version int             `json:"version,omitempty"`
+	Event   json.RawMessage `json:"event,omitempty"`
+}
+
+type BatchResponse struct {
+	Result []*BatchItemResult `json:"result"`
+}
+
+// BatchItemResult is the outcome of an operation with the status code it would have on its own.
+// Operations of a failed atomic batch which were not applied because of another one have 424.
+type BatchItemResult struct {
+	Index  int              `json:"index"`
+	Status int              `json:"status"`
+	Event  *EventDto        `json:"event,omitempty"`
+	Error  string           `json:"error,omitempty"`
+	Fields ValidationErrors `json:"fields,omitempty"`
+}
@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+type CalendarDto struct {
+	ID      int        `json:"id"`
+	OwnerID int        `json:"owner_id"`
+	Name    string     `json:"name"`
+	Shares  []ShareDto `json:"shares,omitempty"`
+}
+
+type ShareDto struct {
+	UserID     int    `json:"user_id"`
+	Permission string `json:"permission"`
+}
+
+type CalendarResponse struct {
+	Result *CalendarDto `json:"result"`
+}
+
+type CalendarsResponse struct {
+	Result []*CalendarDto `json:"result"`
+}
+
+type CreateCalendar struct {
+	UserId int    `json:"user_id" validate:"required"`
+	Name   string `json:"name" validate:"required,max=100"`
+}
+
+func (cc *CreateCalendar) Validate() error {
+	return validateStruct(cc)
+}
+
+func (cc *CreateCalendar) ToDomain() *domains.Calendar {
+	return &domains.Calendar{
+		OwnerID: cc.UserId,
+		Name:    cc.Name,
+	}
+}
+
+type RenameCalendar struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+func (rc *RenameCalendar) Validate() error {
+	return validateStruct(rc)
+}
+
+// ShareCalendar grants a user a permission on a calendar.
+type ShareCalendar struct {
+	Permission string `json:"permission" validate:"required,oneof=read write admin"`
+}
+
+func (sc *ShareCalendar) Validate() error {
+	return validateStruct(sc)
+}
+
+func CalendarDtoFromDomain(calendar *domains.Calendar) *CalendarDto {
+	calendarDto := &CalendarDto{
+		ID:      calendar.ID,
+		OwnerID: calendar.OwnerID,
+		Name:    calendar.Name,
+	}
+
+	for _, share := range calendar.Shares {
+		calendarDto.Shares = append(calendarDto.Shares, ShareDto{
+			UserID:     share.UserID,
+			Permission: string(share.Permission),
+		})
+	}
+
+	return calendarDto
+}
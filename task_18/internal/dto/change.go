@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
+)
+
+// ChangeDto is the data of a server-sent change notification, Event is omitted for deleted events.
+type ChangeDto struct {
+	Type    string    `json:"type"`
+	EventID int       `json:"event_id"`
+	UserID  int       `json:"user_id"`
+	At      string    `json:"at"`
+	Event   *EventDto `json:"event,omitempty"`
+}
+
+func ChangeDtoFromChange(change changes.Change) *ChangeDto {
+	changeDto := &ChangeDto{
+		Type:    string(change.Kind),
+		EventID: change.Event.ID,
+		UserID:  change.Event.UserID,
+		At:      change.At.Format(time.RFC3339Nano),
+	}
+
+	if change.Kind != changes.KindDeleted {
+		changeDto.Event = EventDtoFromDomain(change.Event)
+	}
+
+	return changeDto
+}
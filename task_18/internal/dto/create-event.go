@@ -1,36 +1,63 @@
 package dto
 
 import (
-	"time"
-
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
-	"github.com/go-playground/validator/v10"
 )
 
 type CreateEvent struct {
 	UserId      int    `json:"user_id" validate:"required"`
 	Title       string `json:"title" validate:"required,min=2"`
 	Description string `json:"description" validate:"omitempty,min=1"`
-	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	eventTime
+	Recurrence *Recurrence `json:"recurrence,omitempty" validate:"omitempty"`
+	// Attendees holds the ids of the invited users, at most 100
+	Attendees []int `json:"attendees,omitempty" validate:"omitempty,max=100,unique,dive,min=1"`
+	// CalendarId is empty for a personal event of the user
+	CalendarId int `json:"calendar_id,omitempty" validate:"omitempty,min=1"`
 }
 
 func (ce *CreateEvent) Validate() error {
-	validate := validator.New()
+	if err := validateStruct(ce); err != nil {
+		return err
+	}
+
+	if err := validateAttendees(ce.UserId, ce.Attendees); err != nil {
+		return err
+	}
 
-	return validate.Struct(ce)
+	return ce.eventTime.validate()
 }
 
 func (ce *CreateEvent) ToDomain() *domains.Event {
-	date, err := time.Parse(time.DateOnly, ce.Date)
-
-	if err != nil {
-		date = time.Time{}
-	}
-
-	return &domains.Event{
+	event := &domains.Event{
 		UserID:      ce.UserId,
+		CalendarID:  ce.CalendarId,
 		Title:       ce.Title,
 		Description: ce.Description,
-		Date:        date,
+		Recurrence:  ce.Recurrence.ToDomain(ce.location()),
+		Attendees:   attendeesToDomain(ce.Attendees),
 	}
+
+	ce.apply(event)
+
+	return event
+}
+
+// CreateEventFromDomain returns the create request of the event, so events which do not come
+// from a request body, like imported ones, are validated with the same rules.
+func CreateEventFromDomain(event *domains.Event) *CreateEvent {
+	createEvent := &CreateEvent{
+		UserId:      event.UserID,
+		CalendarId:  event.CalendarID,
+		Title:       event.Title,
+		Description: event.Description,
+		eventTime:   eventTimeFromDomain(event),
+		Recurrence:  RecurrenceFromDomain(event.Recurrence),
+	}
+
+	for _, attendee := range event.Attendees {
+		createEvent.Attendees = append(createEvent.Attendees, attendee.UserID)
+	}
+
+	return createEvent
 }
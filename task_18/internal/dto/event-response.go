@@ -1,9 +1,27 @@
 package dto
 
+// EventsResponse is a page of events, Total counts the events of all pages.
+// NextCursor is passed as the cursor of the request for the next page, it is empty on the last one.
 type EventsResponse struct {
+	Result     []*EventDto `json:"result"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// SearchResponse is a page of the found events, Total counts all of them.
+type SearchResponse struct {
 	Result []*EventDto `json:"result"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
 }
 
 type EventResponse struct {
 	Result *EventDto `json:"result"`
 }
+
+// ConflictResponse lists the occurrences of the events a rejected event overlaps.
+type ConflictResponse struct {
+	Error     string      `json:"error"`
+	Conflicts []*EventDto `json:"conflicts"`
+}
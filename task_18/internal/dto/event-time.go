@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// eventTime is the scheduling part shared by the create and update requests.
+// Either a whole day Date or an RFC 3339 Start is required, both are placed in TimeZone.
+type eventTime struct {
+	Date     string `json:"date,omitempty" validate:"required_without=Start,omitempty,datetime=2006-01-02"`
+	Start    string `json:"start,omitempty" validate:"required_without=Date,excluded_with=Date,omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	End      string `json:"end,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	TimeZone string `json:"time_zone,omitempty" validate:"omitempty,timezone"`
+	// RemindBefore holds Go durations like "15m" or "1h30m"
+	RemindBefore []string `json:"remind_before,omitempty" validate:"omitempty,max=10,dive,required"`
+}
+
+// validate checks the rules which can not be expressed with tags.
+func (et *eventTime) validate() error {
+	result := make(ValidationErrors, 0)
+
+	start, end := et.times()
+	if !end.IsZero() && end.Before(start) {
+		result = append(result, FieldError{
+			Field:   "end",
+			Rule:    "after_start",
+			Message: domains.ErrInvalidTimeRange.Error(),
+		})
+	}
+
+	for i, offset := range et.RemindBefore {
+		if duration, err := time.ParseDuration(offset); err != nil || duration < 0 {
+			result = append(result, FieldError{
+				Field:   fmt.Sprintf("remind_before[%d]", i),
+				Rule:    "duration",
+				Message: "must be a non-negative duration like 15m",
+			})
+		}
+	}
+
+	if len(result) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// eventTimeFromDomain returns the scheduling part which stores the times of the event unchanged.
+func eventTimeFromDomain(event *domains.Event) eventTime {
+	et := eventTime{
+		Start:    event.Date.Format(time.RFC3339Nano),
+		TimeZone: event.TimeZone,
+	}
+
+	if !event.End.IsZero() {
+		et.End = event.End.Format(time.RFC3339Nano)
+	}
+
+	for _, offset := range event.RemindBefore {
+		et.RemindBefore = append(et.RemindBefore, offset.String())
+	}
+
+	return et
+}
+
+func (et *eventTime) location() *time.Location {
+	location, err := time.LoadLocation(et.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func (et *eventTime) times() (start, end time.Time) {
+	location := et.location()
+
+	if et.Start != "" {
+		if parsed, err := time.Parse(time.RFC3339, et.Start); err == nil {
+			start = parsed.In(location)
+		}
+	} else if parsed, err := time.ParseInLocation(time.DateOnly, et.Date, location); err == nil {
+		start = parsed
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, et.End); err == nil {
+		end = parsed.In(location)
+	}
+
+	return start, end
+}
+
+func (et *eventTime) apply(event *domains.Event) {
+	event.Date, event.End = et.times()
+	event.TimeZone = et.TimeZone
+
+	for _, offset := range et.RemindBefore {
+		if duration, err := time.ParseDuration(offset); err == nil {
+			event.RemindBefore = append(event.RemindBefore, duration)
+		}
+	}
+}
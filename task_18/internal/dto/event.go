@@ -7,19 +7,48 @@ import (
 )
 
 type EventDto struct {
-	ID          int    `json:"id"`
-	UserID      int    `json:"user_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Date        string `json:"date"`
+	ID           int           `json:"id"`
+	UserID       int           `json:"user_id"`
+	CalendarID   int           `json:"calendar_id,omitempty"`
+	Title        string        `json:"title"`
+	Description  string        `json:"description"`
+	Date         string        `json:"date"`
+	Start        string        `json:"start"`
+	End          string        `json:"end,omitempty"`
+	TimeZone     string        `json:"time_zone,omitempty"`
+	RemindBefore []string      `json:"remind_before,omitempty"`
+	Recurrence   *Recurrence   `json:"recurrence,omitempty"`
+	Attendees    []AttendeeDto `json:"attendees,omitempty"`
+	Version      int           `json:"version"`
+	DeletedAt    string        `json:"deleted_at,omitempty"`
 }
 
 func EventDtoFromDomain(event *domains.Event) *EventDto {
-	return &EventDto{
+	eventDto := &EventDto{
 		ID:          event.ID,
 		UserID:      event.UserID,
+		CalendarID:  event.CalendarID,
 		Title:       event.Title,
 		Description: event.Description,
 		Date:        event.Date.Format(time.DateOnly),
+		Start:       event.Date.Format(time.RFC3339),
+		TimeZone:    event.TimeZone,
+		Recurrence:  RecurrenceFromDomain(event.Recurrence),
+		Attendees:   attendeesFromDomain(event.Attendees),
+		Version:     event.Version,
 	}
+
+	if !event.End.IsZero() {
+		eventDto.End = event.End.Format(time.RFC3339)
+	}
+
+	for _, offset := range event.RemindBefore {
+		eventDto.RemindBefore = append(eventDto.RemindBefore, offset.String())
+	}
+
+	if !event.DeletedAt.IsZero() {
+		eventDto.DeletedAt = event.DeletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return eventDto
 }
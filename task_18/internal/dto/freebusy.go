@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"slices"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+type IntervalDto struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type UserBusyDto struct {
+	UserID int            `json:"user_id"`
+	Busy   []*IntervalDto `json:"busy"`
+}
+
+type FreeBusyDto struct {
+	Users []*UserBusyDto `json:"users"`
+	Busy  []*IntervalDto `json:"busy"`
+	Free  []*IntervalDto `json:"free"`
+}
+
+type FreeBusyResponse struct {
+	Result *FreeBusyDto `json:"result"`
+}
+
+// FreeBusyDtoFromDomain formats the intervals in location, users are sorted by id.
+func FreeBusyDtoFromDomain(freeBusy *domains.FreeBusy, location *time.Location) *FreeBusyDto {
+	freeBusyDto := &FreeBusyDto{
+		Users: make([]*UserBusyDto, 0, len(freeBusy.Users)),
+		Busy:  intervalDtos(freeBusy.Busy, location),
+		Free:  intervalDtos(freeBusy.Free, location),
+	}
+
+	userIds := make([]int, 0, len(freeBusy.Users))
+	for userId := range freeBusy.Users {
+		userIds = append(userIds, userId)
+	}
+	slices.Sort(userIds)
+
+	for _, userId := range userIds {
+		freeBusyDto.Users = append(freeBusyDto.Users, &UserBusyDto{
+			UserID: userId,
+			Busy:   intervalDtos(freeBusy.Users[userId], location),
+		})
+	}
+
+	return freeBusyDto
+}
+
+func intervalDtos(intervals []domains.Interval, location *time.Location) []*IntervalDto {
+	result := make([]*IntervalDto, 0, len(intervals))
+	for _, interval := range intervals {
+		result = append(result, &IntervalDto{
+			Start: interval.Start.In(location).Format(time.RFC3339),
+			End:   interval.End.In(location).Format(time.RFC3339),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,16 @@
+package dto
+
+type ImportResponse struct {
+	Result *ImportResult `json:"result"`
+}
+
+type ImportResult struct {
+	Imported []*EventDto    `json:"imported"`
+	Errors   []*ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Index int    `json:"index"`
+	UID   string `json:"uid,omitempty"`
+	Error string `json:"error"`
+}
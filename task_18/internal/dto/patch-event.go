@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// PatchEvent is a partial update, absent fields keep their stored values.
+// An empty End or Description clears the field and a null recurrence turns the series into a single event.
+// A zero calendar_id moves the event out of its calendar.
+type PatchEvent struct {
+	CalendarId   *int            `json:"calendar_id"`
+	Title        *string         `json:"title"`
+	Description  *string         `json:"description"`
+	Date         *string         `json:"date"`
+	Start        *string         `json:"start"`
+	End          *string         `json:"end"`
+	TimeZone     *string         `json:"time_zone"`
+	RemindBefore *[]string       `json:"remind_before"`
+	Recurrence   json.RawMessage `json:"recurrence"`
+	Attendees    *[]int          `json:"attendees"`
+}
+
+// Merge applies the patch to event and returns the result as an update request, which is validated as usual.
+func (pe *PatchEvent) Merge(event *domains.Event) (*UpdateEvent, error) {
+	updateEvent := UpdateEventFromDomain(event)
+
+	if pe.CalendarId != nil {
+		updateEvent.CalendarId = *pe.CalendarId
+	}
+
+	if pe.Title != nil {
+		updateEvent.Title = *pe.Title
+	}
+
+	if pe.Description != nil {
+		updateEvent.Description = *pe.Description
+	}
+
+	// date and start are exclusive, setting one of them replaces the other
+	if pe.Date != nil {
+		updateEvent.Date = *pe.Date
+		updateEvent.Start = ""
+	}
+
+	if pe.Start != nil {
+		updateEvent.Start = *pe.Start
+		updateEvent.Date = ""
+	}
+
+	if pe.End != nil {
+		updateEvent.End = *pe.End
+	}
+
+	if pe.TimeZone != nil {
+		updateEvent.TimeZone = *pe.TimeZone
+	}
+
+	if pe.RemindBefore != nil {
+		updateEvent.RemindBefore = *pe.RemindBefore
+	}
+
+	if pe.Attendees != nil {
+		updateEvent.Attendees = *pe.Attendees
+	}
+
+	if len(pe.Recurrence) > 0 {
+		var recurrence *Recurrence
+		if err := json.Unmarshal(pe.Recurrence, &recurrence); err != nil {
+			return nil, err
+		}
+
+		updateEvent.Recurrence = recurrence
+	}
+
+	return updateEvent, nil
+}
+
+// UpdateEventFromDomain returns the update request which stores the event unchanged.
+func UpdateEventFromDomain(event *domains.Event) *UpdateEvent {
+	updateEvent := &UpdateEvent{
+		UserId:      event.UserID,
+		CalendarId:  event.CalendarID,
+		Title:       event.Title,
+		Description: event.Description,
+		eventTime:   eventTimeFromDomain(event),
+		Recurrence:  RecurrenceFromDomain(event.Recurrence),
+	}
+
+	for _, attendee := range event.Attendees {
+		updateEvent.Attendees = append(updateEvent.Attendees, attendee.UserID)
+	}
+
+	return updateEvent
+}
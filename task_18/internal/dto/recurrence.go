@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"slices"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// weekdayNames are RFC 5545 day names indexed by time.Weekday
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+type Recurrence struct {
+	Frequency  string   `json:"frequency" validate:"required,oneof=daily weekly monthly yearly"`
+	Interval   int      `json:"interval,omitempty" validate:"omitempty,min=1"`
+	ByDay      []string `json:"by_day,omitempty" validate:"omitempty,dive,oneof=MO TU WE TH FR SA SU"`
+	Count      int      `json:"count,omitempty" validate:"omitempty,min=1,excluded_with=Until"`
+	Until      string   `json:"until,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Exceptions []string `json:"exceptions,omitempty" validate:"omitempty,dive,datetime=2006-01-02"`
+}
+
+func (r *Recurrence) ToDomain(location *time.Location) *domains.Recurrence {
+	if r == nil {
+		return nil
+	}
+
+	recurrence := &domains.Recurrence{
+		Frequency: domains.Frequency(r.Frequency),
+		Interval:  r.Interval,
+		Count:     r.Count,
+	}
+
+	for _, day := range r.ByDay {
+		if weekday := slices.Index(weekdayNames[:], day); weekday >= 0 {
+			recurrence.ByDay = append(recurrence.ByDay, time.Weekday(weekday))
+		}
+	}
+
+	if until, err := time.ParseInLocation(time.DateOnly, r.Until, location); err == nil {
+		// until is inclusive, so occurrences later on that day are still part of the series
+		recurrence.Until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	for _, exception := range r.Exceptions {
+		if date, err := time.ParseInLocation(time.DateOnly, exception, location); err == nil {
+			recurrence.Exceptions = append(recurrence.Exceptions, date)
+		}
+	}
+
+	return recurrence
+}
+
+func RecurrenceFromDomain(recurrence *domains.Recurrence) *Recurrence {
+	if recurrence == nil {
+		return nil
+	}
+
+	r := &Recurrence{
+		Frequency: string(recurrence.Frequency),
+		Interval:  recurrence.Interval,
+		Count:     recurrence.Count,
+	}
+
+	for _, day := range recurrence.ByDay {
+		r.ByDay = append(r.ByDay, weekdayNames[day])
+	}
+
+	if !recurrence.Until.IsZero() {
+		r.Until = recurrence.Until.Format(time.DateOnly)
+	}
+
+	for _, exception := range recurrence.Exceptions {
+		r.Exceptions = append(r.Exceptions, exception.Format(time.DateOnly))
+	}
+
+	return r
+}
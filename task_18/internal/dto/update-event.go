@@ -1,37 +1,45 @@
 package dto
 
 import (
-	"time"
-
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
-	"github.com/go-playground/validator/v10"
 )
 
 type UpdateEvent struct {
 	UserId      int    `json:"user_id" validate:"required"`
 	Title       string `json:"title" validate:"required,min=2"`
 	Description string `json:"description" validate:"omitempty,min=1"`
-	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	eventTime
+	Recurrence *Recurrence `json:"recurrence,omitempty" validate:"omitempty"`
+	// Attendees holds the ids of the invited users, at most 100
+	Attendees []int `json:"attendees,omitempty" validate:"omitempty,max=100,unique,dive,min=1"`
+	// CalendarId is empty for a personal event of the user
+	CalendarId int `json:"calendar_id,omitempty" validate:"omitempty,min=1"`
 }
 
 func (ue *UpdateEvent) Validate() error {
-	validate := validator.New()
+	if err := validateStruct(ue); err != nil {
+		return err
+	}
 
-	return validate.Struct(ue)
+	if err := validateAttendees(ue.UserId, ue.Attendees); err != nil {
+		return err
+	}
+
+	return ue.eventTime.validate()
 }
 
 func (ue *UpdateEvent) ToDomain(id int) *domains.Event {
-	date, err := time.Parse(time.DateOnly, ue.Date)
-
-	if err != nil {
-		date = time.Time{}
-	}
-
-	return &domains.Event{
+	event := &domains.Event{
 		ID:          id,
 		UserID:      ue.UserId,
+		CalendarID:  ue.CalendarId,
 		Title:       ue.Title,
 		Description: ue.Description,
-		Date:        date,
+		Recurrence:  ue.Recurrence.ToDomain(ue.location()),
+		Attendees:   attendeesToDomain(ue.Attendees),
 	}
+
+	ue.apply(event)
+
+	return event
 }
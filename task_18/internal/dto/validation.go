@@ -0,0 +1,123 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is shared by the requests, the validator caches the parsed tags of every struct.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// report fields by their JSON names, so errors match the request body
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	return v
+}
+
+// FieldError describes why a single field of a request is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by the Validate methods of the requests.
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, 0, len(ve))
+	for _, fieldError := range ve {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+
+	return "validation error: " + strings.Join(messages, "; ")
+}
+
+// validateStruct runs the tag validation of request and converts the failures to ValidationErrors.
+func validateStruct(request any) error {
+	err := validate.Struct(request)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	result := make(ValidationErrors, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		result = append(result, FieldError{
+			Field:   fieldPath(fieldError.Namespace()),
+			Rule:    fieldError.Tag(),
+			Message: ruleMessage(fieldError),
+		})
+	}
+
+	return result
+}
+
+// fieldPath turns a namespace like CreateEvent.eventTime.date into the path of the field in the body,
+// dropping the request type and the embedded eventTime whose fields are inlined by encoding/json.
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+
+	return strings.TrimPrefix(path, "eventTime.")
+}
+
+func ruleMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+
+	switch fieldError.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "excluded_with":
+		return "must not be set together with " + param
+	case "min":
+		if fieldError.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+		if fieldError.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s items", param)
+		}
+		return "must be at least " + param
+	case "max":
+		if fieldError.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", param)
+		}
+		if fieldError.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s items", param)
+		}
+		return "must be at most " + param
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "datetime":
+		if param == "2006-01-02" {
+			return "must be a date in format YYYY-MM-DD"
+		}
+		return "must be an RFC 3339 date-time"
+	case "timezone":
+		return "must be an IANA time zone name"
+	case "unique":
+		return "must not contain duplicates"
+	default:
+		return "failed the " + fieldError.Tag() + " rule"
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+)
+
+type AuditLog interface {
+	AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error)
+}
+
+type AuditHandler struct {
+	log AuditLog
+}
+
+// NewAuditHandler registers the listing of the audit log.
+func NewAuditHandler(router *http.ServeMux, log AuditLog, middleware middlewares.Middleware) {
+	handler := &AuditHandler{
+		log: log,
+	}
+
+	router.HandleFunc("GET /audit", middleware(handler.Audit))
+}
+
+// Audit returns the changes of the events of the user and the changes made by the user, newest first.
+// They can be filtered by event_id, actor_id, action and a from/to time range, before pages to older entries.
+func (ah *AuditHandler) Audit(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Audit] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	if err := authorizeUser(r, userId); err != nil {
+		slog.ErrorContext(r.Context(), "[Audit] error authorizing audit", "error", err)
+		writeAccessError(w, err)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Audit] error parsing filter", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.UserID = userId
+
+	entries, err := ah.log.AuditEntries(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Audit] error getting audit entries", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	results := make([]*dto.AuditEntryDto, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, dto.AuditEntryDtoFromDomain(entry))
+	}
+
+	writeJSON(w, http.StatusOK, dto.AuditResponse{
+		Result: results,
+	})
+}
+
+func parseAuditFilter(r *http.Request) (domains.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := domains.AuditFilter{
+		Action: domains.AuditAction(query.Get("action")),
+		Limit:  defaultPageLimit,
+	}
+
+	if filter.Action != "" && !filter.Action.Valid() {
+		return filter, errors.New("invalid action, expected created, updated, deleted, restored or purged")
+	}
+
+	ids := []struct {
+		name  string
+		value *int
+	}{
+		{"event_id", &filter.EventID},
+		{"actor_id", &filter.ActorID},
+		{"before", &filter.BeforeID},
+		{"limit", &filter.Limit},
+	}
+	for _, id := range ids {
+		if value := query.Get(id.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return filter, fmt.Errorf("invalid %s, expected a positive number", id.name)
+			}
+
+			*id.value = parsed
+		}
+	}
+
+	if filter.Limit > maxPageLimit {
+		return filter, fmt.Errorf("invalid limit, expected a number from 1 to %d", maxPageLimit)
+	}
+
+	bounds := []struct {
+		name  string
+		value *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	}
+	for _, bound := range bounds {
+		if value := query.Get(bound.name); value != "" {
+			parsed, err := parseBound(value, time.UTC)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s, %w", bound.name, err)
+			}
+
+			*bound.value = parsed
+		}
+	}
+
+	return filter, nil
+}
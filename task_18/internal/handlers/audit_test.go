@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/audit"
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	router := http.NewServeMux()
+	repo := repositories.NewEventRepository()
+	service := services.NewEventService(audit.NewRepository(repo, repo), services.ConflictReject)
+	asUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := strconv.Atoi(r.Header.Get("X-User-Id"))
+			next(w, r.WithContext(auth.WithUser(r.Context(), userId)))
+		}
+	}
+	NewEventHandler(router, service, asUser, 1<<20)
+	NewAuditHandler(router, repo, asUser)
+
+	rec := serveAs(router, 1, http.MethodPost, "/api/v1/users/1/events", `{"title":"planning","date":"2026-03-11"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = serveAs(router, 1, http.MethodPatch, "/api/v1/users/1/events/1", `{"title":"review"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = serveAs(router, 1, http.MethodDelete, "/api/v1/users/1/events/1", "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, 1, http.MethodGet, "/audit?user_id=1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response dto.AuditResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Result, 3)
+	assert.Equal(t, "deleted", response.Result[0].Action)
+	assert.Equal(t, "review", response.Result[0].Before.Title)
+	assert.Nil(t, response.Result[0].After)
+	assert.Equal(t, 1, response.Result[0].ActorID)
+
+	rec = serveAs(router, 1, http.MethodGet, "/audit?user_id=1&action=updated&event_id=1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Result, 1)
+	assert.Equal(t, "planning", response.Result[0].Before.Title)
+	assert.Equal(t, "review", response.Result[0].After.Title)
+
+	rec = serveAs(router, 1, http.MethodGet, "/audit?user_id=1&before=2", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Result, 1)
+	assert.Equal(t, "created", response.Result[0].Action)
+
+	rec = serveAs(router, 1, http.MethodGet, "/audit?user_id=1&action=renamed", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/audit?user_id=1", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
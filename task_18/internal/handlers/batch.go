@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+)
+
+// maxBatchSize limits the number of operations of a batch
+const maxBatchSize = 1000
+
+// Batch runs the create, update and delete operations of the body. An atomic batch responds with the status
+// of the operation which failed and applies nothing, a best-effort batch responds 200 with the result of every
+// operation.
+func (eh *EventHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var batch dto.BatchRequest
+	if !eh.decodeBody(w, r, "[Batch]", &batch) {
+		return
+	}
+
+	if batch.Mode == "" {
+		batch.Mode = dto.BatchModeAtomic
+	}
+
+	if batch.Mode != dto.BatchModeAtomic && batch.Mode != dto.BatchModeBestEffort {
+		writeErrorJSON(w, "invalid mode, expected atomic or best_effort", http.StatusBadRequest)
+		return
+	}
+
+	if len(batch.Operations) == 0 || len(batch.Operations) > maxBatchSize {
+		writeErrorJSON(w, fmt.Sprintf("operations are required, at most %d", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]*dto.BatchItemResult, len(batch.Operations))
+	operations := make([]domains.Operation, 0, len(batch.Operations))
+	// indexes maps the valid operations to their position in the request
+	indexes := make([]int, 0, len(batch.Operations))
+
+	for i, batchOperation := range batch.Operations {
+		operation, err := parseBatchOperation(batchOperation)
+		if err != nil {
+			results[i] = invalidBatchItem(i, err)
+			continue
+		}
+
+		operations = append(operations, operation)
+		indexes = append(indexes, i)
+	}
+
+	atomic := batch.Mode == dto.BatchModeAtomic
+
+	if atomic && len(operations) < len(batch.Operations) {
+		writeBatchFailure(w, results, http.StatusBadRequest)
+		return
+	}
+
+	serviceResults, err := eh.service.Batch(r.Context(), operations, atomic)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Batch] error running batch", "error", err)
+
+		var batchErr *domains.BatchError
+		if !errors.As(err, &batchErr) {
+			writeServiceError(w, err)
+			return
+		}
+
+		failed := batchItemError(indexes[batchErr.Index], batchErr.Err)
+		results[failed.Index] = failed
+		writeBatchFailure(w, results, failed.Status)
+		return
+	}
+
+	for i, result := range serviceResults {
+		index := indexes[i]
+		if result.Err != nil {
+			results[index] = batchItemError(index, result.Err)
+			continue
+		}
+
+		results[index] = batchItemResult(index, operations[i].Kind, result)
+	}
+
+	writeJSON(w, http.StatusOK, dto.BatchResponse{
+		Result: results,
+	})
+}
+
+func parseBatchOperation(batchOperation *dto.BatchOperation) (domains.Operation, error) {
+	if batchOperation == nil {
+		return domains.Operation{}, errors.New("operation is required")
+	}
+
+	kind := domains.OperationKind(batchOperation.Op)
+
+	if (kind == domains.OperationUpdate || kind == domains.OperationDelete) && batchOperation.ID <= 0 {
+		return domains.Operation{}, errors.New("id is required")
+	}
+
+	switch kind {
+	case domains.OperationCreate:
+		var createEventDto dto.CreateEvent
+		if err := decodeBatchEvent(batchOperation.Event, &createEventDto); err != nil {
+			return domains.Operation{}, err
+		}
+
+		if err := createEventDto.Validate(); err != nil {
+			return domains.Operation{}, err
+		}
+
+		return domains.Operation{Kind: kind, Event: createEventDto.ToDomain()}, nil
+	case domains.OperationUpdate:
+		var updateEventDto dto.UpdateEvent
+		if err := decodeBatchEvent(batchOperation.Event, &updateEventDto); err != nil {
+			return domains.Operation{}, err
+		}
+
+		if err := updateEventDto.Validate(); err != nil {
+			return domains.Operation{}, err
+		}
+
+		event := updateEventDto.ToDomain(batchOperation.ID)
+		event.Version = batchOperation.Version
+
+		return domains.Operation{Kind: kind, Event: event}, nil
+	case domains.OperationDelete:
+		return domains.Operation{Kind: kind, ID: batchOperation.ID, Version: batchOperation.Version}, nil
+	default:
+		return domains.Operation{}, errors.New("invalid op, expected create, update or delete")
+	}
+}
+
+func decodeBatchEvent(data json.RawMessage, event any) error {
+	if len(data) == 0 {
+		return errors.New("event is required")
+	}
+
+	if err := json.Unmarshal(data, event); err != nil {
+		return errors.New("invalid event")
+	}
+
+	return nil
+}
+
+func batchItemResult(index int, kind domains.OperationKind, result services.BatchResult) *dto.BatchItemResult {
+	item := &dto.BatchItemResult{
+		Index:  index,
+		Status: http.StatusOK,
+	}
+
+	if kind == domains.OperationCreate {
+		item.Status = http.StatusCreated
+	}
+
+	if result.Event != nil {
+		item.Event = dto.EventDtoFromDomain(result.Event)
+	}
+
+	return item
+}
+
+// invalidBatchItem reports an operation which could not be parsed or failed validation.
+func invalidBatchItem(index int, err error) *dto.BatchItemResult {
+	item := &dto.BatchItemResult{
+		Index:  index,
+		Status: http.StatusBadRequest,
+		Error:  err.Error(),
+	}
+
+	var fields dto.ValidationErrors
+	if errors.As(err, &fields) {
+		item.Error = "validation error"
+		item.Fields = fields
+	}
+
+	return item
+}
+
+// batchItemError reports an error of the service with the status code writeServiceError would respond with.
+func batchItemError(index int, err error) *dto.BatchItemResult {
+	item := &dto.BatchItemResult{
+		Index:  index,
+		Status: serviceErrorStatus(err),
+		Error:  err.Error(),
+	}
+
+	if item.Status == http.StatusInternalServerError {
+		item.Error = "something went wrong"
+	}
+
+	return item
+}
+
+// writeBatchFailure responds to a failed atomic batch, the operations without a result were not applied.
+func writeBatchFailure(w http.ResponseWriter, results []*dto.BatchItemResult, statusCode int) {
+	for i, result := range results {
+		if result == nil {
+			results[i] = &dto.BatchItemResult{
+				Index:  i,
+				Status: http.StatusFailedDependency,
+				Error:  "not applied",
+			}
+		}
+	}
+
+	writeJSON(w, statusCode, dto.BatchResponse{
+		Result: results,
+	})
+}
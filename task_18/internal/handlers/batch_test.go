@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchEvents(t *testing.T) {
+	router := newTestRouter()
+
+	batch := func(body string, status int) []*dto.BatchItemResult {
+		t.Helper()
+
+		rec := serve(router, http.MethodPost, "/events/batch", body)
+		require.Equal(t, status, rec.Code, rec.Body.String())
+
+		var response dto.BatchResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		return response.Result
+	}
+
+	results := batch(`{"operations":[
+		{"op":"create","event":{"user_id":1,"title":"stand up","date":"2026-03-11"}},
+		{"op":"create","event":{"user_id":1,"title":"retro","date":"2026-03-12"}}
+	]}`, http.StatusOK)
+	require.Len(t, results, 2)
+	assert.Equal(t, http.StatusCreated, results[0].Status)
+	assert.Equal(t, 2, results[1].Event.ID)
+
+	// an invalid operation fails the whole atomic batch before anything is stored
+	results = batch(`{"operations":[
+		{"op":"delete","id":1},
+		{"op":"create","event":{"user_id":1,"title":"x","date":"2026-03-13"}}
+	]}`, http.StatusBadRequest)
+	assert.Equal(t, http.StatusFailedDependency, results[0].Status)
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+	assert.NotEmpty(t, results[1].Fields)
+
+	// so does an operation the service rejects
+	results = batch(`{"operations":[
+		{"op":"delete","id":1},
+		{"op":"update","id":2,"version":7,"event":{"user_id":1,"title":"retro","date":"2026-03-12"}}
+	]}`, http.StatusPreconditionFailed)
+	assert.Equal(t, http.StatusFailedDependency, results[0].Status)
+	assert.Equal(t, http.StatusPreconditionFailed, results[1].Status)
+
+	rec := serve(router, http.MethodGet, "/api/v1/users/1/events/1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	results = batch(`{"mode":"best_effort","operations":[
+		{"op":"delete","id":1},
+		{"op":"delete","id":1},
+		{"op":"move","id":2},
+		{"op":"update","id":2,"version":1,"event":{"user_id":1,"title":"retrospective","date":"2026-03-12"}}
+	]}`, http.StatusOK)
+	require.Len(t, results, 4)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+	assert.Equal(t, http.StatusBadRequest, results[2].Status)
+	assert.Equal(t, "retrospective", results[3].Event.Title)
+	assert.Equal(t, 2, results[3].Event.Version)
+
+	rec = serve(router, http.MethodPost, "/events/batch", `{"operations":[]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodPost, "/events/batch", `{"mode":"some","operations":[{"op":"delete","id":2}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
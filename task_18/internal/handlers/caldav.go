@@ -0,0 +1,795 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/ical"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+)
+
+const (
+	davNamespace       = "DAV:"
+	calDAVNamespace    = "urn:ietf:params:xml:ns:caldav"
+	calServerNamespace = "http://calendarserver.org/ns/"
+
+	calDAVRoot       = "/caldav/"
+	personalCalendar = "personal"
+
+	calendarContentType = "text/calendar; charset=utf-8"
+	maxDAVRequestSize   = 1 << 20
+	maxResourceLength   = 255
+
+	// timeRangeFormat is the UTC date-time of the time-range filter of calendar-query
+	timeRangeFormat = "20060102T150405Z"
+)
+
+// calDAVMaxTime is the end of a time-range filter without end
+var calDAVMaxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+type CalDAVService interface {
+	Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error)
+	Calendar(ctx context.Context, calendarId int) (*domains.Calendar, error)
+	CalendarEvents(ctx context.Context, userId int, calendarId int, from, to time.Time) ([]*domains.Event, error)
+	Event(ctx context.Context, eventId int) (*domains.Event, error)
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	Update(ctx context.Context, event *domains.Event) (*domains.Event, error)
+	Delete(ctx context.Context, eventId int, version int) error
+}
+
+type CalDAVHandler struct {
+	service CalDAVService
+}
+
+// NewCalDAVHandler registers a CalDAV subset, enough for calendar apps to subscribe to the calendars of a user.
+// The home of a user is /caldav/{user_id}/, it holds the "personal" collection with the personal events and
+// a collection per calendar the user owns or which is shared with the user. Events created over CalDAV keep
+// the resource name the client chose, the other events are {event_id}.ics resources.
+func NewCalDAVHandler(router *http.ServeMux, service CalDAVService, middleware middlewares.Middleware) {
+	handler := &CalDAVHandler{
+		service: service,
+	}
+
+	router.Handle("/.well-known/caldav", http.RedirectHandler(calDAVRoot, http.StatusMovedPermanently))
+	router.HandleFunc("OPTIONS "+calDAVRoot, middleware(handler.Options))
+	router.HandleFunc("PROPFIND /caldav/{$}", middleware(handler.PropfindRoot))
+	router.HandleFunc("PROPFIND /caldav/{user_id}/{$}", middleware(handler.PropfindHome))
+	router.HandleFunc("PROPFIND /caldav/{user_id}/{calendar}/{$}", middleware(handler.PropfindCalendar))
+	router.HandleFunc("REPORT /caldav/{user_id}/{calendar}/{$}", middleware(handler.Report))
+	router.HandleFunc("PROPFIND /caldav/{user_id}/{calendar}/{resource}", middleware(handler.PropfindEvent))
+	router.HandleFunc("GET /caldav/{user_id}/{calendar}/{resource}", middleware(handler.GetEvent))
+	router.HandleFunc("PUT /caldav/{user_id}/{calendar}/{resource}", middleware(handler.PutEvent))
+	router.HandleFunc("DELETE /caldav/{user_id}/{calendar}/{resource}", middleware(handler.DeleteEvent))
+}
+
+func (ch *CalDAVHandler) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// PropfindRoot points clients to the principal of the authenticated user.
+func (ch *CalDAVHandler) PropfindRoot(w http.ResponseWriter, r *http.Request) {
+	userId, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		writeAccessError(w, domains.ErrUnauthenticated)
+		return
+	}
+
+	request, ok := readDAVRequest(w, r, "[CalDAV Propfind]")
+	if !ok {
+		return
+	}
+
+	writeMultistatus(w, davResponseOf(calDAVRoot, request, []davProperty{
+		davElement(davNamespace, "resourcetype", davElement(davNamespace, "collection")),
+		davElement(davNamespace, "current-user-principal", davText(davNamespace, "href", calDAVHome(userId))),
+	}))
+}
+
+// PropfindHome describes the principal and calendar home of the user, with depth 1 also its calendars.
+func (ch *CalDAVHandler) PropfindHome(w http.ResponseWriter, r *http.Request) {
+	userId, ok := calDAVUser(w, r)
+	if !ok {
+		return
+	}
+
+	request, ok := readDAVRequest(w, r, "[CalDAV Propfind]")
+	if !ok {
+		return
+	}
+
+	home := calDAVHome(userId)
+	responses := []davResponse{davResponseOf(home, request, []davProperty{
+		davElement(davNamespace, "resourcetype", davElement(davNamespace, "collection"), davElement(davNamespace, "principal")),
+		davText(davNamespace, "displayname", fmt.Sprintf("User %d", userId)),
+		davElement(davNamespace, "current-user-principal", davText(davNamespace, "href", home)),
+		davElement(davNamespace, "principal-URL", davText(davNamespace, "href", home)),
+		davElement(calDAVNamespace, "calendar-home-set", davText(davNamespace, "href", home)),
+	})}
+
+	if r.Header.Get("Depth") != "0" {
+		calendars, err := ch.calendars(r.Context(), userId)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[CalDAV Propfind] error getting calendars", "error", err)
+			writeServiceError(w, err)
+			return
+		}
+
+		for _, calendar := range calendars {
+			properties, err := ch.calendarProperties(r.Context(), userId, calendar)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "[CalDAV Propfind] error getting calendar events", "error", err)
+				writeServiceError(w, err)
+				return
+			}
+
+			responses = append(responses, davResponseOf(calendar.href(userId), request, properties))
+		}
+	}
+
+	writeMultistatus(w, responses...)
+}
+
+// PropfindCalendar describes the calendar collection, with depth 1 also its events.
+func (ch *CalDAVHandler) PropfindCalendar(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	request, ok := readDAVRequest(w, r, "[CalDAV Propfind]")
+	if !ok {
+		return
+	}
+
+	events, err := ch.service.CalendarEvents(r.Context(), userId, calendar.id, time.Time{}, calDAVMaxTime)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Propfind] error getting calendar events", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	responses := []davResponse{davResponseOf(calendar.href(userId), request, calendar.properties(userId, events))}
+
+	if r.Header.Get("Depth") != "0" {
+		for _, event := range events {
+			responses = append(responses, davResponseOf(calendar.eventHref(userId, event), request, eventProperties(event)))
+		}
+	}
+
+	writeMultistatus(w, responses...)
+}
+
+func (ch *CalDAVHandler) PropfindEvent(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := ch.resourceEvent(r.Context(), userId, calendar, r.PathValue("resource"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Propfind] error getting event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	request, ok := readDAVRequest(w, r, "[CalDAV Propfind]")
+	if !ok {
+		return
+	}
+
+	writeMultistatus(w, davResponseOf(calendar.eventHref(userId, event), request, eventProperties(event)))
+}
+
+// Report answers the calendar-query report, optionally filtered by a time range of the VEVENT components,
+// and the calendar-multiget report.
+func (ch *CalDAVHandler) Report(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	request, ok := readDAVRequest(w, r, "[CalDAV Report]")
+	if !ok {
+		return
+	}
+
+	switch request.XMLName {
+	case xml.Name{Space: calDAVNamespace, Local: "calendar-query"}:
+		ch.calendarQuery(w, r, userId, calendar, request)
+	case xml.Name{Space: calDAVNamespace, Local: "calendar-multiget"}:
+		ch.calendarMultiget(w, r, userId, calendar, request)
+	default:
+		writeErrorJSON(w, "unsupported report", http.StatusForbidden)
+	}
+}
+
+func (ch *CalDAVHandler) calendarQuery(w http.ResponseWriter, r *http.Request, userId int, calendar *calDAVCalendar, request *davRequest) {
+	from, to, matches, err := request.eventRange()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Report] error parsing filter", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]davResponse, 0)
+
+	if matches {
+		events, err := ch.service.CalendarEvents(r.Context(), userId, calendar.id, from, to)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[CalDAV Report] error getting calendar events", "error", err)
+			writeServiceError(w, err)
+			return
+		}
+
+		for _, event := range events {
+			responses = append(responses, davResponseOf(calendar.eventHref(userId, event), request, eventProperties(event)))
+		}
+	}
+
+	writeMultistatus(w, responses...)
+}
+
+func (ch *CalDAVHandler) calendarMultiget(w http.ResponseWriter, r *http.Request, userId int, calendar *calDAVCalendar, request *davRequest) {
+	responses := make([]davResponse, 0, len(request.Hrefs))
+
+	for _, href := range request.Hrefs {
+		resource, ok := strings.CutPrefix(href, calendar.href(userId))
+		if ok {
+			var err error
+			resource, err = url.PathUnescape(resource)
+			ok = err == nil
+		}
+		if !ok {
+			responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+			continue
+		}
+
+		event, err := ch.resourceEvent(r.Context(), userId, calendar, resource)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[CalDAV Report] error getting event", "href", href, "error", err)
+			responses = append(responses, davResponse{Href: href, Status: davStatus(serviceErrorStatus(err))})
+			continue
+		}
+
+		responses = append(responses, davResponseOf(href, request, eventProperties(event)))
+	}
+
+	writeMultistatus(w, responses...)
+}
+
+func (ch *CalDAVHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := ch.resourceEvent(r.Context(), userId, calendar, r.PathValue("resource"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Get] error getting event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	setETag(w, event)
+	if notModified(r, event) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", calendarContentType)
+	w.WriteHeader(http.StatusOK)
+
+	if err := ical.Encode(w, []*domains.Event{event}, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Get] error encoding event", "error", err)
+	}
+}
+
+// PutEvent stores the first VEVENT of the body. It replaces the event of an existing resource, the attendees
+// and the reminder of the event are kept as iCalendar does not carry them. A new event keeps the resource name
+// and the UID of the client, except for an {id}.ics name which is stored under its own id. The Location header
+// tells clients the resource it was created as.
+func (ch *CalDAVHandler) PutEvent(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	resource := r.PathValue("resource")
+	if !strings.HasSuffix(resource, ".ics") || len(resource) > maxResourceLength {
+		writeErrorJSON(w, fmt.Sprintf("invalid resource name, expected at most %d characters ending in .ics", maxResourceLength), http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[CalDAV Put]")
+	if !ok {
+		return
+	}
+
+	results, err := ical.Decode(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Put] error decoding calendar", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return
+		}
+
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(results) == 0 {
+		writeErrorJSON(w, "VEVENT is required", http.StatusBadRequest)
+		return
+	}
+
+	if results[0].Err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Put] error decoding event", "uid", results[0].UID, "error", results[0].Err)
+		writeErrorJSON(w, results[0].Err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := results[0].Event
+	event.UserID = userId
+	event.CalendarID = calendar.id
+
+	if err := dto.CreateEventFromDomain(event).Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Put] error validating event", "uid", results[0].UID, "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	stored, err := ch.resourceEvent(r.Context(), userId, calendar, resource)
+	switch {
+	case err == nil:
+		if r.Header.Get("If-None-Match") == "*" {
+			writeErrorJSON(w, "resource already exists", http.StatusPreconditionFailed)
+			return
+		}
+
+		event.ID = stored.ID
+		event.Version = version
+		event.Attendees = stored.Attendees
+		event.RemindBefore = stored.RemindBefore
+
+		updated, err := ch.service.Update(r.Context(), event)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[CalDAV Put] error updating event", "error", err)
+			writeServiceError(w, err)
+			return
+		}
+
+		setETag(w, updated)
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domains.ErrEventNotFound):
+		if version != 0 {
+			writeErrorJSON(w, domains.ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		if _, numeric := eventIdOf(resource); !numeric {
+			event.UID, event.Resource = results[0].UID, resource
+		}
+
+		created, err := ch.service.Create(r.Context(), event)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[CalDAV Put] error creating event", "error", err)
+			writeServiceError(w, err)
+			return
+		}
+
+		setETag(w, created)
+		w.Header().Set("Location", calendar.eventHref(userId, created))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		slog.ErrorContext(r.Context(), "[CalDAV Put] error getting event", "error", err)
+		writeServiceError(w, err)
+	}
+}
+
+func (ch *CalDAVHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	userId, calendar, ok := ch.pathCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[CalDAV Delete]")
+	if !ok {
+		return
+	}
+
+	event, err := ch.resourceEvent(r.Context(), userId, calendar, r.PathValue("resource"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Delete] error getting event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := ch.service.Delete(r.Context(), event.ID, version); err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV Delete] error deleting event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// calDAVCalendar is a calendar collection, the personal events of the user are the collection with id zero.
+type calDAVCalendar struct {
+	id         int
+	name       string
+	permission domains.Permission
+}
+
+func (c *calDAVCalendar) href(userId int) string {
+	if c.id == 0 {
+		return calDAVHome(userId) + personalCalendar + "/"
+	}
+
+	return fmt.Sprintf("%s%d/", calDAVHome(userId), c.id)
+}
+
+func (c *calDAVCalendar) eventHref(userId int, event *domains.Event) string {
+	if event.Resource != "" {
+		return c.href(userId) + url.PathEscape(event.Resource)
+	}
+
+	return fmt.Sprintf("%s%d.ics", c.href(userId), event.ID)
+}
+
+// properties describes the collection, its ctag changes whenever one of its events is created, changed or deleted.
+func (c *calDAVCalendar) properties(userId int, events []*domains.Event) []davProperty {
+	hash := fnv.New64a()
+	hash.Write([]byte(c.name))
+	for _, event := range events {
+		fmt.Fprintf(hash, ";%d:%d", event.ID, event.Version)
+	}
+
+	privileges := []davProperty{davElement(davNamespace, "privilege", davElement(davNamespace, "read"))}
+	if c.permission.Allows(domains.PermissionWrite) {
+		for _, privilege := range []string{"write", "write-content", "bind", "unbind"} {
+			privileges = append(privileges, davElement(davNamespace, "privilege", davElement(davNamespace, privilege)))
+		}
+	}
+
+	return []davProperty{
+		davElement(davNamespace, "resourcetype", davElement(davNamespace, "collection"), davElement(calDAVNamespace, "calendar")),
+		davText(davNamespace, "displayname", c.name),
+		davElement(davNamespace, "current-user-principal", davText(davNamespace, "href", calDAVHome(userId))),
+		davElement(davNamespace, "current-user-privilege-set", privileges...),
+		davElement(calDAVNamespace, "supported-calendar-component-set", davComponent("VEVENT")),
+		davText(calServerNamespace, "getctag", strconv.FormatUint(hash.Sum64(), 16)),
+	}
+}
+
+// calendars returns the personal collection and the collections of the calendars of the user.
+func (ch *CalDAVHandler) calendars(ctx context.Context, userId int) ([]*calDAVCalendar, error) {
+	calendars, err := ch.service.Calendars(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*calDAVCalendar{{name: "Personal", permission: domains.PermissionAdmin}}
+	for _, calendar := range calendars {
+		permission, _ := calendar.Access(userId)
+		result = append(result, &calDAVCalendar{id: calendar.ID, name: calendar.Name, permission: permission})
+	}
+
+	return result, nil
+}
+
+func (ch *CalDAVHandler) calendarProperties(ctx context.Context, userId int, calendar *calDAVCalendar) ([]davProperty, error) {
+	events, err := ch.service.CalendarEvents(ctx, userId, calendar.id, time.Time{}, calDAVMaxTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar.properties(userId, events), nil
+}
+
+// pathCalendar returns the collection of the path, it responds itself if the collection can not be used.
+func (ch *CalDAVHandler) pathCalendar(w http.ResponseWriter, r *http.Request) (int, *calDAVCalendar, bool) {
+	userId, ok := calDAVUser(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	name := r.PathValue("calendar")
+	if name == personalCalendar {
+		return userId, &calDAVCalendar{name: "Personal", permission: domains.PermissionAdmin}, true
+	}
+
+	calendarId, err := strconv.Atoi(name)
+	if err != nil || calendarId < 1 {
+		writeErrorJSON(w, domains.ErrCalendarNotFound.Error(), http.StatusNotFound)
+		return 0, nil, false
+	}
+
+	calendar, err := ch.service.Calendar(r.Context(), calendarId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV] error getting calendar", "error", err)
+		writeServiceError(w, err)
+		return 0, nil, false
+	}
+
+	permission, _ := calendar.Access(userId)
+
+	return userId, &calDAVCalendar{id: calendar.ID, name: calendar.Name, permission: permission}, true
+}
+
+// resourceEvent returns the event of a resource of the collection, an {event_id}.ics resource or the name
+// an event was created as.
+func (ch *CalDAVHandler) resourceEvent(ctx context.Context, userId int, calendar *calDAVCalendar, resource string) (*domains.Event, error) {
+	eventId, ok := eventIdOf(resource)
+	if !ok {
+		return ch.namedEvent(ctx, userId, calendar, resource)
+	}
+
+	event, err := ch.service.Event(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	// invited users may read events of other users, they are not part of the collection though.
+	// An event created with a name of its own is only found under that name.
+	if event.CalendarID != calendar.id || (calendar.id == 0 && event.UserID != userId) || event.Resource != "" {
+		return nil, domains.ErrEventNotFound
+	}
+
+	return event, nil
+}
+
+// namedEvent returns the event of the collection which was created as the resource.
+func (ch *CalDAVHandler) namedEvent(ctx context.Context, userId int, calendar *calDAVCalendar, resource string) (*domains.Event, error) {
+	events, err := ch.service.CalendarEvents(ctx, userId, calendar.id, time.Time{}, calDAVMaxTime)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if event.Resource == resource {
+			return event, nil
+		}
+	}
+
+	return nil, domains.ErrEventNotFound
+}
+
+// eventIdOf returns the id of an {event_id}.ics resource.
+func eventIdOf(resource string) (int, bool) {
+	name, ok := strings.CutSuffix(resource, ".ics")
+	if !ok {
+		return 0, false
+	}
+
+	eventId, err := strconv.Atoi(name)
+	if err != nil {
+		return 0, false
+	}
+
+	return eventId, true
+}
+
+// calDAVUser returns the user of the path, only the authenticated user may use its home.
+func calDAVUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	if err := authorizeUser(r, userId); err != nil {
+		slog.ErrorContext(r.Context(), "[CalDAV] error authorizing user", "error", err)
+		writeAccessError(w, err)
+		return 0, false
+	}
+
+	return userId, true
+}
+
+func calDAVHome(userId int) string {
+	return fmt.Sprintf("%s%d/", calDAVRoot, userId)
+}
+
+func eventProperties(event *domains.Event) []davProperty {
+	var data bytes.Buffer
+	ical.Encode(&data, []*domains.Event{event}, time.Now())
+
+	return []davProperty{
+		davElement(davNamespace, "resourcetype"),
+		davText(davNamespace, "getetag", etag(event.Version)),
+		davText(davNamespace, "getcontenttype", calendarContentType+"; component=VEVENT"),
+		davText(calDAVNamespace, "calendar-data", data.String()),
+	}
+}
+
+// davRequest is the body of a PROPFIND or REPORT request, a body without prop asks for every property.
+type davRequest struct {
+	XMLName xml.Name
+	Prop    *struct {
+		Names []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	} `xml:"prop"`
+	Hrefs  []string       `xml:"href"`
+	Filter *davCompFilter `xml:"filter>comp-filter"`
+}
+
+type davCompFilter struct {
+	Name      string `xml:"name,attr"`
+	TimeRange *struct {
+		Start string `xml:"start,attr"`
+		End   string `xml:"end,attr"`
+	} `xml:"time-range"`
+	Filters []davCompFilter `xml:"comp-filter"`
+}
+
+// eventRange returns the time range of the VEVENT filter of a calendar-query, matches is false if the filter
+// selects other components only.
+func (dr *davRequest) eventRange() (from, to time.Time, matches bool, err error) {
+	from, to = time.Time{}, calDAVMaxTime
+
+	if dr.Filter == nil || len(dr.Filter.Filters) == 0 {
+		return from, to, true, nil
+	}
+
+	if dr.Filter.Name != "VCALENDAR" {
+		return from, to, false, nil
+	}
+
+	for _, filter := range dr.Filter.Filters {
+		if filter.Name != "VEVENT" {
+			continue
+		}
+
+		if filter.TimeRange == nil {
+			return from, to, true, nil
+		}
+
+		if filter.TimeRange.Start != "" {
+			if from, err = time.Parse(timeRangeFormat, filter.TimeRange.Start); err != nil {
+				return from, to, false, fmt.Errorf("invalid time-range start: %w", err)
+			}
+		}
+
+		if filter.TimeRange.End != "" {
+			if to, err = time.Parse(timeRangeFormat, filter.TimeRange.End); err != nil {
+				return from, to, false, fmt.Errorf("invalid time-range end: %w", err)
+			}
+		}
+
+		return from, to, true, nil
+	}
+
+	return from, to, false, nil
+}
+
+// readDAVRequest decodes the body, it responds itself if the body is not valid XML. An empty body is
+// a request for every property.
+func readDAVRequest(w http.ResponseWriter, r *http.Request, tag string) (*davRequest, bool) {
+	var request davRequest
+
+	err := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxDAVRequestSize)).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		slog.ErrorContext(r.Context(), tag+" error decoding body", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return nil, false
+		}
+
+		writeErrorJSON(w, "invalid xml body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &request, true
+}
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"href"`
+	Status    string        `xml:"status,omitempty"`
+	Propstats []davPropstat `xml:"propstat"`
+}
+
+type davPropstat struct {
+	Prop struct {
+		Properties []davProperty `xml:",any"`
+	} `xml:"prop"`
+	Status string `xml:"status"`
+}
+
+type davProperty struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Value    string        `xml:",chardata"`
+	Children []davProperty `xml:",any"`
+}
+
+func davElement(space, local string, children ...davProperty) davProperty {
+	return davProperty{XMLName: xml.Name{Space: space, Local: local}, Children: children}
+}
+
+func davText(space, local, value string) davProperty {
+	return davProperty{XMLName: xml.Name{Space: space, Local: local}, Value: value}
+}
+
+func davComponent(name string) davProperty {
+	return davProperty{
+		XMLName: xml.Name{Space: calDAVNamespace, Local: "comp"},
+		Attrs:   []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}},
+	}
+}
+
+func davStatus(statusCode int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", statusCode, http.StatusText(statusCode))
+}
+
+// davResponseOf answers the properties the request asks for, the ones the resource does not have
+// are listed as not found.
+func davResponseOf(href string, request *davRequest, properties []davProperty) davResponse {
+	response := davResponse{Href: href}
+
+	if request.Prop == nil {
+		found := davPropstat{Status: davStatus(http.StatusOK)}
+		found.Prop.Properties = properties
+		response.Propstats = append(response.Propstats, found)
+
+		return response
+	}
+
+	found := davPropstat{Status: davStatus(http.StatusOK)}
+	missing := davPropstat{Status: davStatus(http.StatusNotFound)}
+
+	for _, requested := range request.Prop.Names {
+		property, ok := findDAVProperty(properties, requested.XMLName)
+		if ok {
+			found.Prop.Properties = append(found.Prop.Properties, property)
+		} else {
+			missing.Prop.Properties = append(missing.Prop.Properties, davElement(requested.XMLName.Space, requested.XMLName.Local))
+		}
+	}
+
+	for _, propstat := range []davPropstat{found, missing} {
+		if len(propstat.Prop.Properties) > 0 {
+			response.Propstats = append(response.Propstats, propstat)
+		}
+	}
+
+	return response
+}
+
+func findDAVProperty(properties []davProperty, name xml.Name) (davProperty, bool) {
+	for _, property := range properties {
+		if property.XMLName == name {
+			return property, true
+		}
+	}
+
+	return davProperty{}, false
+}
+
+func writeMultistatus(w http.ResponseWriter, responses ...davResponse) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(davMultistatus{Responses: responses})
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calDAVClient is a minimal CalDAV client, it authenticates as its user with the X-User-Id header.
+type calDAVClient struct {
+	t      *testing.T
+	server *httptest.Server
+	userId int
+}
+
+type calDAVResult struct {
+	status int
+	header http.Header
+	body   string
+	// responses maps the href of every response of a multistatus body to its properties
+	responses map[string]map[string]string
+}
+
+func newCalDAVServer(t *testing.T) *httptest.Server {
+	router := http.NewServeMux()
+	service := services.NewEventService(repositories.NewEventRepository(), services.ConflictReject)
+	asUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := strconv.Atoi(r.Header.Get("X-User-Id"))
+			next(w, r.WithContext(auth.WithUser(r.Context(), userId)))
+		}
+	}
+
+	NewEventHandler(router, service, asUser, 1<<20)
+	NewCalDAVHandler(router, service, asUser)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (c *calDAVClient) do(method, path, body string, header map[string]string) calDAVResult {
+	c.t.Helper()
+
+	req, err := http.NewRequest(method, c.server.URL+path, strings.NewReader(body))
+	require.NoError(c.t, err)
+	req.Header.Set("X-User-Id", strconv.Itoa(c.userId))
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.server.Client().Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+
+	result := calDAVResult{status: resp.StatusCode, header: resp.Header, body: string(data)}
+	if resp.StatusCode == http.StatusMultiStatus {
+		result.responses = parseMultistatus(c.t, data)
+	}
+
+	return result
+}
+
+// parseMultistatus keeps the properties found, keyed by their local name. Properties with elements are kept as xml.
+func parseMultistatus(t *testing.T, data []byte) map[string]map[string]string {
+	t.Helper()
+
+	var multistatus struct {
+		Responses []struct {
+			Href      string `xml:"DAV: href"`
+			Propstats []struct {
+				Status string `xml:"DAV: status"`
+				Prop   struct {
+					Properties []struct {
+						XMLName xml.Name
+						Value   string `xml:",chardata"`
+						Inner   string `xml:",innerxml"`
+					} `xml:",any"`
+				} `xml:"DAV: prop"`
+			} `xml:"DAV: propstat"`
+		} `xml:"DAV: response"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &multistatus))
+
+	responses := make(map[string]map[string]string)
+	for _, response := range multistatus.Responses {
+		properties := make(map[string]string)
+		for _, propstat := range response.Propstats {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+
+			for _, property := range propstat.Prop.Properties {
+				properties[property.XMLName.Local] = property.Value
+				if strings.TrimSpace(property.Value) == "" {
+					properties[property.XMLName.Local] = property.Inner
+				}
+			}
+		}
+
+		responses[response.Href] = properties
+	}
+
+	return responses
+}
+
+const calendarQuery = `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="20260318T000000Z" end="20260319T000000Z"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+const standUp = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:abc\r\nDTSTART:20260311T100000Z\r\n" +
+	"DTEND:20260311T101500Z\r\nSUMMARY:stand up\r\nRRULE:FREQ=WEEKLY\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+func TestCalDAV(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+	reader := &calDAVClient{t: t, server: server, userId: 2}
+
+	result := owner.do("OPTIONS", "/caldav/", "", nil)
+	require.Equal(t, http.StatusOK, result.status)
+	assert.Contains(t, result.header.Get("DAV"), "calendar-access")
+
+	result = owner.do("PROPFIND", "/caldav/", `<propfind xmlns="DAV:"><prop><current-user-principal/></prop></propfind>`, map[string]string{"Depth": "0"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Contains(t, result.responses["/caldav/"]["current-user-principal"], "/caldav/1/")
+
+	result = owner.do("PUT", "/caldav/1/personal/abc.ics", standUp, map[string]string{"If-None-Match": "*"})
+	require.Equal(t, http.StatusCreated, result.status, result.body)
+	assert.Equal(t, "/caldav/1/personal/abc.ics", result.header.Get("Location"))
+	assert.Equal(t, `"1"`, result.header.Get("ETag"))
+
+	// the event is not a resource of its own under its id
+	result = owner.do("GET", "/caldav/1/personal/1.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, result.status)
+
+	result = owner.do("PROPFIND", "/caldav/1/personal/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	require.Contains(t, result.responses, "/caldav/1/personal/abc.ics")
+	assert.Equal(t, `"1"`, result.responses["/caldav/1/personal/abc.ics"]["getetag"])
+	assert.Contains(t, result.responses["/caldav/1/personal/"]["resourcetype"], "calendar")
+	ctag := result.responses["/caldav/1/personal/"]["getctag"]
+	require.NotEmpty(t, ctag)
+
+	// a weekly occurrence falls into the range
+	result = owner.do("REPORT", "/caldav/1/personal/", calendarQuery, map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	require.Len(t, result.responses, 1)
+	assert.Contains(t, result.responses["/caldav/1/personal/abc.ics"]["calendar-data"], "SUMMARY:stand up")
+
+	result = owner.do("PUT", "/caldav/1/personal/abc.ics", strings.Replace(standUp, "stand up", "daily", 1), map[string]string{"If-Match": `"7"`})
+	assert.Equal(t, http.StatusPreconditionFailed, result.status)
+
+	result = owner.do("PUT", "/caldav/1/personal/abc.ics", strings.Replace(standUp, "stand up", "daily", 1), map[string]string{"If-Match": `"1"`})
+	require.Equal(t, http.StatusNoContent, result.status, result.body)
+	assert.Equal(t, `"2"`, result.header.Get("ETag"))
+
+	result = owner.do("REPORT", "/caldav/1/personal/", `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/><C:calendar-data/></D:prop>
+		<D:href>/caldav/1/personal/abc.ics</D:href>
+		<D:href>/caldav/1/personal/9.ics</D:href>
+	</C:calendar-multiget>`, nil)
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Equal(t, `"2"`, result.responses["/caldav/1/personal/abc.ics"]["getetag"])
+	assert.Contains(t, result.responses["/caldav/1/personal/abc.ics"]["calendar-data"], "SUMMARY:daily")
+	assert.Contains(t, result.responses, "/caldav/1/personal/9.ics")
+
+	result = owner.do("PROPFIND", "/caldav/1/personal/", `<propfind xmlns="DAV:" xmlns:CS="http://calendarserver.org/ns/"><prop><CS:getctag/></prop></propfind>`, map[string]string{"Depth": "0"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.NotEqual(t, ctag, result.responses["/caldav/1/personal/"]["getctag"])
+
+	result = owner.do("GET", "/caldav/1/personal/abc.ics", "", nil)
+	require.Equal(t, http.StatusOK, result.status)
+	assert.Equal(t, `"2"`, result.header.Get("ETag"))
+	assert.Contains(t, result.body, "RRULE:FREQ=WEEKLY")
+
+	// other users can not read the home of the user
+	result = reader.do("PROPFIND", "/caldav/1/personal/", "", map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusForbidden, result.status)
+
+	result = owner.do("DELETE", "/caldav/1/personal/abc.ics", "", map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusNoContent, result.status)
+
+	result = owner.do("GET", "/caldav/1/personal/abc.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, result.status)
+}
+
+func TestCalDAV_SharedCalendar(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+	reader := &calDAVClient{t: t, server: server, userId: 2}
+
+	result := owner.do(http.MethodPost, "/api/v1/users/1/calendars", `{"name":"team"}`, nil)
+	require.Equal(t, http.StatusCreated, result.status, result.body)
+	result = owner.do(http.MethodPut, "/api/v1/calendars/1/shares/2", `{"permission":"read"}`, nil)
+	require.Equal(t, http.StatusOK, result.status, result.body)
+
+	result = owner.do("PUT", "/caldav/1/1/new.ics", standUp, nil)
+	require.Equal(t, http.StatusCreated, result.status, result.body)
+	assert.Equal(t, "/caldav/1/1/new.ics", result.header.Get("Location"))
+
+	result = reader.do("PROPFIND", "/caldav/2/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Contains(t, result.responses, "/caldav/2/personal/")
+	require.Contains(t, result.responses, "/caldav/2/1/")
+	assert.Equal(t, "team", result.responses["/caldav/2/1/"]["displayname"])
+	assert.NotContains(t, result.responses["/caldav/2/1/"]["current-user-privilege-set"], "write")
+
+	result = reader.do("PROPFIND", "/caldav/2/1/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Contains(t, result.responses, "/caldav/2/1/new.ics")
+
+	// the event of the calendar is not a personal event of its owner
+	result = owner.do("GET", "/caldav/1/personal/new.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, result.status)
+
+	result = reader.do("PUT", "/caldav/2/1/new.ics", standUp, nil)
+	assert.Equal(t, http.StatusForbidden, result.status)
+
+	result = reader.do("DELETE", "/caldav/2/1/new.ics", "", nil)
+	assert.Equal(t, http.StatusForbidden, result.status)
+}
+
+func TestCalDAV_PutTwice(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+
+	result := owner.do("PUT", "/caldav/1/personal/abc.ics", standUp, nil)
+	require.Equal(t, http.StatusCreated, result.status, result.body)
+
+	// the resource keeps its name, so the second PUT replaces the event instead of creating another one
+	result = owner.do("PUT", "/caldav/1/personal/abc.ics", strings.Replace(standUp, "stand up", "daily", 1), nil)
+	require.Equal(t, http.StatusNoContent, result.status, result.body)
+	assert.Equal(t, `"2"`, result.header.Get("ETag"))
+
+	result = owner.do("PROPFIND", "/caldav/1/personal/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Len(t, result.responses, 2)
+	require.Contains(t, result.responses, "/caldav/1/personal/abc.ics")
+	assert.Contains(t, result.responses["/caldav/1/personal/abc.ics"]["calendar-data"], "SUMMARY:daily")
+	// the client finds the event under its own UID
+	assert.Contains(t, result.responses["/caldav/1/personal/abc.ics"]["calendar-data"], "UID:abc\r\n")
+
+	result = owner.do("DELETE", "/caldav/1/personal/abc.ics", "", nil)
+	require.Equal(t, http.StatusNoContent, result.status, result.body)
+
+	result = owner.do("PROPFIND", "/caldav/1/personal/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Len(t, result.responses, 1)
+
+	result = owner.do("PUT", "/caldav/1/personal/abc", standUp, nil)
+	assert.Equal(t, http.StatusBadRequest, result.status)
+
+	// events created through the API are {id}.ics resources
+	result = owner.do(http.MethodPost, "/create_event", `{"user_id":1,"title":"planning","date":"2026-03-11"}`, nil)
+	require.Equal(t, http.StatusOK, result.status, result.body)
+	result = owner.do("GET", "/caldav/1/personal/2.ics", "", nil)
+	require.Equal(t, http.StatusOK, result.status, result.body)
+	assert.Contains(t, result.body, "UID:2@task_18")
+}
+
+func TestCalDAV_PutInvalid(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+
+	// the event passes the iCalendar decoder, but not the rules of the API
+	result := owner.do("PUT", "/caldav/1/personal/abc.ics", strings.Replace(standUp, "stand up", "x", 1), nil)
+	require.Equal(t, http.StatusBadRequest, result.status, result.body)
+	assert.Contains(t, result.body, `"field":"title"`)
+
+	result = owner.do("PROPFIND", "/caldav/1/personal/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Len(t, result.responses, 1)
+}
+
+func TestImport_Invalid(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+
+	body := strings.Replace(standUp, "END:VCALENDAR", "BEGIN:VEVENT\r\nUID:short\r\nDTSTART:20260312T100000Z\r\n"+
+		"SUMMARY:x\r\nEND:VEVENT\r\nEND:VCALENDAR", 1)
+	result := owner.do(http.MethodPost, "/import?user_id=1", body, nil)
+	require.Equal(t, http.StatusOK, result.status, result.body)
+
+	var response dto.ImportResponse
+	require.NoError(t, json.Unmarshal([]byte(result.body), &response))
+	require.Len(t, response.Result.Imported, 1)
+	assert.Equal(t, "stand up", response.Result.Imported[0].Title)
+	require.Len(t, response.Result.Errors, 1)
+	assert.Equal(t, "short", response.Result.Errors[0].UID)
+	assert.Contains(t, response.Result.Errors[0].Error, "title")
+}
+
+func TestCalDAV_OpenEndedSeries(t *testing.T) {
+	server := newCalDAVServer(t)
+	owner := &calDAVClient{t: t, server: server, userId: 1}
+
+	daily := strings.Replace(standUp, "RRULE:FREQ=WEEKLY", "RRULE:FREQ=DAILY", 1)
+	result := owner.do("PUT", "/caldav/1/personal/standup.ics", daily, nil)
+	require.Equal(t, http.StatusCreated, result.status, result.body)
+
+	// the series is never expanded up to the end of the unbounded window
+	started := time.Now()
+
+	result = owner.do("PROPFIND", "/caldav/1/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.NotEmpty(t, result.responses["/caldav/1/personal/"]["getctag"])
+
+	result = owner.do("GET", "/caldav/1/personal/standup.ics", "", nil)
+	require.Equal(t, http.StatusOK, result.status, result.body)
+
+	result = owner.do("REPORT", "/caldav/1/personal/", `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/></D:prop>
+		<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">
+			<C:time-range start="20300101T000000Z"/>
+		</C:comp-filter></C:comp-filter></C:filter>
+	</C:calendar-query>`, map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, result.status, result.body)
+	assert.Contains(t, result.responses, "/caldav/1/personal/standup.ics")
+
+	assert.Less(t, time.Since(started), time.Second)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+)
+
+const (
+	restUserCalendarsPath = "/api/v1/users/{user_id}/calendars"
+	restCalendarPath      = "/api/v1/calendars/{id}"
+	restSharePath         = restCalendarPath + "/shares/{user_id}"
+)
+
+// registerCalendarRoutes registers the calendar resources, they answer like the versioned event API.
+func registerCalendarRoutes(router *http.ServeMux, handler *EventHandler, middleware middlewares.Middleware) {
+	router.HandleFunc("GET "+restUserCalendarsPath, middleware(handler.ListCalendars))
+	router.HandleFunc("POST "+restUserCalendarsPath, middleware(handler.CreateCalendar))
+	router.HandleFunc("GET "+restCalendarPath, middleware(handler.GetCalendar))
+	router.HandleFunc("PATCH "+restCalendarPath, middleware(handler.RenameCalendar))
+	router.HandleFunc("DELETE "+restCalendarPath, middleware(handler.DeleteCalendar))
+	router.HandleFunc("PUT "+restSharePath, middleware(handler.ShareCalendar))
+	router.HandleFunc("DELETE "+restSharePath, middleware(handler.UnshareCalendar))
+}
+
+// ListCalendars returns the calendars the user owns or which are shared with the user.
+func (eh *EventHandler) ListCalendars(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Calendars] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	calendars, err := eh.service.Calendars(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Calendars] error getting calendars", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	results := make([]*dto.CalendarDto, 0, len(calendars))
+	for _, calendar := range calendars {
+		results = append(results, dto.CalendarDtoFromDomain(calendar))
+	}
+
+	writeJSON(w, http.StatusOK, dto.CalendarsResponse{
+		Result: results,
+	})
+}
+
+func (eh *EventHandler) CreateCalendar(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Create Calendar] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var createCalendarDto dto.CreateCalendar
+	if !eh.decodeBody(w, r, "[Create Calendar]", &createCalendarDto) {
+		return
+	}
+
+	if createCalendarDto.UserId != 0 && createCalendarDto.UserId != userId {
+		writeErrorJSON(w, errUserMismatch.Error(), http.StatusConflict)
+		return
+	}
+	createCalendarDto.UserId = userId
+
+	if err := createCalendarDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[Create Calendar] error validating calendar", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	calendar, err := eh.service.CreateCalendar(r.Context(), createCalendarDto.ToDomain())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Create Calendar] error creating calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/calendars/%d", calendar.ID))
+	writeJSON(w, http.StatusCreated, dto.CalendarResponse{
+		Result: dto.CalendarDtoFromDomain(calendar),
+	})
+}
+
+func (eh *EventHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	calendarId, ok := pathCalendarId(w, r)
+	if !ok {
+		return
+	}
+
+	calendar, err := eh.service.Calendar(r.Context(), calendarId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Calendar] error getting calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.CalendarResponse{
+		Result: dto.CalendarDtoFromDomain(calendar),
+	})
+}
+
+func (eh *EventHandler) RenameCalendar(w http.ResponseWriter, r *http.Request) {
+	calendarId, ok := pathCalendarId(w, r)
+	if !ok {
+		return
+	}
+
+	var renameCalendarDto dto.RenameCalendar
+	if !eh.decodeBody(w, r, "[Rename Calendar]", &renameCalendarDto) {
+		return
+	}
+
+	if err := renameCalendarDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[Rename Calendar] error validating calendar", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	calendar, err := eh.service.RenameCalendar(r.Context(), calendarId, renameCalendarDto.Name)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Rename Calendar] error renaming calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.CalendarResponse{
+		Result: dto.CalendarDtoFromDomain(calendar),
+	})
+}
+
+// DeleteCalendar removes an empty calendar, a calendar which still holds events is answered 409.
+func (eh *EventHandler) DeleteCalendar(w http.ResponseWriter, r *http.Request) {
+	calendarId, ok := pathCalendarId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := eh.service.DeleteCalendar(r.Context(), calendarId); err != nil {
+		slog.ErrorContext(r.Context(), "[Delete Calendar] error deleting calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareCalendar grants the user of the path the permission of the body, replacing the one the user had.
+func (eh *EventHandler) ShareCalendar(w http.ResponseWriter, r *http.Request) {
+	calendarId, ok := pathCalendarId(w, r)
+	if !ok {
+		return
+	}
+
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Share Calendar] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var shareCalendarDto dto.ShareCalendar
+	if !eh.decodeBody(w, r, "[Share Calendar]", &shareCalendarDto) {
+		return
+	}
+
+	if err := shareCalendarDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[Share Calendar] error validating share", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	calendar, err := eh.service.Share(r.Context(), calendarId, userId, domains.Permission(shareCalendarDto.Permission))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Share Calendar] error sharing calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.CalendarResponse{
+		Result: dto.CalendarDtoFromDomain(calendar),
+	})
+}
+
+// UnshareCalendar takes the access away from the user of the path, users may leave a calendar shared with them.
+func (eh *EventHandler) UnshareCalendar(w http.ResponseWriter, r *http.Request) {
+	calendarId, ok := pathCalendarId(w, r)
+	if !ok {
+		return
+	}
+
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Unshare Calendar] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := eh.service.Unshare(r.Context(), calendarId, userId); err != nil {
+		slog.ErrorContext(r.Context(), "[Unshare Calendar] error unsharing calendar", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathCalendarId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	calendarId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Calendar] error converting path calendar id to int", "error", err)
+		writeErrorJSON(w, "invalid calendar id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return calendarId, true
+}
+
+// parseCalendarIds reads the optional calendar_id query parameters, repeated or comma separated.
+// Zero selects the personal events of the user.
+func parseCalendarIds(r *http.Request) ([]int, error) {
+	calendarIds := make([]int, 0)
+
+	for _, value := range r.URL.Query()["calendar_id"] {
+		for _, part := range strings.Split(value, ",") {
+			calendarId, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || calendarId < 0 {
+				return nil, errors.New("invalid calendar id")
+			}
+
+			calendarIds = append(calendarIds, calendarId)
+		}
+	}
+
+	return calendarIds, nil
+}
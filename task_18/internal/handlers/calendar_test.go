@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendars(t *testing.T) {
+	router := newMultiUserRouter()
+
+	rec := serveAs(router, 1, http.MethodPost, "/api/v1/users/1/calendars", `{"name":"team"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "/api/v1/calendars/1", rec.Header().Get("Location"))
+
+	rec = serveAs(router, 1, http.MethodPost, "/api/v1/users/1/calendars", `{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodPut, "/api/v1/calendars/1/shares/2", `{"permission":"write"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var calendar dto.CalendarResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&calendar))
+	assert.Equal(t, []dto.ShareDto{{UserID: 2, Permission: "write"}}, calendar.Result.Shares)
+
+	rec = serveAs(router, 1, http.MethodPut, "/api/v1/calendars/1/shares/2", `{"permission":"owner"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/api/v1/users/2/calendars", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var calendars dto.CalendarsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&calendars))
+	require.Len(t, calendars.Result, 1)
+	assert.Equal(t, "team", calendars.Result[0].Name)
+
+	rec = serveAs(router, 2, http.MethodPost, "/api/v1/users/2/events", `{"title":"planning","date":"2026-03-11","calendar_id":1}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "/api/v1/users/1/events/1", rec.Header().Get("Location"))
+	assert.Equal(t, 1, decodeEvent(t, rec).CalendarID)
+
+	rec = serveAs(router, 2, http.MethodPost, "/api/v1/users/2/events", `{"title":"planning","date":"2026-03-11","calendar_id":7}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/events_for_day?user_id=2&date=2026-03-11&calendar_id=1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "planning")
+
+	rec = serveAs(router, 2, http.MethodGet, "/events_for_day?user_id=2&date=2026-03-11&calendar_id=0", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "planning")
+
+	rec = serveAs(router, 3, http.MethodGet, "/events_for_day?user_id=3&date=2026-03-11&calendar_id=1", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/events_for_day?user_id=2&date=2026-03-11&calendar_id=x", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodPatch, "/api/v1/calendars/1", `{"name":"renamed"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodDelete, "/api/v1/calendars/1", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodDelete, "/api/v1/users/1/events/1", "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, 2, http.MethodDelete, "/api/v1/calendars/1/shares/2", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/api/v1/calendars/1", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodDelete, "/api/v1/calendars/1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodGet, "/api/v1/calendars/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
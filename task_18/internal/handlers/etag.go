@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match, expected a single ETag like \"3\"")
+
+// setETag sets the ETag of the response to the version of the event.
+func setETag(w http.ResponseWriter, event *domains.Event) {
+	w.Header().Set("ETag", etag(event.Version))
+}
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch returns the version an update or delete is based on. Zero means the request has no
+// precondition, a weak ETag never matches as If-Match uses the strong comparison.
+func parseIfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return 0, domains.ErrVersionMismatch
+	}
+
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
+}
+
+// notModified reports whether the If-None-Match header of a read request lists the current ETag of the event.
+func notModified(r *http.Request, event *domains.Event) bool {
+	current := etag(event.Version)
+
+	for value := range strings.SplitSeq(r.Header.Get("If-None-Match"), ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersion reads the If-Match header, it responds itself if the header can not be used.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, tag string) (int, bool) {
+	version, err := parseIfMatch(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), tag+" error parsing If-Match", "error", err)
+		if errors.Is(err, domains.ErrVersionMismatch) {
+			writeErrorJSON(w, err.Error(), http.StatusPreconditionFailed)
+			return 0, false
+		}
+
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+
+	return version, true
+}
@@ -12,24 +12,48 @@ import (
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
 	"github.com/M-kos/wb_level2/task_18/internal/dto"
 	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
 )
 
 type EventService interface {
-	EventsForDay(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error)
-	EventsForWeek(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error)
-	EventsForMonth(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error)
+	EventsForDay(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
+	EventsForWeek(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
+	EventsForMonth(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
 	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
 	Update(ctx context.Context, event *domains.Event) (*domains.Event, error)
-	Delete(ctx context.Context, eventId int) error
+	Delete(ctx context.Context, eventId int, version int) error
+	UpdateOccurrence(ctx context.Context, eventId int, occurrence time.Time, event *domains.Event) (*domains.Event, error)
+	DeleteOccurrence(ctx context.Context, eventId int, occurrence time.Time, version int) error
+	UserEvents(ctx context.Context, userId int) ([]*domains.Event, error)
+	EventsBetween(ctx context.Context, userId int, from, to time.Time, page domains.Page) (*domains.EventPage, error)
+	Event(ctx context.Context, eventId int) (*domains.Event, error)
+	FreeBusy(ctx context.Context, userIds []int, from, to time.Time, length time.Duration) (*domains.FreeBusy, error)
+	History(ctx context.Context, eventId int) ([]*domains.Event, error)
+	Search(ctx context.Context, userId int, query string, from, to time.Time) ([]*domains.Event, error)
+	Batch(ctx context.Context, operations []domains.Operation, atomic bool) ([]services.BatchResult, error)
+	Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) (*domains.EventPage, error)
+	Respond(ctx context.Context, eventId int, userId int, status domains.AttendeeStatus) (*domains.Event, error)
+	Trash(ctx context.Context, userId int) ([]*domains.Event, error)
+	Restore(ctx context.Context, eventId int) (*domains.Event, error)
+	Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error)
+	Calendar(ctx context.Context, calendarId int) (*domains.Calendar, error)
+	CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error)
+	RenameCalendar(ctx context.Context, calendarId int, name string) (*domains.Calendar, error)
+	DeleteCalendar(ctx context.Context, calendarId int) error
+	Share(ctx context.Context, calendarId int, userId int, permission domains.Permission) (*domains.Calendar, error)
+	Unshare(ctx context.Context, calendarId int, userId int) (*domains.Calendar, error)
 }
 
 type EventHandler struct {
-	service EventService
+	service     EventService
+	maxBodySize int64
 }
 
-func NewEventHandler(router *http.ServeMux, service EventService, middleware middlewares.Middleware) {
+// NewEventHandler registers the event routes, maxBodySize limits the body of create and update requests in bytes.
+func NewEventHandler(router *http.ServeMux, service EventService, middleware middlewares.Middleware, maxBodySize int64) {
 	handler := &EventHandler{
-		service: service,
+		service:     service,
+		maxBodySize: maxBodySize,
 	}
 
 	router.HandleFunc("GET /events_for_day", middleware(handler.EventsForDay))
@@ -38,6 +62,18 @@ func NewEventHandler(router *http.ServeMux, service EventService, middleware mid
 	router.HandleFunc("POST /create_event", middleware(handler.Create))
 	router.HandleFunc("POST /update_event/{id}", middleware(handler.Update))
 	router.HandleFunc("POST /delete_event/{id}", middleware(handler.Delete))
+	router.HandleFunc("GET /export.ics", middleware(handler.Export))
+	router.HandleFunc("POST /import", middleware(handler.Import))
+	router.HandleFunc("GET /freebusy", middleware(handler.FreeBusy))
+	router.HandleFunc("GET /events/search", middleware(handler.Search))
+	router.HandleFunc("POST /events/batch", middleware(handler.Batch))
+	router.HandleFunc("GET /invitations", middleware(handler.Invitations))
+	router.HandleFunc("POST /respond_event/{id}", middleware(handler.Respond))
+	router.HandleFunc("GET /trash", middleware(handler.Trash))
+	router.HandleFunc("POST /restore_event/{id}", middleware(handler.Restore))
+
+	registerRESTRoutes(router, handler, middleware)
+	registerCalendarRoutes(router, handler, middleware)
 }
 
 func (eh *EventHandler) EventsForDay(w http.ResponseWriter, r *http.Request) {
@@ -53,28 +89,44 @@ func (eh *EventHandler) EventsForMonth(w http.ResponseWriter, r *http.Request) {
 }
 
 func (eh *EventHandler) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, eh.maxBodySize)
+
 	var createEventDto dto.CreateEvent
 	if err := json.NewDecoder(r.Body).Decode(&createEventDto); err != nil {
-		slog.Error("[Create] error decoding create event", "error", err)
+		slog.ErrorContext(r.Context(), "[Create] error decoding create event", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return
+		}
+
 		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if err := createEventDto.Validate(); err != nil {
-		slog.Error("[Create] error validating create event", "error", err)
-		writeErrorJSON(w, "validation error", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "[Create] error validating create event", "error", err)
+		writeValidationError(w, err)
 		return
 	}
 
 	event, err := eh.service.Create(r.Context(), createEventDto.ToDomain())
 	if err != nil {
-		slog.Error("[Create] error creating event", "error", err)
+		slog.ErrorContext(r.Context(), "[Create] error creating event", "error", err)
+		if writeConflictError(w, err) || writeAccessError(w, err) {
+			return
+		}
+
+		if errors.Is(err, domains.ErrCalendarNotFound) {
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		writeErrorJSON(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	eventDto := dto.EventDtoFromDomain(event)
 
+	setETag(w, event)
 	writeJSON(w, http.StatusOK, dto.EventResponse{
 		Result: eventDto,
 	})
@@ -84,39 +136,86 @@ func (eh *EventHandler) Update(w http.ResponseWriter, r *http.Request) {
 	queryEventId := r.PathValue("id")
 	eventId, err := strconv.Atoi(queryEventId)
 	if err != nil {
-		slog.Error("[Update] error converting query event id to int", "error", err)
+		slog.ErrorContext(r.Context(), "[Update] error converting query event id to int", "error", err)
 		writeErrorJSON(w, "invalid event id", http.StatusBadRequest)
 		return
 	}
 
+	version, ok := ifMatchVersion(w, r, "[Update]")
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, eh.maxBodySize)
+
 	var updateEventDto dto.UpdateEvent
 	if err := json.NewDecoder(r.Body).Decode(&updateEventDto); err != nil {
-		slog.Error("[Update] error decoding update event", "error", err)
+		slog.ErrorContext(r.Context(), "[Update] error decoding update event", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return
+		}
+
 		writeErrorJSON(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if err := updateEventDto.Validate(); err != nil {
-		slog.Error("[Update] error validating update event", "error", err)
-		writeErrorJSON(w, "validation error", http.StatusBadRequest)
+		slog.ErrorContext(r.Context(), "[Update] error validating update event", "error", err)
+		writeValidationError(w, err)
 		return
 	}
 
-	event, err := eh.service.Update(r.Context(), updateEventDto.ToDomain(eventId))
+	occurrence, hasOccurrence, err := parseOccurrence(r)
 	if err != nil {
-		if errors.Is(err, domains.ErrEventNotFound) {
-			slog.Error("[Update] error updating event, event not found", "error", err)
+		slog.ErrorContext(r.Context(), "[Update] error converting query occurrence to time", "error", err)
+		writeErrorJSON(w, "invalid occurrence, expected format: YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	event := updateEventDto.ToDomain(eventId)
+	event.Version = version
+
+	if hasOccurrence {
+		event, err = eh.service.UpdateOccurrence(r.Context(), eventId, occurrence, event)
+	} else {
+		event, err = eh.service.Update(r.Context(), event)
+	}
+	if err != nil {
+		if errors.Is(err, domains.ErrEventNotFound) || errors.Is(err, domains.ErrOccurrenceNotFound) {
+			slog.ErrorContext(r.Context(), "[Update] error updating event, event not found", "error", err)
 			writeErrorJSON(w, err.Error(), http.StatusServiceUnavailable)
 			return
 		}
 
-		slog.Error("[Update] error updating event", "error", err)
+		if errors.Is(err, domains.ErrEventNotRecurring) {
+			slog.ErrorContext(r.Context(), "[Update] error updating occurrence, event is not recurring", "error", err)
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, domains.ErrVersionMismatch) {
+			slog.ErrorContext(r.Context(), "[Update] error updating event, version mismatch", "error", err)
+			writeErrorJSON(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		slog.ErrorContext(r.Context(), "[Update] error updating event", "error", err)
+		if writeConflictError(w, err) || writeAccessError(w, err) {
+			return
+		}
+
+		if errors.Is(err, domains.ErrCalendarNotFound) {
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		writeErrorJSON(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	eventDto := dto.EventDtoFromDomain(event)
 
+	setETag(w, event)
 	writeJSON(w, http.StatusOK, dto.EventResponse{
 		Result: eventDto,
 	})
@@ -126,20 +225,52 @@ func (eh *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	queryEventId := r.PathValue("id")
 	eventId, err := strconv.Atoi(queryEventId)
 	if err != nil {
-		slog.Error("[Delete] error converting query event id to int", "error", err)
+		slog.ErrorContext(r.Context(), "[Delete] error converting query event id to int", "error", err)
 		writeErrorJSON(w, "invalid event id", http.StatusBadRequest)
 		return
 	}
 
-	err = eh.service.Delete(r.Context(), eventId)
+	occurrence, hasOccurrence, err := parseOccurrence(r)
 	if err != nil {
-		if errors.Is(err, domains.ErrEventNotFound) {
-			slog.Error("[Delete] error deleting event, event not found", "error", err)
+		slog.ErrorContext(r.Context(), "[Delete] error converting query occurrence to time", "error", err)
+		writeErrorJSON(w, "invalid occurrence, expected format: YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[Delete]")
+	if !ok {
+		return
+	}
+
+	if hasOccurrence {
+		err = eh.service.DeleteOccurrence(r.Context(), eventId, occurrence, version)
+	} else {
+		err = eh.service.Delete(r.Context(), eventId, version)
+	}
+	if err != nil {
+		if errors.Is(err, domains.ErrEventNotFound) || errors.Is(err, domains.ErrOccurrenceNotFound) {
+			slog.ErrorContext(r.Context(), "[Delete] error deleting event, event not found", "error", err)
 			writeErrorJSON(w, err.Error(), http.StatusServiceUnavailable)
 			return
 		}
 
-		slog.Error("[Delete] error deleting event", "error", err)
+		if errors.Is(err, domains.ErrEventNotRecurring) {
+			slog.ErrorContext(r.Context(), "[Delete] error deleting occurrence, event is not recurring", "error", err)
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, domains.ErrVersionMismatch) {
+			slog.ErrorContext(r.Context(), "[Delete] error deleting event, version mismatch", "error", err)
+			writeErrorJSON(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		slog.ErrorContext(r.Context(), "[Delete] error deleting event", "error", err)
+		if writeAccessError(w, err) {
+			return
+		}
+
 		writeErrorJSON(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -147,55 +278,151 @@ func (eh *EventHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (eh *EventHandler) getEvents(w http.ResponseWriter, r *http.Request, serviceFn func(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error)) {
+// getEvents answers the agenda routes, the optional calendar_id parameters select the calendars to show.
+func (eh *EventHandler) getEvents(w http.ResponseWriter, r *http.Request, serviceFn func(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)) {
 	queryUserId := r.URL.Query().Get("user_id")
 	queryDate := r.URL.Query().Get("date")
 
 	userId, err := strconv.Atoi(queryUserId)
 	if err != nil {
-		slog.Error("[Get Events] error converting query user id to int", "error", err)
+		slog.ErrorContext(r.Context(), "[Get Events] error converting query user id to int", "error", err)
 		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
 		return
 	}
 
-	date, err := time.Parse(time.DateOnly, queryDate)
+	location := time.UTC
+	if queryTimeZone := r.URL.Query().Get("tz"); queryTimeZone != "" {
+		location, err = time.LoadLocation(queryTimeZone)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[Get Events] error loading query time zone", "error", err)
+			writeErrorJSON(w, "invalid tz, expected an IANA time zone name", http.StatusBadRequest)
+			return
+		}
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, queryDate, location)
 	if err != nil {
-		slog.Error("[Get Events] error converting query date to time", "error", err)
+		slog.ErrorContext(r.Context(), "[Get Events] error converting query date to time", "error", err)
 		writeErrorJSON(w, "invalid date, expected format: YYYY-MM-DD", http.StatusBadRequest)
 		return
 	}
 
+	calendarIds, err := parseCalendarIds(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Get Events] error parsing calendar ids", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parseCursorPage(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Get Events] error parsing page", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	select {
 	case <-r.Context().Done():
-		slog.Info("[Get Events] context done")
+		slog.InfoContext(r.Context(), "[Get Events] context done")
 		writeErrorJSON(w, "request cancelled by the client", http.StatusRequestTimeout)
 		return
 	default:
-		events, err := serviceFn(r.Context(), userId, date)
+		events, err := serviceFn(r.Context(), userId, date, page, calendarIds...)
 		if err != nil {
-			slog.Error("[Get Events] error getting events", "error", err)
-			writeErrorJSON(w, "something went wrong", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "[Get Events] error getting events", "error", err)
+			writeServiceError(w, err)
 			return
 		}
 
-		var results []*dto.EventDto
+		writeEventsPage(w, events)
+	}
+}
+
+// parseOccurrence reads the optional occurrence query parameter which addresses
+// a single occurrence of a recurring event instead of the whole series.
+func parseOccurrence(r *http.Request) (time.Time, bool, error) {
+	queryOccurrence := r.URL.Query().Get("occurrence")
+	if queryOccurrence == "" {
+		return time.Time{}, false, nil
+	}
 
-		for _, event := range events {
-			eventDto := dto.EventDtoFromDomain(event)
+	occurrence, err := time.Parse(time.DateOnly, queryOccurrence)
+	if err != nil {
+		return time.Time{}, false, err
+	}
 
-			results = append(results, eventDto)
-		}
+	return occurrence, true, nil
+}
 
-		writeJSON(w, http.StatusOK, dto.EventsResponse{
-			Result: results,
-		})
+// writeAccessError responds to authentication and authorization errors, it reports whether err was one of them.
+func writeAccessError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, domains.ErrUnauthenticated):
+		writeErrorJSON(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domains.ErrForbidden):
+		writeErrorJSON(w, err.Error(), http.StatusForbidden)
+	default:
+		return false
 	}
+
+	return true
+}
+
+// writeConflictError responds 409 with the conflicting events if err is a *domains.ConflictError, it reports whether it did.
+func writeConflictError(w http.ResponseWriter, err error) bool {
+	var conflictErr *domains.ConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+
+	conflicts := make([]*dto.EventDto, 0, len(conflictErr.Conflicts))
+	for _, conflict := range conflictErr.Conflicts {
+		conflicts = append(conflicts, dto.EventDtoFromDomain(conflict))
+	}
+
+	writeJSON(w, http.StatusConflict, dto.ConflictResponse{
+		Error:     conflictErr.Error(),
+		Conflicts: conflicts,
+	})
+
+	return true
+}
+
+// writeBodyTooLarge responds 413 if reading the body failed because of its size, it reports whether it did.
+func writeBodyTooLarge(w http.ResponseWriter, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+
+	writeErrorJSON(w, "request body is too large", http.StatusRequestEntityTooLarge)
+
+	return true
 }
 
 type errorResponse struct {
 	Error string `json:"error"`
 }
 
+type validationErrorResponse struct {
+	Error  string               `json:"error"`
+	Fields dto.ValidationErrors `json:"fields"`
+}
+
+// writeValidationError responds 400 listing the invalid fields if err is a dto.ValidationErrors.
+func writeValidationError(w http.ResponseWriter, err error) {
+	var fields dto.ValidationErrors
+	if !errors.As(err, &fields) {
+		writeErrorJSON(w, "validation error", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusBadRequest, validationErrorResponse{
+		Error:  "validation error",
+		Fields: fields,
+	})
+}
+
 func writeErrorJSON(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+)
+
+const (
+	// maxFreeBusyUsers limits the number of calendars a free/busy request reads
+	maxFreeBusyUsers = 50
+	// defaultSlotLength is the free slot length of requests without a duration
+	defaultSlotLength = 30 * time.Minute
+)
+
+// FreeBusy returns the merged busy intervals of the users in [from, to) and the free slots of at least duration.
+// The users are given as repeated or comma separated user_id parameters.
+func (eh *EventHandler) FreeBusy(w http.ResponseWriter, r *http.Request) {
+	userIds, err := parseUserIds(r.URL.Query()["user_id"])
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[FreeBusy] error parsing user ids", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[FreeBusy] error parsing range", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	length := defaultSlotLength
+	if queryDuration := r.URL.Query().Get("duration"); queryDuration != "" {
+		length, err = time.ParseDuration(queryDuration)
+		if err != nil || length <= 0 {
+			slog.ErrorContext(r.Context(), "[FreeBusy] error parsing duration", "error", err)
+			writeErrorJSON(w, "invalid duration, expected a positive duration like 30m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	freeBusy, err := eh.service.FreeBusy(r.Context(), userIds, from, to, length)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[FreeBusy] error getting free/busy", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.FreeBusyResponse{
+		Result: dto.FreeBusyDtoFromDomain(freeBusy, from.Location()),
+	})
+}
+
+func parseUserIds(values []string) ([]int, error) {
+	userIds := make([]int, 0, len(values))
+
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			userId, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, errors.New("invalid user id")
+			}
+
+			userIds = append(userIds, userId)
+		}
+	}
+
+	if len(userIds) == 0 {
+		return nil, errors.New("user_id is required")
+	}
+
+	if len(userIds) > maxFreeBusyUsers {
+		return nil, errors.New("too many users, at most 50 are allowed")
+	}
+
+	return userIds, nil
+}
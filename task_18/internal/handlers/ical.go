@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/ical"
+)
+
+const maxImportSize = 10 << 20
+
+func (eh *EventHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Export] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	events, err := eh.service.UserEvents(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Export] error getting events", "error", err)
+		if writeAccessError(w, err) {
+			return
+		}
+
+		writeErrorJSON(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	w.WriteHeader(http.StatusOK)
+
+	if err := ical.Encode(w, events, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "[Export] error encoding calendar", "error", err)
+	}
+}
+
+// Import accepts a calendar either as a multipart "file" field or as the raw request body.
+func (eh *EventHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Import] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[Import] error reading uploaded file", "error", err)
+			writeErrorJSON(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		body = file
+	}
+
+	results, err := ical.Decode(body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Import] error decoding calendar", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return
+		}
+
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	importResult := &dto.ImportResult{
+		Imported: make([]*dto.EventDto, 0, len(results)),
+		Errors:   make([]*dto.ImportError, 0),
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			importResult.Errors = append(importResult.Errors, &dto.ImportError{Index: i, UID: result.UID, Error: result.Err.Error()})
+			continue
+		}
+
+		result.Event.UserID = userId
+
+		if err := dto.CreateEventFromDomain(result.Event).Validate(); err != nil {
+			slog.ErrorContext(r.Context(), "[Import] error validating event", "uid", result.UID, "error", err)
+			importResult.Errors = append(importResult.Errors, &dto.ImportError{Index: i, UID: result.UID, Error: err.Error()})
+			continue
+		}
+
+		event, err := eh.service.Create(r.Context(), result.Event)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[Import] error creating event", "uid", result.UID, "error", err)
+			importResult.Errors = append(importResult.Errors, &dto.ImportError{Index: i, UID: result.UID, Error: err.Error()})
+			continue
+		}
+
+		importResult.Imported = append(importResult.Imported, dto.EventDtoFromDomain(event))
+	}
+
+	writeJSON(w, http.StatusOK, dto.ImportResponse{
+		Result: importResult,
+	})
+}
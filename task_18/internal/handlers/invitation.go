@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+)
+
+// Invitations returns a page of the events the user is invited to ordered by date, with the answers of all attendees.
+// Recurring events are returned as series, status filters by the answer of the user.
+func (eh *EventHandler) Invitations(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Invitations] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	status := domains.AttendeeStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", domains.AttendeePending, domains.AttendeeAccepted, domains.AttendeeDeclined, domains.AttendeeTentative:
+	default:
+		writeErrorJSON(w, "invalid status, expected pending, accepted, declined or tentative", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parseCursorPage(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Invitations] error parsing page", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	invitations, err := eh.service.Invitations(r.Context(), userId, status, page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Invitations] error getting invitations", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeEventsPage(w, invitations)
+}
+
+// Respond stores the answer of an invited user to the invitation to the event.
+func (eh *EventHandler) Respond(w http.ResponseWriter, r *http.Request) {
+	eventId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Respond] error converting path event id to int", "error", err)
+		writeErrorJSON(w, "invalid event id", http.StatusBadRequest)
+		return
+	}
+
+	var respondEventDto dto.RespondEvent
+	if !eh.decodeBody(w, r, "[Respond]", &respondEventDto) {
+		return
+	}
+
+	if err := respondEventDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[Respond] error validating answer", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	event, err := eh.service.Respond(r.Context(), eventId, respondEventDto.UserId, domains.AttendeeStatus(respondEventDto.Status))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Respond] error storing answer", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, dto.EventResponse{
+		Result: dto.EventDtoFromDomain(event),
+	})
+}
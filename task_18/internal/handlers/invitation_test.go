@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMultiUserRouter authenticates the requests as the user of the X-User-Id header.
+func newMultiUserRouter() *http.ServeMux {
+	router := http.NewServeMux()
+	service := services.NewEventService(repositories.NewEventRepository(), services.ConflictReject)
+	asUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			userId, _ := strconv.Atoi(r.Header.Get("X-User-Id"))
+			next(w, r.WithContext(auth.WithUser(r.Context(), userId)))
+		}
+	}
+
+	NewEventHandler(router, service, asUser, 1<<20)
+
+	return router
+}
+
+func serveAs(router http.Handler, userId int, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-User-Id", strconv.Itoa(userId))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestInvitations(t *testing.T) {
+	router := newMultiUserRouter()
+
+	rec := serveAs(router, 1, http.MethodPost, "/create_event", `{"user_id":1,"title":"planning","date":"2026-03-11","attendees":[2,3]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []dto.AttendeeDto{{UserID: 2, Status: "pending"}, {UserID: 3, Status: "pending"}}, decodeEvent(t, rec).Attendees)
+
+	rec = serveAs(router, 1, http.MethodPost, "/create_event", `{"user_id":1,"title":"alone","date":"2026-03-11","attendees":[1]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodGet, "/invitations?user_id=2&status=pending", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page dto.EventsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Result, 1)
+	assert.Equal(t, "planning", page.Result[0].Title)
+
+	rec = serveAs(router, 2, http.MethodPost, "/respond_event/1", `{"user_id":2,"status":"accepted"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "accepted", decodeEvent(t, rec).Attendees[0].Status)
+
+	rec = serveAs(router, 2, http.MethodGet, "/events_for_day?user_id=2&date=2026-03-11", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "planning")
+
+	rec = serveAs(router, 2, http.MethodGet, "/invitations?user_id=2&status=pending", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	assert.Empty(t, page.Result)
+
+	rec = serveAs(router, 4, http.MethodPost, "/respond_event/1", `{"user_id":4,"status":"accepted"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodPost, "/respond_event/1", `{"user_id":2,"status":"pending"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodPost, "/respond_event/1", `{"user_id":3,"status":"declined"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+	"github.com/M-kos/wb_level2/task_18/internal/openapi"
+)
+
+//go:embed swagger.html
+var swaggerPage []byte
+
+// NewDocsHandler serves the OpenAPI document of the event routes at /openapi.json and a Swagger UI page at /docs.
+func NewDocsHandler(router *http.ServeMux, middleware middlewares.Middleware) {
+	document, err := json.Marshal(APIDocument())
+	if err != nil {
+		// the document is built from static types, so this is a programming error
+		panic(err)
+	}
+
+	router.HandleFunc("GET /openapi.json", middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(document); err != nil {
+			slog.ErrorContext(r.Context(), "[OpenAPI] error writing document", "error", err)
+		}
+	}))
+	router.HandleFunc("GET /docs", middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := w.Write(swaggerPage); err != nil {
+			slog.ErrorContext(r.Context(), "[OpenAPI] error writing docs page", "error", err)
+		}
+	}))
+}
+
+// APIDocument describes the routes registered by NewEventHandler, the schemas are generated from the dto types.
+func APIDocument() *openapi.Document {
+	g := openapi.NewGenerator()
+	spec := &apiSpec{
+		generator:  g,
+		errorBody:  g.Schema(errorResponse{}),
+		invalidDto: g.Schema(validationErrorResponse{}),
+		conflict:   g.Schema(dto.ConflictResponse{}),
+	}
+
+	eventResponse := g.Schema(dto.EventResponse{})
+	eventsResponse := g.Schema(dto.EventsResponse{})
+	userQuery := spec.param("user_id", "query", "id of the calendar owner", true, "integer", "")
+	userPath := spec.param("user_id", "path", "id of the calendar owner", true, "integer", "")
+	eventPath := spec.param("id", "path", "event id", true, "integer", "")
+	occurrence := spec.param("occurrence", "query", "addresses the occurrence of a recurring event on this day instead of the series", false, "string", "date")
+	timeZone := spec.param("tz", "query", "IANA time zone the dates are interpreted in, UTC by default", false, "string", "")
+	ifMatch := spec.param("If-Match", "header", "ETag of the version the change is based on, 412 if the event changed since", false, "string", "")
+	eTag := map[string]*openapi.Header{"ETag": {Description: "version of the event", Schema: &openapi.Schema{Type: "string"}}}
+	calendarResponse := g.Schema(dto.CalendarResponse{})
+	calendarPath := spec.param("id", "path", "calendar id", true, "integer", "")
+
+	paths := map[string]openapi.PathItem{
+		"/events_for_day":   {"get": spec.period("eventsForDay", "Events of the day", userQuery, timeZone, eventsResponse)},
+		"/events_for_week":  {"get": spec.period("eventsForWeek", "Events of the week starting on monday", userQuery, timeZone, eventsResponse)},
+		"/events_for_month": {"get": spec.period("eventsForMonth", "Events of the month", userQuery, timeZone, eventsResponse)},
+		"/create_event": {"post": &openapi.Operation{
+			OperationID: "createEventLegacy",
+			Summary:     "Create an event",
+			Tags:        []string{"legacy"},
+			RequestBody: spec.body(dto.CreateEvent{}),
+			Responses: spec.withHeaders(
+				spec.responses(http.StatusOK, "created event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge),
+				http.StatusOK, eTag,
+			),
+		}},
+		"/update_event/{id}": {"post": &openapi.Operation{
+			OperationID: "updateEventLegacy",
+			Summary:     "Replace an event or one occurrence of a recurring event",
+			Tags:        []string{"legacy"},
+			Parameters:  []*openapi.Parameter{eventPath, occurrence, ifMatch},
+			RequestBody: spec.body(dto.UpdateEvent{}),
+			Responses: spec.withHeaders(
+				spec.responses(http.StatusOK, "updated event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge, http.StatusServiceUnavailable),
+				http.StatusOK, eTag,
+			),
+		}},
+		"/delete_event/{id}": {"post": &openapi.Operation{
+			OperationID: "deleteEventLegacy",
+			Summary:     "Delete an event or one occurrence of a recurring event",
+			Tags:        []string{"legacy"},
+			Parameters:  []*openapi.Parameter{eventPath, occurrence, ifMatch},
+			Responses:   spec.responses(http.StatusOK, "event deleted", nil, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusPreconditionFailed, http.StatusServiceUnavailable),
+		}},
+		"/export.ics": {"get": &openapi.Operation{
+			OperationID: "exportCalendar",
+			Summary:     "Export the events of a user as an iCalendar file",
+			Tags:        []string{"ical"},
+			Parameters:  []*openapi.Parameter{userQuery},
+			Responses: spec.withErrors(map[string]*openapi.Response{
+				"200": {
+					Description: "RFC 5545 calendar",
+					Content:     map[string]*openapi.MediaType{"text/calendar": {Schema: &openapi.Schema{Type: "string"}}},
+				},
+			}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		"/import": {"post": &openapi.Operation{
+			OperationID: "importCalendar",
+			Summary:     "Import the events of an iCalendar file",
+			Description: "The calendar is sent as the raw body or as the file field of a multipart form.",
+			Tags:        []string{"ical"},
+			Parameters:  []*openapi.Parameter{userQuery},
+			RequestBody: &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					"text/calendar": {Schema: &openapi.Schema{Type: "string"}},
+					"multipart/form-data": {Schema: &openapi.Schema{
+						Type:       "object",
+						Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+						Required:   []string{"file"},
+					}},
+				},
+			},
+			Responses: spec.responses(http.StatusOK, "imported events and the errors of the skipped ones", g.Schema(dto.ImportResponse{}), http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestEntityTooLarge),
+		}},
+		"/freebusy": {"get": &openapi.Operation{
+			OperationID: "freeBusy",
+			Summary:     "Busy intervals of users and the free slots between them",
+			Description: "Only events with an end take up time, the events themselves are not returned.",
+			Tags:        []string{"events"},
+			Parameters: []*openapi.Parameter{
+				spec.param("user_id", "query", "ids of the users, repeated or comma separated, at most 50", true, "string", ""),
+				spec.param("from", "query", "start of the window, a date or an RFC 3339 time", true, "string", ""),
+				spec.param("to", "query", "end of the window, exclusive, at most 366 days after from", true, "string", ""),
+				spec.param("duration", "query", "minimal length of the free slots like 45m, 30m by default", false, "string", ""),
+				timeZone,
+			},
+			Responses: spec.responses(http.StatusOK, "busy intervals and free slots", g.Schema(dto.FreeBusyResponse{}), http.StatusBadRequest, http.StatusUnauthorized),
+		}},
+		"/events/search": {"get": &openapi.Operation{
+			OperationID: "searchEvents",
+			Summary:     "Search the events of a user by the words of their title and description",
+			Description: "Every word of q has to start a word of the event, case and ё/е are ignored. Without from and to recurring events are returned as series.",
+			Tags:        []string{"events"},
+			Parameters: []*openapi.Parameter{
+				userQuery,
+				spec.param("q", "query", "words to search for, at most 256 bytes", true, "string", ""),
+				spec.param("from", "query", "start of the range, a date or an RFC 3339 time, required with to", false, "string", ""),
+				spec.param("to", "query", "end of the range, exclusive, at most 366 days after from", false, "string", ""),
+				timeZone,
+				spec.param("limit", "query", "page size from 1 to 100, 20 by default", false, "integer", ""),
+				spec.param("offset", "query", "number of found events to skip", false, "integer", ""),
+			},
+			Responses: spec.responses(http.StatusOK, "page of found events", g.Schema(dto.SearchResponse{}), http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		"/events/batch": {"post": &openapi.Operation{
+			OperationID: "batchEvents",
+			Summary:     "Create, update and delete events in one request",
+			Description: "Events are CreateEvent bodies for create and UpdateEvent bodies for update, version is checked like If-Match. " +
+				"An atomic batch, the default, applies all operations or none and fails with the status of the failed operation, " +
+				"the others have 424. A best_effort batch applies every valid operation and responds 200. At most 1000 operations.",
+			Tags:        []string{"events"},
+			RequestBody: spec.body(dto.BatchRequest{}),
+			Responses: spec.responses(http.StatusOK, "result of every operation", g.Schema(dto.BatchResponse{}),
+				http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+				http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge),
+		}},
+		"/events/stream": {"get": &openapi.Operation{
+			OperationID: "streamChanges",
+			Summary:     "Server-Sent Events stream of the changes of the user's events",
+			Description: "Every change is a created, updated or deleted event whose id is the change id and whose data holds the type, the event id and the event, omitted when deleted. " +
+				"A client reconnecting with Last-Event-ID first gets the changes it missed, a reset event tells it they are no longer buffered.",
+			Tags: []string{"events"},
+			Parameters: []*openapi.Parameter{
+				userQuery,
+				spec.param("Last-Event-ID", "header", "id of the last change received, resumes the stream after it", false, "integer", ""),
+			},
+			Responses: spec.withErrors(map[string]*openapi.Response{
+				"200": {
+					Description: "stream of changes, kept open with comment pings",
+					Content:     map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}},
+				},
+			}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		"/invitations": {"get": &openapi.Operation{
+			OperationID: "listInvitations",
+			Summary:     "Events the user is invited to, recurring events as series",
+			Tags:        []string{"invitations"},
+			Parameters: []*openapi.Parameter{
+				spec.param("user_id", "query", "id of the invited user", true, "integer", ""),
+				spec.param("status", "query", "only invitations with this answer: pending, accepted, declined or tentative", false, "string", ""),
+				spec.limit(),
+				spec.cursor(),
+			},
+			Responses: spec.responses(http.StatusOK, "page of events", eventsResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		"/respond_event/{id}": {"post": &openapi.Operation{
+			OperationID: "respondEvent",
+			Summary:     "Answer an invitation to an event",
+			Description: "The answer changes the event, so its version grows.",
+			Tags:        []string{"invitations"},
+			Parameters:  []*openapi.Parameter{eventPath},
+			RequestBody: spec.body(dto.RespondEvent{}),
+			Responses: spec.withHeaders(
+				spec.responses(http.StatusOK, "event with the answer", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed),
+				http.StatusOK, eTag,
+			),
+		}},
+		"/trash": {"get": &openapi.Operation{
+			OperationID: "listTrash",
+			Summary:     "Deleted events of the user, recurring events as series",
+			Description: "Trashed events are purged for good once the retention period has passed.",
+			Tags:        []string{"trash"},
+			Parameters:  []*openapi.Parameter{userQuery, spec.limit(), spec.cursor()},
+			Responses:   spec.responses(http.StatusOK, "page of events", eventsResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		"/restore_event/{id}": {"post": &openapi.Operation{
+			OperationID: "restoreEvent",
+			Summary:     "Move an event from the trash back to the events",
+			Description: "The restored event gets the next version. An event whose calendar has been deleted becomes a personal event of its owner.",
+			Tags:        []string{"trash"},
+			Parameters:  []*openapi.Parameter{eventPath},
+			Responses: spec.withHeaders(
+				spec.responses(http.StatusOK, "restored event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict),
+				http.StatusOK, eTag,
+			),
+		}},
+		"/audit": {"get": &openapi.Operation{
+			OperationID: "listAudit",
+			Summary:     "Changes of the events of the user and changes made by the user, newest first",
+			Description: "Only served when an audit sink is configured. Older entries are paged with before.",
+			Tags:        []string{"audit"},
+			Parameters: []*openapi.Parameter{
+				spec.param("user_id", "query", "user id", true, "integer", ""),
+				spec.param("event_id", "query", "only changes of this event", false, "integer", ""),
+				spec.param("actor_id", "query", "only changes made by this user", false, "integer", ""),
+				spec.param("action", "query", "only changes of this kind: created, updated, deleted, restored or purged", false, "string", ""),
+				spec.param("from", "query", "only changes at or after, YYYY-MM-DD or RFC 3339", false, "string", ""),
+				spec.param("to", "query", "only changes before, YYYY-MM-DD or RFC 3339", false, "string", ""),
+				spec.param("before", "query", "only entries older than the entry with this id", false, "integer", ""),
+				spec.limit(),
+			},
+			Responses: spec.responses(http.StatusOK, "audit entries", g.Schema(dto.AuditResponse{}), http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+		}},
+		restUserCalendarsPath: {
+			"get": {
+				OperationID: "listCalendars",
+				Summary:     "Calendars the user owns or which are shared with the user",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{spec.param("user_id", "path", "user id", true, "integer", "")},
+				Responses:   spec.responses(http.StatusOK, "calendars", g.Schema(dto.CalendarsResponse{}), http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+			},
+			"post": {
+				OperationID: "createCalendar",
+				Summary:     "Create a calendar",
+				Description: "user_id may be omitted from the body, it has to match the path otherwise.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{spec.param("user_id", "path", "id of the owner", true, "integer", "")},
+				RequestBody: spec.body(dto.CreateCalendar{}),
+				Responses: spec.withHeaders(
+					spec.responses(http.StatusCreated, "created calendar", calendarResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict),
+					http.StatusCreated, map[string]*openapi.Header{
+						"Location": {Description: "URL of the created calendar", Schema: &openapi.Schema{Type: "string"}},
+					},
+				),
+			},
+		},
+		restCalendarPath: {
+			"get": {
+				OperationID: "getCalendar",
+				Summary:     "Get a calendar",
+				Description: "Needs the read permission.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{calendarPath},
+				Responses:   spec.responses(http.StatusOK, "calendar", calendarResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+			},
+			"patch": {
+				OperationID: "renameCalendar",
+				Summary:     "Rename a calendar",
+				Description: "Needs the admin permission.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{calendarPath},
+				RequestBody: spec.body(dto.RenameCalendar{}),
+				Responses:   spec.responses(http.StatusOK, "renamed calendar", calendarResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+			},
+			"delete": {
+				OperationID: "deleteCalendar",
+				Summary:     "Delete an empty calendar",
+				Description: "Only the owner may delete a calendar, 409 while it still holds events.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{calendarPath},
+				Responses:   spec.responses(http.StatusNoContent, "calendar deleted", nil, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict),
+			},
+		},
+		restSharePath: {
+			"put": {
+				OperationID: "shareCalendar",
+				Summary:     "Share a calendar with a user or change the permission of the user",
+				Description: "Needs the admin permission. read shows the events, write also changes them and admin also manages the calendar.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{calendarPath, spec.param("user_id", "path", "id of the user to share with", true, "integer", "")},
+				RequestBody: spec.body(dto.ShareCalendar{}),
+				Responses:   spec.responses(http.StatusOK, "shared calendar", calendarResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+			},
+			"delete": {
+				OperationID: "unshareCalendar",
+				Summary:     "Stop sharing a calendar with a user",
+				Description: "Needs the admin permission, users may leave a calendar shared with them.",
+				Tags:        []string{"calendars"},
+				Parameters:  []*openapi.Parameter{calendarPath, spec.param("user_id", "path", "id of the user", true, "integer", "")},
+				Responses:   spec.responses(http.StatusNoContent, "share removed", nil, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+			},
+		},
+		restEventsPath: {
+			"get": {
+				OperationID: "listEvents",
+				Summary:     "Events in a range, recurring events are expanded into occurrences",
+				Tags:        []string{"events"},
+				Parameters: []*openapi.Parameter{
+					userPath,
+					spec.param("from", "query", "start of the range, a date or an RFC 3339 time", true, "string", ""),
+					spec.param("to", "query", "end of the range, exclusive, at most 366 days after from", true, "string", ""),
+					timeZone,
+					spec.limit(),
+					spec.cursor(),
+				},
+				Responses: spec.responses(http.StatusOK, "events", eventsResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+			},
+			"post": {
+				OperationID: "createEvent",
+				Summary:     "Create an event",
+				Description: "user_id may be omitted from the body, it has to match the path otherwise.",
+				Tags:        []string{"events"},
+				Parameters:  []*openapi.Parameter{userPath},
+				RequestBody: spec.body(dto.CreateEvent{}),
+				Responses: spec.withHeaders(
+					spec.responses(http.StatusCreated, "created event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge),
+					http.StatusCreated, map[string]*openapi.Header{
+						"Location": {Description: "URL of the created event", Schema: &openapi.Schema{Type: "string"}},
+						"ETag":     eTag["ETag"],
+					},
+				),
+			},
+		},
+		restEventPath: {
+			"get": {
+				OperationID: "getEvent",
+				Summary:     "Get an event",
+				Tags:        []string{"events"},
+				Parameters: []*openapi.Parameter{
+					userPath,
+					eventPath,
+					spec.param("If-None-Match", "header", "ETag of a cached version, 304 if the event did not change", false, "string", ""),
+				},
+				Responses: spec.withHeaders(
+					spec.withHeaders(
+						spec.responses(http.StatusOK, "event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+						http.StatusOK, eTag,
+					),
+					http.StatusNotModified, eTag,
+				),
+			},
+			"put": {
+				OperationID: "replaceEvent",
+				Summary:     "Replace an event or one occurrence of a recurring event",
+				Tags:        []string{"events"},
+				Parameters:  []*openapi.Parameter{userPath, eventPath, occurrence, ifMatch},
+				RequestBody: spec.body(dto.UpdateEvent{}),
+				Responses: spec.withHeaders(
+					spec.responses(http.StatusOK, "updated event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge),
+					http.StatusOK, eTag,
+				),
+			},
+			"patch": {
+				OperationID: "patchEvent",
+				Summary:     "Change some fields of an event",
+				Description: "Absent fields keep their values, an empty end or description clears it and a null recurrence ends the series.",
+				Tags:        []string{"events"},
+				Parameters:  []*openapi.Parameter{userPath, eventPath, occurrence, ifMatch},
+				RequestBody: spec.body(dto.PatchEvent{}),
+				Responses: spec.withHeaders(
+					spec.responses(http.StatusOK, "updated event", eventResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge),
+					http.StatusOK, eTag,
+				),
+			},
+			"delete": {
+				OperationID: "deleteEvent",
+				Summary:     "Delete an event or one occurrence of a recurring event",
+				Description: "A deleted event moves to the trash, a deleted occurrence is gone for good.",
+				Tags:        []string{"events"},
+				Parameters:  []*openapi.Parameter{userPath, eventPath, occurrence, ifMatch},
+				Responses:   spec.responses(http.StatusNoContent, "event deleted", nil, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed),
+			},
+		},
+		restEventPath + "/history": {
+			"get": {
+				OperationID: "eventHistory",
+				Summary:     "Previous versions of an event, oldest first",
+				Tags:        []string{"events"},
+				Parameters:  []*openapi.Parameter{userPath, eventPath},
+				Responses:   spec.responses(http.StatusOK, "previous versions", eventsResponse, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound),
+			},
+		},
+	}
+
+	// every POST route takes an idempotency key, see middlewares.Idempotency
+	idempotencyKey := spec.param("Idempotency-Key", "header", "makes the request safe to retry, the first response to the key is replayed for identical retries", false, "string", "")
+	for _, item := range paths {
+		if operation, ok := item["post"]; ok {
+			operation.Parameters = append(operation.Parameters, idempotencyKey)
+			spec.withErrors(operation.Responses, http.StatusUnprocessableEntity)
+		}
+	}
+
+	return &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Calendar API",
+			Version: "1.0.0",
+		},
+		Paths: paths,
+		Components: openapi.Components{
+			Schemas: g.Schemas(),
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []openapi.SecurityRequirement{{"bearerAuth": {}}},
+	}
+}
+
+// apiSpec holds the shared parts of the operations of APIDocument.
+type apiSpec struct {
+	generator  *openapi.Generator
+	errorBody  *openapi.Schema
+	invalidDto *openapi.Schema
+	conflict   *openapi.Schema
+}
+
+func (s *apiSpec) param(name, in, description string, required bool, schemaType, format string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          in,
+		Description: description,
+		Required:    required,
+		Schema:      &openapi.Schema{Type: schemaType, Format: format},
+	}
+}
+
+func (s *apiSpec) limit() *openapi.Parameter {
+	return s.param("limit", "query", "page size from 1 to 1000, 100 by default", false, "integer", "")
+}
+
+func (s *apiSpec) cursor() *openapi.Parameter {
+	return s.param("cursor", "query", "next_cursor of the previous page, the first page is returned without it", false, "string", "")
+}
+
+func (s *apiSpec) period(operationId, summary string, user, timeZone *openapi.Parameter, result *openapi.Schema) *openapi.Operation {
+	return &openapi.Operation{
+		OperationID: operationId,
+		Summary:     summary,
+		Tags:        []string{"legacy"},
+		Parameters: []*openapi.Parameter{
+			user,
+			s.param("date", "query", "any day of the period", true, "string", "date"),
+			timeZone,
+			s.param("calendar_id", "query", "only events of these calendars, repeated or comma separated, 0 selects the personal events", false, "string", ""),
+			s.limit(),
+			s.cursor(),
+		},
+		Responses: s.responses(http.StatusOK, "events", result, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusRequestTimeout),
+	}
+}
+
+func (s *apiSpec) body(request any) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content:  openapi.JSON(s.generator.Schema(request)),
+	}
+}
+
+// responses returns the success response with an optional JSON body and the given error responses.
+func (s *apiSpec) responses(status int, description string, result *openapi.Schema, errorStatuses ...int) map[string]*openapi.Response {
+	success := &openapi.Response{Description: description}
+	if result != nil {
+		success.Content = openapi.JSON(result)
+	}
+
+	return s.withErrors(map[string]*openapi.Response{strconv.Itoa(status): success}, errorStatuses...)
+}
+
+func (s *apiSpec) withErrors(responses map[string]*openapi.Response, errorStatuses ...int) map[string]*openapi.Response {
+	for _, status := range errorStatuses {
+		schema := s.errorBody
+		description := http.StatusText(status)
+		switch status {
+		case http.StatusBadRequest:
+			// fields is only set when the body failed validation
+			schema = s.invalidDto
+		case http.StatusConflict:
+			// conflicts is only set when the event overlaps other events
+			schema = s.conflict
+		}
+
+		responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: description,
+			Content:     openapi.JSON(schema),
+		}
+	}
+
+	return responses
+}
+
+// withHeaders sets the headers of the response with the status, a response without a body is added if it is missing.
+func (s *apiSpec) withHeaders(responses map[string]*openapi.Response, status int, headers map[string]*openapi.Header) map[string]*openapi.Response {
+	response, ok := responses[strconv.Itoa(status)]
+	if !ok {
+		response = &openapi.Response{Description: http.StatusText(status)}
+		responses[strconv.Itoa(status)] = response
+	}
+
+	response.Headers = headers
+
+	return responses
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsHandler(t *testing.T) {
+	router := http.NewServeMux()
+	NewDocsHandler(router, func(next http.HandlerFunc) http.HandlerFunc { return next })
+
+	rec := serve(router, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var document openapi.Document
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&document))
+	assert.Equal(t, openapi.Version, document.OpenAPI)
+
+	require.Contains(t, document.Paths, "/api/v1/users/{user_id}/events/{id}")
+	assert.Contains(t, document.Paths["/api/v1/users/{user_id}/events/{id}"], "patch")
+	assert.Contains(t, document.Paths["/create_event"]["post"].Responses, "400")
+	assert.Contains(t, document.Paths["/create_event"]["post"].Responses, "422")
+	assert.Contains(t, document.Paths["/api/v1/users/{user_id}/events/{id}"]["get"].Responses, "304")
+	assert.Contains(t, document.Paths["/api/v1/users/{user_id}/events/{id}"]["put"].Responses, "412")
+	assert.Contains(t, document.Paths, "/api/v1/users/{user_id}/events/{id}/history")
+
+	require.Contains(t, document.Paths, "/events/stream")
+	stream := document.Paths["/events/stream"]["get"]
+	require.NotNil(t, stream)
+	assert.Contains(t, stream.Responses["200"].Content, "text/event-stream")
+	parameters := make(map[string]string)
+	for _, parameter := range stream.Parameters {
+		parameters[parameter.Name] = parameter.In
+	}
+	assert.Equal(t, map[string]string{"user_id": "query", "Last-Event-ID": "header"}, parameters)
+
+	createEvent := document.Components.Schemas["CreateEvent"]
+	require.NotNil(t, createEvent)
+	assert.ElementsMatch(t, []string{"user_id", "title"}, createEvent.Required)
+	assert.Equal(t, "date", createEvent.Properties["date"].Format)
+	assert.Equal(t, "#/components/schemas/Recurrence", createEvent.Properties["recurrence"].Ref)
+	assert.Contains(t, document.Components.Schemas, "ValidationErrorResponse")
+
+	rec = serve(router, http.MethodGet, "/docs", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/openapi.json")
+	assert.Regexp(t, `swagger-ui-dist@\d+\.\d+\.\d+/swagger-ui-bundle\.js`, rec.Body.String())
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+)
+
+const (
+	// defaultPageLimit is the page size of listings without a limit
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+var errInvalidCursor = errors.New("invalid cursor, pass the next_cursor of the previous page")
+
+// parseCursorPage reads the optional limit and cursor query parameters of a listing.
+func parseCursorPage(r *http.Request) (domains.Page, error) {
+	page := domains.Page{Limit: defaultPageLimit}
+	if queryLimit := r.URL.Query().Get("limit"); queryLimit != "" {
+		var err error
+		page.Limit, err = strconv.Atoi(queryLimit)
+		if err != nil || page.Limit < 1 || page.Limit > maxPageLimit {
+			return domains.Page{}, errors.New("invalid limit, expected a number from 1 to 1000")
+		}
+	}
+
+	queryCursor := r.URL.Query().Get("cursor")
+	if queryCursor == "" {
+		return page, nil
+	}
+
+	cursor, err := decodeCursor(queryCursor)
+	if err != nil {
+		return domains.Page{}, err
+	}
+	page.After = cursor
+
+	return page, nil
+}
+
+// encodeCursor returns the opaque form of the cursor handed out to clients.
+func encodeCursor(cursor *domains.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	raw := strconv.FormatInt(cursor.Date.UnixNano(), 10) + "." + strconv.Itoa(cursor.ID)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(value string) (*domains.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	eventId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &domains.Cursor{Date: time.Unix(0, unixNano), ID: eventId}, nil
+}
+
+// writeEventsPage responds with the page of events and the total number of events of the listing.
+func writeEventsPage(w http.ResponseWriter, page *domains.EventPage) {
+	results := make([]*dto.EventDto, 0, len(page.Events))
+	for _, event := range page.Events {
+		results = append(results, dto.EventDtoFromDomain(event))
+	}
+
+	writeJSON(w, http.StatusOK, dto.EventsResponse{
+		Result:     results,
+		Total:      page.Total,
+		NextCursor: encodeCursor(page.Next),
+	})
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+)
+
+const (
+	restEventsPath = "/api/v1/users/{user_id}/events"
+	restEventPath  = restEventsPath + "/{id}"
+
+	// maxRangeWindow limits the range of a list request, recurring events are expanded inside it
+	maxRangeWindow = 366 * 24 * time.Hour
+)
+
+var errUserMismatch = errors.New("user_id in the body does not match the path")
+
+// registerRESTRoutes registers the versioned resource API, unlike the legacy routes it
+// answers 404 for missing events, 201 for created and 204 for deleted ones.
+func registerRESTRoutes(router *http.ServeMux, handler *EventHandler, middleware middlewares.Middleware) {
+	router.HandleFunc("GET "+restEventsPath, middleware(handler.ListEvents))
+	router.HandleFunc("POST "+restEventsPath, middleware(handler.CreateEvent))
+	router.HandleFunc("GET "+restEventPath, middleware(handler.GetEvent))
+	router.HandleFunc("PUT "+restEventPath, middleware(handler.ReplaceEvent))
+	router.HandleFunc("PATCH "+restEventPath, middleware(handler.PatchEvent))
+	router.HandleFunc("DELETE "+restEventPath, middleware(handler.DeleteEvent))
+	router.HandleFunc("GET "+restEventPath+"/history", middleware(handler.EventHistory))
+}
+
+// ListEvents returns a page of the events in [from, to) ordered by date and id,
+// both accept a YYYY-MM-DD date in tz or an RFC 3339 time.
+func (eh *EventHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST List] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseRange(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST List] error parsing range", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parseCursorPage(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST List] error parsing page", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := eh.service.EventsBetween(r.Context(), userId, from, to, page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST List] error getting events", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeEventsPage(w, events)
+}
+
+// GetEvent returns the event with its version as the ETag, If-None-Match with that ETag is answered 304.
+func (eh *EventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := eh.pathEvent(w, r)
+	if !ok {
+		return
+	}
+
+	setETag(w, event)
+	if notModified(r, event) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.EventResponse{
+		Result: dto.EventDtoFromDomain(event),
+	})
+}
+
+func (eh *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST Create] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var createEventDto dto.CreateEvent
+	if !eh.decodeBody(w, r, "[REST Create]", &createEventDto) {
+		return
+	}
+
+	if createEventDto.UserId != 0 && createEventDto.UserId != userId {
+		writeErrorJSON(w, errUserMismatch.Error(), http.StatusConflict)
+		return
+	}
+	createEventDto.UserId = userId
+
+	if err := createEventDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), "[REST Create] error validating create event", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	event, err := eh.service.Create(r.Context(), createEventDto.ToDomain())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST Create] error creating event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/users/%d/events/%d", event.UserID, event.ID))
+	setETag(w, event)
+	writeJSON(w, http.StatusCreated, dto.EventResponse{
+		Result: dto.EventDtoFromDomain(event),
+	})
+}
+
+// ReplaceEvent stores the body as the new state of the event,
+// with an occurrence query it detaches that occurrence of a recurring event instead.
+// An If-Match header makes the change conditional on the version of the event.
+func (eh *EventHandler) ReplaceEvent(w http.ResponseWriter, r *http.Request) {
+	stored, ok := eh.pathEvent(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[REST Replace]")
+	if !ok {
+		return
+	}
+
+	var updateEventDto dto.UpdateEvent
+	if !eh.decodeBody(w, r, "[REST Replace]", &updateEventDto) {
+		return
+	}
+
+	if updateEventDto.UserId != 0 && updateEventDto.UserId != stored.UserID {
+		writeErrorJSON(w, errUserMismatch.Error(), http.StatusConflict)
+		return
+	}
+	updateEventDto.UserId = stored.UserID
+
+	eh.saveEvent(w, r, "[REST Replace]", stored.ID, version, &updateEventDto)
+}
+
+// PatchEvent merges the body into the stored event. The merge is based on the loaded version,
+// so a change made in between fails with 412 instead of being overwritten.
+func (eh *EventHandler) PatchEvent(w http.ResponseWriter, r *http.Request) {
+	stored, ok := eh.pathEvent(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[REST Patch]")
+	if !ok {
+		return
+	}
+
+	if version != 0 && version != stored.Version {
+		writeErrorJSON(w, domains.ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var patchEventDto dto.PatchEvent
+	if !eh.decodeBody(w, r, "[REST Patch]", &patchEventDto) {
+		return
+	}
+
+	updateEventDto, err := patchEventDto.Merge(stored)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST Patch] error merging patch", "error", err)
+		writeErrorJSON(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	eh.saveEvent(w, r, "[REST Patch]", stored.ID, stored.Version, updateEventDto)
+}
+
+func (eh *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	stored, ok := eh.pathEvent(w, r)
+	if !ok {
+		return
+	}
+
+	occurrence, hasOccurrence, err := parseOccurrence(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST Delete] error converting query occurrence to time", "error", err)
+		writeErrorJSON(w, "invalid occurrence, expected format: YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r, "[REST Delete]")
+	if !ok {
+		return
+	}
+
+	if hasOccurrence {
+		err = eh.service.DeleteOccurrence(r.Context(), stored.ID, occurrence, version)
+	} else {
+		err = eh.service.Delete(r.Context(), stored.ID, version)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST Delete] error deleting event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EventHistory returns the previous versions of the event, oldest first.
+func (eh *EventHandler) EventHistory(w http.ResponseWriter, r *http.Request) {
+	stored, ok := eh.pathEvent(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := eh.service.History(r.Context(), stored.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST History] error getting history", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	results := make([]*dto.EventDto, 0, len(history))
+	for _, event := range history {
+		results = append(results, dto.EventDtoFromDomain(event))
+	}
+
+	writeJSON(w, http.StatusOK, dto.EventsResponse{
+		Result: results,
+		Total:  len(results),
+	})
+}
+
+// pathEvent loads the event addressed by the path, events of another user than the one
+// in the path are reported as missing. It responds itself if the event can not be returned.
+func (eh *EventHandler) pathEvent(w http.ResponseWriter, r *http.Request) (*domains.Event, bool) {
+	userId, err := strconv.Atoi(r.PathValue("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST] error converting path user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	eventId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST] error converting path event id to int", "error", err)
+		writeErrorJSON(w, "invalid event id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	event, err := eh.service.Event(r.Context(), eventId)
+	if err == nil && event.UserID != userId {
+		err = domains.ErrEventNotFound
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[REST] error getting event", "error", err)
+		writeServiceError(w, err)
+		return nil, false
+	}
+
+	return event, true
+}
+
+func (eh *EventHandler) saveEvent(w http.ResponseWriter, r *http.Request, tag string, eventId, version int, updateEventDto *dto.UpdateEvent) {
+	if err := updateEventDto.Validate(); err != nil {
+		slog.ErrorContext(r.Context(), tag+" error validating event", "error", err)
+		writeValidationError(w, err)
+		return
+	}
+
+	occurrence, hasOccurrence, err := parseOccurrence(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), tag+" error converting query occurrence to time", "error", err)
+		writeErrorJSON(w, "invalid occurrence, expected format: YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	event := updateEventDto.ToDomain(eventId)
+	event.Version = version
+
+	if hasOccurrence {
+		event, err = eh.service.UpdateOccurrence(r.Context(), eventId, occurrence, event)
+	} else {
+		event, err = eh.service.Update(r.Context(), event)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), tag+" error updating event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, dto.EventResponse{
+		Result: dto.EventDtoFromDomain(event),
+	})
+}
+
+func (eh *EventHandler) decodeBody(w http.ResponseWriter, r *http.Request, tag string, body any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, eh.maxBodySize)
+
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.ErrorContext(r.Context(), tag+" error decoding body", "error", err)
+		if writeBodyTooLarge(w, err) {
+			return false
+		}
+
+		writeErrorJSON(w, "invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// parseRange reads the required from and to query parameters.
+func parseRange(r *http.Request) (time.Time, time.Time, error) {
+	location := time.UTC
+	if queryTimeZone := r.URL.Query().Get("tz"); queryTimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(queryTimeZone); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid tz, expected an IANA time zone name")
+		}
+	}
+
+	from, err := parseBound(r.URL.Query().Get("from"), location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from, %w", err)
+	}
+
+	to, err := parseBound(r.URL.Query().Get("to"), location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to, %w", err)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+
+	if to.Sub(from) > maxRangeWindow {
+		return time.Time{}, time.Time{}, errors.New("range must not be longer than 366 days")
+	}
+
+	return from, to, nil
+}
+
+func parseBound(value string, location *time.Location) (time.Time, error) {
+	if parsed, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		return parsed, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.In(location), nil
+	}
+
+	return time.Time{}, errors.New("expected format: YYYY-MM-DD or RFC 3339")
+}
+
+// writeServiceError maps the errors of the service to the status codes of the resource API.
+func writeServiceError(w http.ResponseWriter, err error) {
+	if writeConflictError(w, err) {
+		return
+	}
+
+	statusCode := serviceErrorStatus(err)
+	if statusCode == http.StatusInternalServerError {
+		writeErrorJSON(w, "something went wrong", statusCode)
+		return
+	}
+
+	writeErrorJSON(w, err.Error(), statusCode)
+}
+
+// serviceErrorStatus returns the status code of an error of the service.
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domains.ErrEventNotFound), errors.Is(err, domains.ErrOccurrenceNotFound), errors.Is(err, domains.ErrNotInvited),
+		errors.Is(err, domains.ErrCalendarNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domains.ErrEventNotRecurring), errors.Is(err, domains.ErrEventConflict), errors.Is(err, domains.ErrCalendarNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, domains.ErrInvalidTimeRange), errors.Is(err, domains.ErrShareWithOwner):
+		return http.StatusBadRequest
+	case errors.Is(err, domains.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domains.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, domains.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
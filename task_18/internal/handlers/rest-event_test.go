@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter() *http.ServeMux {
+	router := http.NewServeMux()
+	service := services.NewEventService(repositories.NewEventRepository(), services.ConflictReject)
+	asUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(auth.WithUser(r.Context(), 1)))
+		}
+	}
+
+	NewEventHandler(router, service, asUser, 1<<20)
+
+	return router
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	return rec
+}
+
+func decodeEvent(t *testing.T, rec *httptest.ResponseRecorder) *dto.EventDto {
+	t.Helper()
+
+	var response dto.EventResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+	return response.Result
+}
+
+func TestRESTEventLifecycle(t *testing.T) {
+	router := newTestRouter()
+
+	rec := serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"stand up","start":"2026-03-11T10:00:00Z","end":"2026-03-11T10:15:00Z"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/api/v1/users/1/events/1", rec.Header().Get("Location"))
+	assert.Equal(t, 1, decodeEvent(t, rec).UserID)
+
+	rec = serve(router, http.MethodPatch, "/api/v1/users/1/events/1", `{"title":"daily stand up","recurrence":{"frequency":"daily"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	patched := decodeEvent(t, rec)
+	assert.Equal(t, "daily stand up", patched.Title)
+	assert.Equal(t, "2026-03-11T10:15:00Z", patched.End)
+	require.NotNil(t, patched.Recurrence)
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/1/events?from=2026-03-11&to=2026-03-14", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list dto.EventsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	assert.Len(t, list.Result, 3)
+
+	rec = serve(router, http.MethodPut, "/api/v1/users/1/events/1", `{"title":"one off","date":"2026-03-12"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, decodeEvent(t, rec).Recurrence)
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/1/events/1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "one off", decodeEvent(t, rec).Title)
+
+	rec = serve(router, http.MethodDelete, "/api/v1/users/1/events/1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/1/events/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRESTEventErrors(t *testing.T) {
+	router := newTestRouter()
+
+	rec := serve(router, http.MethodPost, "/api/v1/users/1/events", `{"user_id":2,"title":"mine","date":"2026-03-11"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serve(router, http.MethodPost, "/api/v1/users/2/events", `{"title":"not mine","date":"2026-03-11"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"single","date":"2026-03-11"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = serve(router, http.MethodDelete, "/api/v1/users/1/events/1?occurrence=2026-03-11", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serve(router, http.MethodPatch, "/api/v1/users/1/events/1", `{"end":"2026-03-10T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/1/events?from=2026-03-11", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodPut, "/api/v1/users/1/events/42", `{"title":"missing","date":"2026-03-11"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRESTEventValidationErrors(t *testing.T) {
+	router := newTestRouter()
+
+	rec := serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"x","start":"2026-03-11T10:00:00Z","end":"2026-03-11T09:00:00Z","recurrence":{"frequency":"hourly"}}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response validationErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "validation error", response.Error)
+	assert.Equal(t, dto.ValidationErrors{
+		{Field: "title", Rule: "min", Message: "must be at least 2 characters long"},
+		{Field: "recurrence.frequency", Rule: "oneof", Message: "must be one of: daily, weekly, monthly, yearly"},
+	}, response.Fields)
+
+	rec = serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"stand up","start":"2026-03-11T10:00:00Z","end":"2026-03-11T09:00:00Z","remind_before":["soon"]}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, dto.ValidationErrors{
+		{Field: "end", Rule: "after_start", Message: "event end is before its start"},
+		{Field: "remind_before[0]", Rule: "duration", Message: "must be a non-negative duration like 15m"},
+	}, response.Fields)
+}
+
+func TestRESTEventConflict(t *testing.T) {
+	router := newTestRouter()
+
+	rec := serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"planning","start":"2026-03-11T09:00:00Z","end":"2026-03-11T10:00:00Z"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = serve(router, http.MethodPost, "/create_event", `{"user_id":1,"title":"review","start":"2026-03-11T09:30:00Z","end":"2026-03-11T10:30:00Z"}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	var response dto.ConflictResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Conflicts, 1)
+	assert.Equal(t, 1, response.Conflicts[0].ID)
+
+	rec = serve(router, http.MethodGet, "/freebusy?user_id=1,2&from=2026-03-11T08:00:00Z&to=2026-03-11T12:00:00Z&duration=1h", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var freeBusy dto.FreeBusyResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&freeBusy))
+	assert.Equal(t, []*dto.IntervalDto{{Start: "2026-03-11T09:00:00Z", End: "2026-03-11T10:00:00Z"}}, freeBusy.Result.Busy)
+	assert.Equal(t, []*dto.IntervalDto{
+		{Start: "2026-03-11T08:00:00Z", End: "2026-03-11T09:00:00Z"},
+		{Start: "2026-03-11T10:00:00Z", End: "2026-03-11T12:00:00Z"},
+	}, freeBusy.Result.Free)
+	require.Len(t, freeBusy.Result.Users, 2)
+	assert.Empty(t, freeBusy.Result.Users[1].Busy)
+
+	rec = serve(router, http.MethodGet, "/freebusy?from=2026-03-11&to=2026-03-12", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRESTEventVersions(t *testing.T) {
+	router := newTestRouter()
+	withHeader := func(method, target, body, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve(router, http.MethodPost, "/api/v1/users/1/events", `{"title":"stand up","date":"2026-03-11"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	assert.Equal(t, 1, decodeEvent(t, rec).Version)
+
+	rec = withHeader(http.MethodGet, "/api/v1/users/1/events/1", "", "If-None-Match", `"1"`)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = withHeader(http.MethodPut, "/api/v1/users/1/events/1", `{"title":"daily stand up","date":"2026-03-11"}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	rec = withHeader(http.MethodPatch, "/api/v1/users/1/events/1", `{"title":"lost update"}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = withHeader(http.MethodPost, "/update_event/1", `{"user_id":1,"title":"lost update","date":"2026-03-11"}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = withHeader(http.MethodDelete, "/api/v1/users/1/events/1", "", "If-Match", `W/"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = withHeader(http.MethodDelete, "/api/v1/users/1/events/1", "", "If-Match", `2`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodPatch, "/api/v1/users/1/events/1", `{"description":"every morning"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 3, decodeEvent(t, rec).Version)
+
+	rec = withHeader(http.MethodGet, "/api/v1/users/1/events/1", "", "If-None-Match", `"2"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	rec = serve(router, http.MethodGet, "/api/v1/users/1/events/1/history", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var history dto.EventsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&history))
+	require.Len(t, history.Result, 2)
+	assert.Equal(t, "stand up", history.Result[0].Title)
+	assert.Equal(t, "daily stand up", history.Result[1].Title)
+
+	rec = withHeader(http.MethodDelete, "/api/v1/users/1/events/1", "", "If-Match", `"3"`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestSearchEvents(t *testing.T) {
+	router := newTestRouter()
+
+	for _, body := range []string{
+		`{"title":"Встреча с командой","date":"2026-03-12"}`,
+		`{"title":"Обед","description":"после встречи","date":"2026-03-11"}`,
+		`{"title":"Планёрка","date":"2026-03-02","recurrence":{"frequency":"weekly"}}`,
+	} {
+		rec := serve(router, http.MethodPost, "/api/v1/users/1/events", body)
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	search := func(target string) dto.SearchResponse {
+		t.Helper()
+
+		rec := serve(router, http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.SearchResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		return response
+	}
+
+	response := search("/events/search?user_id=1&q=ВСТРЕЧ&limit=1")
+	assert.Equal(t, 2, response.Total)
+	require.Len(t, response.Result, 1)
+	assert.Equal(t, "Обед", response.Result[0].Title)
+
+	response = search("/events/search?user_id=1&q=встреч&limit=1&offset=1")
+	require.Len(t, response.Result, 1)
+	assert.Equal(t, "Встреча с командой", response.Result[0].Title)
+
+	response = search("/events/search?user_id=1&q=встреч&offset=9223372036854775807")
+	assert.Equal(t, 2, response.Total)
+	assert.Empty(t, response.Result)
+
+	response = search("/events/search?user_id=1&q=планерка&from=2026-03-01&to=2026-03-20")
+	assert.Equal(t, 3, response.Total)
+
+	response = search("/events/search?user_id=1&q=отпуск")
+	assert.Equal(t, 0, response.Total)
+	assert.NotNil(t, response.Result)
+
+	rec := serve(router, http.MethodGet, "/events/search?user_id=1&q=", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/events/search?user_id=1&q=обед&from=2026-03-01", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/events/search?user_id=1&q=обед&limit=500", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/events/search?user_id=2&q=обед", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestListEventsPagination(t *testing.T) {
+	router := newTestRouter()
+
+	for _, body := range []string{
+		`{"title":"third","date":"2026-03-13"}`,
+		`{"title":"first","date":"2026-03-11"}`,
+		`{"title":"second","date":"2026-03-11"}`,
+		`{"title":"daily","start":"2026-03-12T08:00:00Z","recurrence":{"frequency":"daily","count":2}}`,
+	} {
+		rec := serve(router, http.MethodPost, "/api/v1/users/1/events", body)
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	list := func(target string) dto.EventsResponse {
+		t.Helper()
+
+		rec := serve(router, http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.EventsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		return response
+	}
+
+	var titles []string
+	cursor := ""
+	for range 3 {
+		response := list("/api/v1/users/1/events?from=2026-03-01&to=2026-04-01&limit=2&cursor=" + cursor)
+		assert.Equal(t, 5, response.Total)
+
+		for _, event := range response.Result {
+			titles = append(titles, event.Title+" "+event.Start)
+		}
+
+		cursor = response.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{
+		"first 2026-03-11T00:00:00Z",
+		"second 2026-03-11T00:00:00Z",
+		"daily 2026-03-12T08:00:00Z",
+		"third 2026-03-13T00:00:00Z",
+		"daily 2026-03-13T08:00:00Z",
+	}, titles)
+	assert.Empty(t, cursor)
+
+	response := list("/events_for_month?user_id=1&date=2026-03-01&limit=1")
+	assert.Equal(t, 5, response.Total)
+	require.Len(t, response.Result, 1)
+	assert.Equal(t, "first", response.Result[0].Title)
+	assert.NotEmpty(t, response.NextCursor)
+
+	rec := serve(router, http.MethodGet, "/api/v1/users/1/events?from=2026-03-01&to=2026-04-01&cursor=garbage", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/events_for_month?user_id=1&date=2026-03-01&limit=0", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
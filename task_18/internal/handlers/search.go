@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+)
+
+const (
+	// defaultSearchLimit is the page size of search requests without a limit
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// maxQueryLength limits the length of a search query in bytes
+	maxQueryLength = 256
+)
+
+// Search returns a page of the events of the user matching the words of q, ordered by date.
+// With from and to only the occurrences inside the range are returned.
+func (eh *EventHandler) Search(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Search] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" || len(query) > maxQueryLength {
+		writeErrorJSON(w, "q is required and must not be longer than 256 bytes", http.StatusBadRequest)
+		return
+	}
+
+	var from, to time.Time
+	if r.URL.Query().Has("from") || r.URL.Query().Has("to") {
+		from, to, err = parseRange(r)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[Search] error parsing range", "error", err)
+			writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, offset, err := parseSearchPage(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Search] error parsing page", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := eh.service.Search(r.Context(), userId, query, from, to)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Search] error searching events", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	// offset may be close to the maximum int, so the end is computed without adding it to the limit
+	start := min(offset, len(events))
+	page := events[start : start+min(limit, len(events)-start)]
+
+	results := make([]*dto.EventDto, 0, len(page))
+	for _, event := range page {
+		results = append(results, dto.EventDtoFromDomain(event))
+	}
+
+	writeJSON(w, http.StatusOK, dto.SearchResponse{
+		Result: results,
+		Total:  len(events),
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// parseSearchPage reads the optional limit and offset query parameters.
+func parseSearchPage(r *http.Request) (int, int, error) {
+	limit := defaultSearchLimit
+	if queryLimit := r.URL.Query().Get("limit"); queryLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(queryLimit)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return 0, 0, errors.New("invalid limit, expected a number from 1 to 100")
+		}
+	}
+
+	offset := 0
+	if queryOffset := r.URL.Query().Get("offset"); queryOffset != "" {
+		var err error
+		offset, err = strconv.Atoi(queryOffset)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset, expected a non-negative number")
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+)
+
+type ChangeBus interface {
+	Subscribe(userId int, resume bool, lastID uint64) (*changes.Subscription, []changes.Change, bool)
+}
+
+type StreamHandler struct {
+	bus       ChangeBus
+	heartbeat time.Duration
+}
+
+// NewStreamHandler registers the Server-Sent Events stream of event changes,
+// a comment is sent every heartbeat to keep idle connections open.
+func NewStreamHandler(router *http.ServeMux, bus ChangeBus, middleware middlewares.Middleware, heartbeat time.Duration) {
+	handler := &StreamHandler{
+		bus:       bus,
+		heartbeat: heartbeat,
+	}
+
+	router.HandleFunc("GET /events/stream", middleware(handler.Stream))
+}
+
+// Stream pushes the created, updated and deleted events of the user. A client reconnecting with
+// Last-Event-ID first gets the changes it missed, a reset event tells it they are no longer buffered.
+func (sh *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Stream] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	if err := authorizeUser(r, userId); err != nil {
+		slog.ErrorContext(r.Context(), "[Stream] error authorizing stream", "error", err)
+		writeAccessError(w, err)
+		return
+	}
+
+	var lastID uint64
+	header := r.Header.Get("Last-Event-ID")
+	if header != "" {
+		if lastID, err = strconv.ParseUint(header, 10, 64); err != nil {
+			slog.ErrorContext(r.Context(), "[Stream] error converting last event id to int", "error", err)
+			writeErrorJSON(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	// the stream outlives the write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.ErrorContext(r.Context(), "[Stream] error clearing write deadline", "error", err)
+	}
+
+	subscription, replay, complete := sh.bus.Subscribe(userId, header != "", lastID)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		if _, err := io.WriteString(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+
+	for _, change := range replay {
+		if err := writeChange(w, change); err != nil {
+			return
+		}
+	}
+
+	if err := controller.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "[Stream] error flushing stream", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(sh.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, ok := <-subscription.C:
+			if !ok {
+				// dropped or shutting down, the client resumes with Last-Event-ID
+				return
+			}
+
+			err = writeChange(w, change)
+		case <-ticker.C:
+			_, err = io.WriteString(w, ": ping\n\n")
+		}
+
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			slog.InfoContext(r.Context(), "[Stream] stream closed", "error", err)
+			return
+		}
+	}
+}
+
+func writeChange(w io.Writer, change changes.Change) error {
+	data, err := json.Marshal(dto.ChangeDtoFromChange(change))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Kind, data)
+
+	return err
+}
+
+// authorizeUser checks that the authenticated user is the one whose data is requested.
+func authorizeUser(r *http.Request, userId int) error {
+	authenticated, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return domains.ErrUnauthenticated
+	}
+
+	if authenticated != userId {
+		return domains.ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamServer(t *testing.T, bus *changes.Bus) *httptest.Server {
+	t.Helper()
+
+	router := http.NewServeMux()
+	asUser := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(auth.WithUser(r.Context(), 1)))
+		}
+	}
+	NewStreamHandler(router, bus, asUser, time.Hour)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// readEvent reads the lines of the next server-sent event.
+func readEvent(t *testing.T, reader *bufio.Reader) []string {
+	t.Helper()
+
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+func TestStream(t *testing.T) {
+	bus := changes.NewBus(10)
+	server := newStreamServer(t, bus)
+	ctx := context.Background()
+
+	bus.EventCreated(ctx, &domains.Event{ID: 1, UserID: 1, Title: "missed", Date: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)})
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/events/stream?user_id=1", nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+
+	bus.EventCreated(ctx, &domains.Event{ID: 2, UserID: 2, Title: "other user"})
+	bus.EventDeleted(ctx, &domains.Event{ID: 1, UserID: 1})
+
+	lines := readEvent(t, reader)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id: 3", lines[0])
+	assert.Equal(t, "event: deleted", lines[1])
+	assert.Contains(t, lines[2], `"event_id":1`)
+	assert.NotContains(t, lines[2], `"event":`)
+}
+
+func TestStreamResume(t *testing.T) {
+	bus := changes.NewBus(2)
+	server := newStreamServer(t, bus)
+	ctx := context.Background()
+
+	for id := 1; id <= 3; id++ {
+		bus.EventUpdated(ctx, &domains.Event{ID: id, UserID: 1, Title: "changed"})
+	}
+
+	stream := func(lastID string) *bufio.Reader {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/events/stream?user_id=1", nil)
+		require.NoError(t, err)
+		request.Header.Set("Last-Event-ID", lastID)
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		t.Cleanup(func() { response.Body.Close() })
+
+		return bufio.NewReader(response.Body)
+	}
+
+	reader := stream("2")
+	lines := readEvent(t, reader)
+	assert.Equal(t, "id: 3", lines[0])
+	assert.Contains(t, lines[2], `"title":"changed"`)
+
+	reader = stream("0")
+	assert.Equal(t, []string{"event: reset", "data: {}"}, readEvent(t, reader))
+	assert.Equal(t, "id: 2", readEvent(t, reader)[0])
+	assert.Equal(t, "id: 3", readEvent(t, reader)[0])
+}
+
+func TestStreamForbidden(t *testing.T) {
+	server := newStreamServer(t, changes.NewBus(10))
+
+	response, err := http.Get(server.URL + "/events/stream?user_id=2")
+	require.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+)
+
+// Trash returns a page of the deleted events of the user ordered by date, recurring events are returned as series.
+func (eh *EventHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Trash] error converting query user id to int", "error", err)
+		writeErrorJSON(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parseCursorPage(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Trash] error parsing page", "error", err)
+		writeErrorJSON(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := eh.service.Trash(r.Context(), userId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Trash] error getting trash", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeEventsPage(w, domains.PageOf(events, page))
+}
+
+// Restore moves the event from the trash back to the events, it responds with the restored event.
+func (eh *EventHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	eventId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Restore] error converting path event id to int", "error", err)
+		writeErrorJSON(w, "invalid event id", http.StatusBadRequest)
+		return
+	}
+
+	event, err := eh.service.Restore(r.Context(), eventId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[Restore] error restoring event", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, dto.EventResponse{
+		Result: dto.EventDtoFromDomain(event),
+	})
+}
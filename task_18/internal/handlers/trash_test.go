@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	router := newMultiUserRouter()
+
+	rec := serveAs(router, 1, http.MethodPost, "/api/v1/users/1/events", `{"title":"stand up","start":"2026-03-11T10:00:00Z","end":"2026-03-11T10:15:00Z"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, 1, http.MethodDelete, "/api/v1/users/1/events/1", "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	rec = serveAs(router, 1, http.MethodGet, "/trash?user_id=1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page dto.EventsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Result, 1)
+	assert.Equal(t, "stand up", page.Result[0].Title)
+	assert.NotEmpty(t, page.Result[0].DeletedAt)
+
+	rec = serveAs(router, 2, http.MethodGet, "/trash?user_id=1", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, 2, http.MethodPost, "/restore_event/1", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodPost, "/restore_event/1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	restored := decodeEvent(t, rec)
+	assert.Equal(t, "stand up", restored.Title)
+	assert.Empty(t, restored.DeletedAt)
+
+	rec = serveAs(router, 1, http.MethodGet, "/api/v1/users/1/events/1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodPost, "/restore_event/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, 1, http.MethodPost, "/restore_event/abc", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,346 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+var ErrInvalidCalendar = errors.New("invalid calendar: VCALENDAR object not found")
+
+// Result is a decoded VEVENT, Err is set when the VEVENT could not be converted to an event.
+type Result struct {
+	UID   string
+	Event *domains.Event
+	Err   error
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode reads the VEVENT components of a VCALENDAR object. Other components are skipped.
+func Decode(r io.Reader) ([]Result, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results    []Result
+		inCalendar bool
+		found      bool
+		event      []property
+		inEvent    bool
+		nested     int
+	)
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		prop, err := parseProperty(line)
+		if err != nil {
+			if inEvent && nested == 0 {
+				event = append(event, property{name: "X-INVALID", value: err.Error()})
+			}
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VCALENDAR"):
+			inCalendar, found = true, true
+		case prop.name == "END" && strings.EqualFold(prop.value, "VCALENDAR"):
+			inCalendar = false
+		case !inCalendar:
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && !inEvent:
+			inEvent, event = true, nil
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT") && inEvent && nested == 0:
+			inEvent = false
+			results = append(results, toResult(event))
+		case !inEvent:
+		case prop.name == "BEGIN":
+			nested++
+		case prop.name == "END":
+			nested--
+		case nested == 0:
+			event = append(event, prop)
+		}
+	}
+
+	if !found {
+		return nil, ErrInvalidCalendar
+	}
+
+	return results, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line "NAME;PARAM=VALUE:value" ignoring separators inside quoted parameter values.
+func parseProperty(line string) (property, error) {
+	var (
+		parts   []string
+		quoted  bool
+		start   int
+		valueAt = -1
+	)
+
+	for i := 0; i < len(line) && valueAt < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, line[start:i])
+				start = i + 1
+			}
+		case ':':
+			if !quoted {
+				parts = append(parts, line[start:i])
+				valueAt = i + 1
+			}
+		}
+	}
+
+	if valueAt < 0 || parts[0] == "" {
+		return property{}, fmt.Errorf("malformed content line %q", line)
+	}
+
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[valueAt:],
+	}
+
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+func toResult(props []property) Result {
+	var (
+		result     Result
+		event      = &domains.Event{}
+		hasStart   bool
+		exceptions []time.Time
+	)
+
+	fail := func(err error) Result {
+		result.Err = err
+		return result
+	}
+
+	for _, prop := range props {
+		switch prop.name {
+		case "X-INVALID":
+			return fail(errors.New(prop.value))
+		case "UID":
+			result.UID = prop.value
+		case "SUMMARY":
+			event.Title = unescapeText(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(prop.value)
+		case "DTSTART":
+			start, err := parseTime(prop.value, prop.params)
+			if err != nil {
+				return fail(fmt.Errorf("DTSTART: %w", err))
+			}
+
+			event.Date, hasStart = start, true
+			event.TimeZone = prop.params["TZID"]
+		case "DTEND":
+			end, err := parseTime(prop.value, prop.params)
+			if err != nil {
+				return fail(fmt.Errorf("DTEND: %w", err))
+			}
+
+			event.End = end
+		case "RRULE":
+			recurrence, err := parseRecurrence(prop.value)
+			if err != nil {
+				return fail(fmt.Errorf("RRULE: %w", err))
+			}
+
+			event.Recurrence = recurrence
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				exception, err := parseTime(value, prop.params)
+				if err != nil {
+					return fail(fmt.Errorf("EXDATE: %w", err))
+				}
+
+				exceptions = append(exceptions, exception)
+			}
+		}
+	}
+
+	if !hasStart {
+		return fail(errors.New("DTSTART is required"))
+	}
+
+	if event.Title == "" {
+		return fail(errors.New("SUMMARY is required"))
+	}
+
+	if !event.End.IsZero() && event.End.Before(event.Date) {
+		return fail(domains.ErrInvalidTimeRange)
+	}
+
+	if len(exceptions) > 0 {
+		if !event.IsRecurring() {
+			return fail(errors.New("EXDATE without RRULE"))
+		}
+
+		event.Recurrence.Exceptions = exceptions
+	}
+
+	result.Event = event
+
+	return result
+}
+
+func parseTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		return time.Parse(dateFormat, value)
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeFormat+"Z", value)
+	}
+
+	location := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if location, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+
+	return time.ParseInLocation(dateTimeFormat, value, location)
+}
+
+func parseRecurrence(value string) (*domains.Recurrence, error) {
+	recurrence := &domains.Recurrence{}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			frequency := domains.Frequency(strings.ToLower(val))
+			if !slices.Contains([]domains.Frequency{
+				domains.FrequencyDaily,
+				domains.FrequencyWeekly,
+				domains.FrequencyMonthly,
+				domains.FrequencyYearly,
+			}, frequency) {
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+
+			recurrence.Frequency = frequency
+		case "INTERVAL", "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid %s %q", key, val)
+			}
+
+			if strings.ToUpper(key) == "INTERVAL" {
+				recurrence.Interval = n
+			} else {
+				recurrence.Count = n
+			}
+		case "UNTIL":
+			until, err := parseTime(val, nil)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", val)
+			}
+
+			if len(val) == len(dateFormat) {
+				// a date bound includes the whole day
+				until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+
+			recurrence.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday := slices.Index(weekdayNames[:], strings.ToUpper(day))
+				if weekday < 0 {
+					return nil, fmt.Errorf("unsupported BYDAY %q", day)
+				}
+
+				recurrence.ByDay = append(recurrence.ByDay, time.Weekday(weekday))
+			}
+		case "WKST":
+			// weeks always start on monday
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if recurrence.Frequency == "" {
+		return nil, errors.New("FREQ is required")
+	}
+
+	if recurrence.Count > 0 && !recurrence.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL are exclusive")
+	}
+
+	return recurrence, nil
+}
+
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
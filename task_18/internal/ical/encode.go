@@ -0,0 +1,195 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+const (
+	prodID = "-//wb_level2//task_18//EN"
+
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405"
+
+	// maxLineLength is the limit of a content line in octets, longer lines are folded
+	maxLineLength = 75
+)
+
+// weekdayNames are RFC 5545 day names indexed by time.Weekday
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Encode writes events as a VCALENDAR object, now is used as DTSTAMP of every VEVENT.
+func Encode(w io.Writer, events []*domains.Event, now time.Time) error {
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+prodID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+
+	for _, event := range events {
+		writeEvent(bw, event, now)
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// EventUID returns the UID of an exported event which was not created with a UID of its own.
+func EventUID(id int) string {
+	return fmt.Sprintf("%d@task_18", id)
+}
+
+func writeEvent(w *bufio.Writer, event *domains.Event, now time.Time) {
+	writeLine(w, "BEGIN:VEVENT")
+	uid := event.UID
+	if uid == "" {
+		uid = EventUID(event.ID)
+	}
+	writeLine(w, "UID:"+uid)
+	writeLine(w, "DTSTAMP:"+now.UTC().Format(dateTimeFormat)+"Z")
+	allDay := isAllDay(event)
+	writeLine(w, "DTSTART"+formatTime(event.Date, allDay))
+
+	if !event.End.IsZero() {
+		writeLine(w, "DTEND"+formatTime(event.End, allDay))
+	}
+
+	writeLine(w, "SUMMARY:"+escapeText(event.Title))
+
+	if event.Description != "" {
+		writeLine(w, "DESCRIPTION:"+escapeText(event.Description))
+	}
+
+	if event.IsRecurring() {
+		writeLine(w, "RRULE:"+formatRecurrence(event.Recurrence, allDay))
+
+		if len(event.Recurrence.Exceptions) > 0 {
+			// EXDATE must have the same value type as DTSTART, so a timed series
+			// excludes the occurrences starting on the exception dates
+			hour, minute, sec := event.Date.Clock()
+			values := make([]string, 0, len(event.Recurrence.Exceptions))
+			for _, exception := range event.Recurrence.Exceptions {
+				year, month, day := exception.Date()
+				occurrence := time.Date(year, month, day, hour, minute, sec, 0, event.Date.Location())
+				values = append(values, timeValue(occurrence, allDay))
+			}
+
+			writeLine(w, "EXDATE"+timeParams(event.Date, allDay)+":"+strings.Join(values, ","))
+		}
+	}
+
+	writeLine(w, "END:VEVENT")
+}
+
+// isAllDay reports whether the event lasts whole days, that is it starts at midnight and
+// either has no end or ends at midnight too. DTSTART and DTEND of such an event are dates.
+func isAllDay(event *domains.Event) bool {
+	return isMidnight(event.Date) && (event.End.IsZero() || isMidnight(event.End))
+}
+
+func isMidnight(t time.Time) bool {
+	hour, minute, sec := t.Clock()
+
+	return hour == 0 && minute == 0 && sec == 0
+}
+
+// formatTime returns the parameters and the value of a DTSTART-like property,
+// a date for all-day events and a date-time in the location of t otherwise.
+func formatTime(t time.Time, allDay bool) string {
+	return timeParams(t, allDay) + ":" + timeValue(t, allDay)
+}
+
+func timeParams(t time.Time, allDay bool) string {
+	switch {
+	case allDay:
+		return ";VALUE=DATE"
+	case t.Location() == time.UTC:
+		return ""
+	default:
+		return ";TZID=" + t.Location().String()
+	}
+}
+
+func timeValue(t time.Time, allDay bool) string {
+	switch {
+	case allDay:
+		return t.Format(dateFormat)
+	case t.Location() == time.UTC:
+		return t.Format(dateTimeFormat) + "Z"
+	default:
+		return t.Format(dateTimeFormat)
+	}
+}
+
+func formatRecurrence(recurrence *domains.Recurrence, allDay bool) string {
+	parts := []string{"FREQ=" + strings.ToUpper(string(recurrence.Frequency))}
+
+	if recurrence.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(recurrence.Interval))
+	}
+
+	if len(recurrence.ByDay) > 0 {
+		days := make([]string, 0, len(recurrence.ByDay))
+		for _, day := range recurrence.ByDay {
+			days = append(days, weekdayNames[day])
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if recurrence.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(recurrence.Count))
+	}
+
+	if !recurrence.Until.IsZero() {
+		// UNTIL must have the same value type as DTSTART
+		if allDay {
+			parts = append(parts, "UNTIL="+recurrence.Until.Format(dateFormat))
+		} else {
+			parts = append(parts, "UNTIL="+recurrence.Until.UTC().Format(dateTimeFormat)+"Z")
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line folding it at maxLineLength octets without splitting runes.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineLength
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+
+		// the leading space of a continuation line counts towards its length
+		limit = maxLineLength - 1
+	}
+
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
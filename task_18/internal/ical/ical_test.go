@@ -0,0 +1,275 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Encode(&buf, []*domains.Event{
+		{
+			ID:          7,
+			UserID:      1,
+			Title:       "Планёрка; отдел, продаж",
+			Description: "line1\nline2",
+			Date:        date(2026, 3, 11),
+			Recurrence: &domains.Recurrence{
+				Frequency:  domains.FrequencyWeekly,
+				Interval:   2,
+				ByDay:      []time.Weekday{time.Monday, time.Wednesday},
+				Until:      date(2026, 6, 1),
+				Exceptions: []time.Time{date(2026, 3, 23)},
+			},
+		},
+	}, date(2026, 3, 1))
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//wb_level2//task_18//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:7@task_18",
+		"DTSTAMP:20260301T000000Z",
+		"DTSTART;VALUE=DATE:20260311",
+		`SUMMARY:Планёрка\; отдел\, продаж`,
+		`DESCRIPTION:line1\nline2`,
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20260601",
+		"EXDATE;VALUE=DATE:20260323",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestEncode_FoldsLongLines(t *testing.T) {
+	var buf bytes.Buffer
+
+	title := strings.Repeat("ж", 100)
+	err := Encode(&buf, []*domains.Event{{ID: 1, Title: title, Date: date(2026, 3, 11)}}, date(2026, 3, 1))
+	require.NoError(t, err)
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+	}
+
+	results, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, title, results[0].Event.Title)
+}
+
+func TestDecode(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Moscow",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:first",
+		"DTSTART;TZID=Europe/Moscow:20260311T100000",
+		"SUMMARY:Stand",
+		"  up",
+		`DESCRIPTION:a\, b\nc`,
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"EXDATE;VALUE=DATE:20260312",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:second",
+		"SUMMARY:no start",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:third",
+		"DTSTART:20260311T100000Z",
+		"SUMMARY:bad rule",
+		"RRULE:FREQ=HOURLY",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	results, err := Decode(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	first := results[0]
+	require.NoError(t, first.Err)
+	assert.Equal(t, "first", first.UID)
+	assert.Equal(t, "Stand up", first.Event.Title)
+	assert.Equal(t, "a, b\nc", first.Event.Description)
+	assert.Equal(t, "Europe/Moscow", first.Event.Date.Location().String())
+	assert.Equal(t, 10, first.Event.Date.Hour())
+	require.NotNil(t, first.Event.Recurrence)
+	assert.Equal(t, domains.FrequencyDaily, first.Event.Recurrence.Frequency)
+	assert.Equal(t, 3, first.Event.Recurrence.Count)
+	assert.Equal(t, []time.Time{date(2026, 3, 12)}, first.Event.Recurrence.Exceptions)
+
+	assert.Equal(t, "second", results[1].UID)
+	assert.ErrorContains(t, results[1].Err, "DTSTART is required")
+
+	assert.Equal(t, "third", results[2].UID)
+	assert.ErrorContains(t, results[2].Err, "unsupported FREQ")
+}
+
+func TestDecode_InvalidCalendar(t *testing.T) {
+	_, err := Decode(strings.NewReader("hello"))
+	assert.ErrorIs(t, err, ErrInvalidCalendar)
+}
+
+func TestRoundTrip(t *testing.T) {
+	events := []*domains.Event{
+		{
+			ID:    1,
+			Title: "monthly",
+			Date:  time.Date(2026, 3, 11, 9, 30, 0, 0, time.UTC),
+			Recurrence: &domains.Recurrence{
+				Frequency: domains.FrequencyMonthly,
+				Count:     4,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events, date(2026, 3, 1)))
+
+	results, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, EventUID(1), results[0].UID)
+	assert.True(t, events[0].Date.Equal(results[0].Event.Date))
+	assert.Equal(t, events[0].Recurrence, results[0].Event.Recurrence)
+}
+
+func TestRoundTrip_TimeZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	events := []*domains.Event{
+		{
+			ID:       2,
+			Title:    "meeting",
+			Date:     time.Date(2026, 3, 11, 10, 0, 0, 0, moscow),
+			End:      time.Date(2026, 3, 11, 11, 30, 0, 0, moscow),
+			TimeZone: "Europe/Moscow",
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events, date(2026, 3, 1)))
+	assert.Contains(t, buf.String(), "DTSTART;TZID=Europe/Moscow:20260311T100000\r\n")
+	assert.Contains(t, buf.String(), "DTEND;TZID=Europe/Moscow:20260311T113000\r\n")
+
+	results, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, "Europe/Moscow", results[0].Event.TimeZone)
+	assert.True(t, events[0].Date.Equal(results[0].Event.Date))
+	assert.True(t, events[0].End.Equal(results[0].Event.End))
+}
+
+func TestEncode_ValueTypes(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	events := []*domains.Event{
+		{ID: 1, Title: "vacation", Date: date(2026, 3, 11), End: date(2026, 3, 14)},
+		{
+			ID:       2,
+			Title:    "night shift",
+			Date:     time.Date(2026, 3, 11, 0, 0, 0, 0, moscow),
+			End:      time.Date(2026, 3, 11, 1, 30, 0, 0, moscow),
+			TimeZone: "Europe/Moscow",
+		},
+		{ID: 3, Title: "late", Date: time.Date(2026, 3, 11, 22, 0, 0, 0, time.UTC), End: date(2026, 3, 12)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events, date(2026, 3, 1)))
+
+	for _, line := range []string{
+		"DTSTART;VALUE=DATE:20260311",
+		"DTEND;VALUE=DATE:20260314",
+		"DTSTART;TZID=Europe/Moscow:20260311T000000",
+		"DTEND;TZID=Europe/Moscow:20260311T013000",
+		"DTSTART:20260311T220000Z",
+		"DTEND:20260312T000000Z",
+	} {
+		assert.Contains(t, buf.String(), line+"\r\n")
+	}
+
+	results, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for i, result := range results {
+		require.NoError(t, result.Err)
+		assert.True(t, events[i].Date.Equal(result.Event.Date), events[i].Title)
+		assert.True(t, events[i].End.Equal(result.Event.End), events[i].Title)
+	}
+}
+
+func TestRoundTrip_TimedExceptions(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	events := []*domains.Event{
+		{
+			ID:       1,
+			Title:    "standup",
+			Date:     time.Date(2026, 3, 11, 10, 0, 0, 0, moscow),
+			TimeZone: "Europe/Moscow",
+			Recurrence: &domains.Recurrence{
+				Frequency:  domains.FrequencyDaily,
+				Exceptions: []time.Time{date(2026, 3, 12), date(2026, 3, 14)},
+			},
+		},
+		{
+			ID:    2,
+			Title: "sync",
+			Date:  time.Date(2026, 3, 11, 9, 30, 0, 0, time.UTC),
+			Recurrence: &domains.Recurrence{
+				Frequency:  domains.FrequencyWeekly,
+				Exceptions: []time.Time{date(2026, 3, 18)},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events, date(2026, 3, 1)))
+	assert.Contains(t, buf.String(), "EXDATE;TZID=Europe/Moscow:20260312T100000,20260314T100000\r\n")
+	assert.Contains(t, buf.String(), "EXDATE:20260318T093000Z\r\n")
+
+	results, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	for i, result := range results {
+		require.NoError(t, result.Err)
+
+		from, to := events[i].Date, events[i].Date.AddDate(0, 1, 0)
+		assert.Equal(t,
+			events[i].Recurrence.Occurrences(events[i].Date, from, to),
+			result.Event.Recurrence.Occurrences(result.Event.Date, from, to),
+			events[i].Title,
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+)
+
+// AuthMiddleware requires a "Bearer" JWT signed with secret and puts its user into the request context.
+func AuthMiddleware(secret []byte) Middleware {
+	return func(fn http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeErrorJSON(w, "missing bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			userId, err := auth.ParseToken(secret, token)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "[Middleware Auth] error parsing token", "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeErrorJSON(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			fn(w, r.WithContext(auth.WithUser(r.Context(), userId)))
+		}
+	}
+}
+
+// BasicAuthMiddleware works like AuthMiddleware and also takes the token as the password of "Basic"
+// credentials, the user name is ignored. It is meant for clients like CalDAV apps which only know passwords.
+func BasicAuthMiddleware(secret []byte) Middleware {
+	return func(fn http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				_, token, ok = r.BasicAuth()
+			}
+
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="task_18"`)
+				writeErrorJSON(w, "missing credentials", http.StatusUnauthorized)
+				return
+			}
+
+			userId, err := auth.ParseToken(secret, token)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "[Middleware Basic Auth] error parsing token", "error", err)
+				w.Header().Set("WWW-Authenticate", `Basic realm="task_18"`)
+				writeErrorJSON(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			fn(w, r.WithContext(auth.WithUser(r.Context(), userId)))
+		}
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeErrorJSON(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(errorResponse{Error: message})
+}
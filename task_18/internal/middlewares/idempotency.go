@@ -0,0 +1,327 @@
+package middlewares
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxIdempotencyKeyLength keeps clients from filling the memory with huge keys
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes POST requests with an Idempotency-Key header safe to retry. The first response
+// to a key of a client is stored for ttl and replayed for retries with the same method, URL and body,
+// a different request with the same key is rejected with 422. Responses with a 5xx status are not
+// stored, so the retry runs again. Clients are told apart like in RateLimiter, so the middleware has to
+// run after authentication. Every client keeps at most maxEntries responses of at most maxBytes in total,
+// the least recently used ones are evicted first and the latest response is always kept.
+type Idempotency struct {
+	ttl time.Duration
+	// maxBodySize is the largest body which is fingerprinted, larger requests pass through untouched
+	maxBodySize int64
+	maxEntries  int
+	maxBytes    int64
+	now         func() time.Time
+
+	mu        sync.Mutex
+	responses map[idempotencyKey]*storedResponse
+	clients   map[string]*idempotencyClient
+	lastSweep time.Time
+}
+
+// idempotencyClient holds the stored responses of a client, the most recently used first.
+type idempotencyClient struct {
+	lru   *list.List
+	bytes int64
+}
+
+type idempotencyKey struct {
+	client string
+	key    string
+}
+
+// storedResponse is the response to a key, done is closed once it has been recorded.
+type storedResponse struct {
+	id          idempotencyKey
+	fingerprint [sha256.Size]byte
+	done        chan struct{}
+	expires     time.Time
+	element     *list.Element
+	// size is the number of bytes of the response counted against the budget of the client
+	size int64
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+func NewIdempotency(ttl time.Duration, maxBodySize int64, maxEntries int, maxBytes int64) *Idempotency {
+	return &Idempotency{
+		ttl:         ttl,
+		maxBodySize: maxBodySize,
+		maxEntries:  maxEntries,
+		maxBytes:    maxBytes,
+		now:         time.Now,
+		responses:   make(map[idempotencyKey]*storedResponse),
+		clients:     make(map[string]*idempotencyClient),
+	}
+}
+
+// Middleware replays the stored response of a retried POST request. A retry which arrives while the first
+// request is still served waits for its response.
+func (i *Idempotency) Middleware(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			fn(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			writeErrorJSON(w, "invalid Idempotency-Key, expected at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, i.maxBodySize+1))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "[Middleware Idempotency] error reading body", "error", err)
+			writeErrorJSON(w, "error reading body", http.StatusBadRequest)
+			return
+		}
+
+		if int64(len(body)) > i.maxBodySize {
+			// the handler rejects the body as too large, there is nothing to store
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			fn(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		id := idempotencyKey{client: clientKey(r), key: key}
+		fingerprint := requestFingerprint(r, body)
+
+		for {
+			stored, first := i.reserve(id, fingerprint)
+			if first {
+				i.record(w, r, fn, id, stored)
+				return
+			}
+
+			if stored.fingerprint != fingerprint {
+				slog.WarnContext(r.Context(), "[Middleware Idempotency] key reused with a different request", "client", id.client)
+				writeErrorJSON(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+				return
+			}
+
+			select {
+			case <-stored.done:
+			case <-r.Context().Done():
+				writeErrorJSON(w, "request canceled", http.StatusRequestTimeout)
+				return
+			}
+
+			// a response which was not stored has been dropped, so the next round runs the request
+			if stored.header != nil {
+				stored.replay(w)
+				return
+			}
+		}
+	}
+}
+
+// reserve returns the response stored for the key, or a new one to record if the request is the first.
+func (i *Idempotency) reserve(id idempotencyKey, fingerprint [sha256.Size]byte) (*storedResponse, bool) {
+	now := i.now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.sweep(now)
+
+	if stored, ok := i.responses[id]; ok {
+		if !stored.expired(now) {
+			i.clients[id.client].lru.MoveToFront(stored.element)
+			return stored, false
+		}
+
+		i.remove(stored)
+	}
+
+	client, ok := i.clients[id.client]
+	if !ok {
+		client = &idempotencyClient{lru: list.New()}
+		i.clients[id.client] = client
+	}
+
+	stored := &storedResponse{
+		id:          id,
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+		expires:     now.Add(i.ttl),
+	}
+	stored.element = client.lru.PushFront(stored)
+	i.responses[id] = stored
+
+	i.evict(client, stored)
+
+	return stored, true
+}
+
+// evict drops the least recently used responses of the client until it is within its limits. Responses still
+// being recorded and keep are never evicted. It has to be called with the lock held.
+func (i *Idempotency) evict(client *idempotencyClient, keep *storedResponse) {
+	element := client.lru.Back()
+
+	for element != nil && (client.lru.Len() > i.maxEntries || client.bytes > i.maxBytes) {
+		stored := element.Value.(*storedResponse)
+		element = element.Prev()
+
+		if stored != keep && stored.recorded() {
+			i.remove(stored)
+		}
+	}
+}
+
+// remove drops the response, it has to be called with the lock held.
+func (i *Idempotency) remove(stored *storedResponse) {
+	// a response replaced by a retry has already been dropped
+	if i.responses[stored.id] != stored {
+		return
+	}
+
+	delete(i.responses, stored.id)
+
+	client := i.clients[stored.id.client]
+	client.lru.Remove(stored.element)
+	client.bytes -= stored.size
+	if client.lru.Len() == 0 {
+		delete(i.clients, stored.id.client)
+	}
+}
+
+// record runs the request and stores its response, it is dropped if the request fails or panics.
+func (i *Idempotency) record(w http.ResponseWriter, r *http.Request, fn http.HandlerFunc, id idempotencyKey, stored *storedResponse) {
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	kept := false
+
+	defer func() {
+		i.mu.Lock()
+		if kept {
+			stored.status, stored.header, stored.body = recorder.status, recorder.header, recorder.body.Bytes()
+		}
+		close(stored.done)
+
+		if !kept {
+			i.remove(stored)
+		} else if client, ok := i.clients[id.client]; ok && i.responses[id] == stored {
+			stored.size = int64(len(stored.body))
+			for name, values := range stored.header {
+				stored.size += int64(len(name))
+				for _, value := range values {
+					stored.size += int64(len(value))
+				}
+			}
+
+			client.bytes += stored.size
+			i.evict(client, stored)
+		}
+		i.mu.Unlock()
+	}()
+
+	fn(recorder, r)
+
+	if recorder.header == nil {
+		// the handler wrote nothing, which is an empty 200
+		recorder.header = w.Header().Clone()
+	}
+	kept = recorder.status < http.StatusInternalServerError
+}
+
+// sweep drops the expired responses at most once per ttl, it has to be called with the lock held.
+func (i *Idempotency) sweep(now time.Time) {
+	if now.Sub(i.lastSweep) < i.ttl {
+		return
+	}
+
+	i.lastSweep = now
+
+	for _, stored := range i.responses {
+		if stored.expired(now) {
+			i.remove(stored)
+		}
+	}
+}
+
+// recorded reports whether the request of the response has been served.
+func (sr *storedResponse) recorded() bool {
+	select {
+	case <-sr.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// expired reports whether the response is past its ttl, a response still being recorded does not expire.
+func (sr *storedResponse) expired(now time.Time) bool {
+	return sr.recorded() && !now.Before(sr.expires)
+}
+
+// replay writes the stored response. Headers already set for this request, like its request id, are kept.
+func (sr *storedResponse) replay(w http.ResponseWriter) {
+	header := w.Header()
+	for name, values := range sr.header {
+		if _, ok := header[name]; !ok {
+			header[name] = values
+		}
+	}
+	header.Set("Idempotent-Replayed", "true")
+
+	w.WriteHeader(sr.status)
+	w.Write(sr.body)
+}
+
+// requestFingerprint hashes what makes two requests with the same key the same request.
+func requestFingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n" + strconv.Itoa(len(body)) + "\n"))
+	hash.Write(body)
+
+	var fingerprint [sha256.Size]byte
+	hash.Sum(fingerprint[:0])
+
+	return fingerprint
+}
+
+// responseRecorder passes the response on and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.header == nil {
+		rr.status = status
+		rr.header = rr.ResponseWriter.Header().Clone()
+	}
+
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.header == nil {
+		rr.WriteHeader(http.StatusOK)
+	}
+
+	rr.body.Write(b)
+
+	return rr.ResponseWriter.Write(b)
+}
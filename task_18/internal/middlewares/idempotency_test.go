@@ -0,0 +1,211 @@
+package middlewares
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	now := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	idempotency := NewIdempotency(time.Hour, 1<<10, 100, 1<<20)
+	idempotency.now = func() time.Time { return now }
+
+	var created atomic.Int32
+	create := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		id := created.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/events/%d", id))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d,"body":%q}`, id, body)
+	}
+
+	send := func(userId int, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(body))
+		req = req.WithContext(auth.WithUser(req.Context(), userId))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		rec := httptest.NewRecorder()
+		idempotency.Middleware(create)(rec, req)
+
+		return rec
+	}
+
+	first := send(1, "a", `{"title":"planning"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := send(1, "a", `{"title":"planning"}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/events/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(1), created.Load())
+
+	rec := send(1, "a", `{"title":"other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// keys are per user, and requests without a key are not deduplicated
+	assert.Equal(t, http.StatusCreated, send(2, "a", `{"title":"other"}`).Code)
+	assert.Equal(t, http.StatusCreated, send(1, "", `{"title":"planning"}`).Code)
+	assert.Equal(t, http.StatusCreated, send(1, "", `{"title":"planning"}`).Code)
+	assert.Equal(t, int32(4), created.Load())
+
+	assert.Equal(t, http.StatusBadRequest, send(1, strings.Repeat("k", 256), `{}`).Code)
+
+	// a body too large to fingerprint reaches the handler untouched
+	large := strings.Repeat("x", 2<<10)
+	rec = send(1, "large", large)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), large)
+	assert.Equal(t, int32(5), created.Load())
+
+	now = now.Add(time.Hour)
+
+	rec = send(1, "a", `{"title":"other"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, int32(6), created.Load())
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	idempotency := NewIdempotency(time.Hour, 1<<10, 100, 1<<20)
+
+	calls := 0
+	handler := idempotency.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			writeErrorJSON(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "a")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, expected, rec.Code)
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ConcurrentRetryWaits(t *testing.T) {
+	idempotency := NewIdempotency(time.Hour, 1<<10, 100, 1<<20)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	handler := idempotency.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-release
+
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "a")
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = send()
+	}()
+
+	<-started
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+
+	retry := send()
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "created", retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_Eviction(t *testing.T) {
+	idempotency := NewIdempotency(time.Hour, 1<<12, 2, 1<<10)
+
+	var calls atomic.Int32
+	handler := idempotency.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	send := func(userId int, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(body))
+		req = req.WithContext(auth.WithUser(req.Context(), userId))
+		req.Header.Set("Idempotency-Key", key)
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		return rec
+	}
+
+	send(1, "a", `{}`)
+	send(1, "b", `{}`)
+	// replaying a makes b the least recently used key
+	assert.Equal(t, "true", send(1, "a", `{}`).Header().Get("Idempotent-Replayed"))
+	send(1, "c", `{}`)
+	require.Equal(t, int32(3), calls.Load())
+
+	// the keys of other clients do not count against the limit
+	send(2, "d", `{}`)
+	send(2, "e", `{}`)
+	require.Equal(t, int32(5), calls.Load())
+
+	assert.Equal(t, "true", send(1, "a", `{}`).Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "true", send(1, "c", `{}`).Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, send(1, "b", `{}`).Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(6), calls.Load())
+
+	// a large response pushes the older ones out of the byte budget but is kept itself
+	large := `"` + strings.Repeat("x", 1<<10) + `"`
+	send(3, "f", `{}`)
+	send(3, "g", large)
+	assert.Equal(t, large, send(3, "g", large).Body.String())
+	assert.Empty(t, send(3, "f", `{}`).Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(9), calls.Load())
+
+	// b and c, d and e, and f which pushed g out in turn
+
+	idempotency.mu.Lock()
+	defer idempotency.mu.Unlock()
+	assert.Len(t, idempotency.responses, 5)
+	for client, stored := range idempotency.clients {
+		assert.LessOrEqual(t, stored.lru.Len(), 2, client)
+	}
+}
@@ -8,7 +8,7 @@ import (
 
 func LoggingMiddleware(fn http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("[Middleware Logger]",
+		slog.InfoContext(r.Context(), "[Middleware Logger]",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"time", time.Now().String())
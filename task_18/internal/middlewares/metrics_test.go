@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := NewMetrics(registry)
+
+	router := http.NewServeMux()
+	router.HandleFunc("POST /delete_event/{id}", metrics.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, id := range []string{"1", "2", "0"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/delete_event/"+id, nil))
+	}
+
+	expected := `
+# HELP http_requests_total Number of handled HTTP requests by route and status code.
+# TYPE http_requests_total counter
+http_requests_total{code="200",route="POST /delete_event/{id}"} 2
+http_requests_total{code="400",route="POST /delete_event/{id}"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"))
+
+	count, err := testutil.GatherAndCount(registry, "http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+)
+
+// Limit allows Burst requests at once and refills them evenly over Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit reads a limit like "30/1m", 30 requests per minute. "0" turns the limit off.
+func ParseLimit(value string) (Limit, error) {
+	if value == "0" {
+		return Limit{}, nil
+	}
+
+	burst, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected a limit like 30/1m", value)
+	}
+
+	var limit Limit
+	var err error
+
+	if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected a positive number of requests", value)
+	}
+
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected a positive period", value)
+	}
+
+	return limit, nil
+}
+
+func (l Limit) disabled() bool {
+	return l.Burst == 0
+}
+
+// interval is the time it takes to refill one request.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Burst)
+}
+
+// RateLimiter limits the requests of every client to each route with a token bucket. Clients are told apart
+// by the authenticated user or, before authentication, by the remote address. Buckets of clients which did
+// not send a request for idleTimeout are dropped, a full bucket would be recreated for them anyway.
+type RateLimiter struct {
+	defaultLimit Limit
+	// routes holds the limits of the ServeMux patterns which differ from the default
+	routes      map[string]Limit
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	route  string
+	client string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(defaultLimit Limit, routes map[string]Limit, idleTimeout time.Duration) *RateLimiter {
+	// evicting a bucket before it is refilled would reset it early
+	for _, limit := range routes {
+		idleTimeout = max(idleTimeout, limit.Period)
+	}
+
+	return &RateLimiter{
+		defaultLimit: defaultLimit,
+		routes:       routes,
+		idleTimeout:  max(idleTimeout, defaultLimit.Period),
+		now:          time.Now,
+		buckets:      make(map[bucketKey]*bucket),
+	}
+}
+
+// Middleware responds 429 with a Retry-After header when the client used up the limit of the route.
+func (rl *RateLimiter) Middleware(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := rl.routes[r.Pattern]
+		if !ok {
+			limit = rl.defaultLimit
+		}
+
+		if limit.disabled() {
+			fn(w, r)
+			return
+		}
+
+		key := bucketKey{route: r.Pattern, client: clientKey(r)}
+		if retryAfter, ok := rl.allow(key, limit); !ok {
+			slog.WarnContext(r.Context(), "[Middleware RateLimit] rate limit exceeded", "route", key.route, "client", key.client)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeErrorJSON(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		fn(w, r)
+	}
+}
+
+// allow takes a token from the bucket of key, if there is none it returns how long until the next one.
+func (rl *RateLimiter) allow(key bucketKey, limit Limit) (time.Duration, bool) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.sweep(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		rl.buckets[key] = b
+	}
+
+	refilled := float64(now.Sub(b.last)) / float64(limit.interval())
+	b.tokens = min(float64(limit.Burst), b.tokens+refilled)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(limit.interval())), false
+	}
+
+	b.tokens--
+
+	return 0, true
+}
+
+// sweep drops the idle buckets at most once per idleTimeout, it has to be called with the lock held.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rl.idleTimeout {
+		return
+	}
+
+	rl.lastSweep = now
+
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) >= rl.idleTimeout {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies the client of the request, the authenticated user if there is one.
+func clientKey(r *http.Request) string {
+	if userId, ok := auth.UserFromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(userId)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
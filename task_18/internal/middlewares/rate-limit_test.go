@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("30/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Burst: 30, Period: time.Minute}, limit)
+
+	limit, err = ParseLimit("0")
+	require.NoError(t, err)
+	assert.True(t, limit.disabled())
+
+	for _, invalid := range []string{"", "30", "-1/1m", "30/0s", "x/1m"} {
+		_, err := ParseLimit(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(Limit{Burst: 2, Period: time.Minute}, map[string]Limit{
+		"GET /events_for_day": {},
+	}, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	router := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("POST /create_event", limiter.Middleware(ok))
+	router.HandleFunc("GET /events_for_day", limiter.Middleware(ok))
+
+	send := func(method, target, remoteAddr string, userId int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		if userId != 0 {
+			req = req.WithContext(auth.WithUser(req.Context(), userId))
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.1:1234", 0).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.1:4321", 0).Code)
+
+	rec := send(http.MethodPost, "/create_event", "10.0.0.1:1234", 0)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	// other clients, users and unlimited routes have their own budget
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.2:1234", 0).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.1:1234", 7).Code)
+	for range 5 {
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/events_for_day", "10.0.0.1:1234", 0).Code)
+	}
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.1:1234", 0).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, "/create_event", "10.0.0.1:1234", 0).Code)
+	assert.Len(t, limiter.buckets, 3)
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/create_event", "10.0.0.3:1234", 0).Code)
+	assert.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoveryMiddleware turns a panic of the handler into a JSON 500 response instead of dropping the connection.
+func RecoveryMiddleware(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+
+			// the server aborts the response on purpose
+			if e, ok := err.(error); ok && errors.Is(e, http.ErrAbortHandler) {
+				panic(err)
+			}
+
+			slog.ErrorContext(r.Context(), "[Middleware Recovery] handler panicked",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"error", err,
+				"stack", string(debug.Stack()))
+
+			writeErrorJSON(w, "internal server error", http.StatusInternalServerError)
+		}()
+
+		fn(w, r)
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/M-kos/wb_level2/task_18/internal/tracing"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength keeps client supplied ids out of the logs if they are unreasonably long
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware reuses the X-Request-ID header of the request or generates a new id,
+// echoes it in the response and puts it into the request context for logging.
+func RequestIDMiddleware(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIDHeader)
+		if requestId == "" || len(requestId) > maxRequestIDLength {
+			requestId = tracing.NewRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestId)
+
+		fn(w, r.WithContext(tracing.WithRequestID(r.Context(), requestId)))
+	}
+}
@@ -3,3 +3,14 @@ package middlewares
 import "net/http"
 
 type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Chain combines middlewares, the first one is the outermost.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(fn http.HandlerFunc) http.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			fn = middlewares[i](fn)
+		}
+
+		return fn
+	}
+}
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	timeType       = reflect.TypeFor[time.Time]()
+)
+
+// Generator builds schemas from Go types. Named structs become components referenced by $ref,
+// fields are named by their json tags and constrained by their go-playground/validator tags.
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schema returns the schema of the type of v, registering the components it needs.
+func (g *Generator) Schema(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Schemas returns the components registered so far.
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == rawMessageType:
+		return &Schema{Description: "any JSON value"}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+
+		return &Schema{Ref: "#/components/schemas/" + g.register(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// register adds the component of a named struct once and returns its name.
+func (g *Generator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := exportedName(t.Name())
+	if _, taken := g.schemas[name]; taken {
+		name = exportedName(path.Base(t.PkgPath())) + name
+	}
+
+	// registered before the fields are walked, so recursive types end in a $ref
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.object(t)
+
+	return name
+}
+
+func (g *Generator) object(t reflect.Type) *Schema {
+	object := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	g.addFields(object, t, jsonNames(t))
+
+	return object
+}
+
+// addFields adds the fields of t to object, the fields of embedded structs are inlined like encoding/json does.
+func (g *Generator) addFields(object *Schema, t reflect.Type, names map[string]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			g.addFields(object, field.Type, names)
+			continue
+		}
+
+		name, ok := names[field.Name]
+		if !ok {
+			continue
+		}
+
+		schema := g.schema(field.Type)
+		if applyRules(schema, field.Tag.Get("validate"), names) {
+			object.Required = append(object.Required, name)
+		}
+
+		object.Properties[name] = schema
+	}
+}
+
+// applyRules constrains schema with the validate rules of a field and reports whether the field is required.
+// Rules after dive apply to the items of a list.
+func applyRules(schema *Schema, tag string, names map[string]string) bool {
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	target := schema
+	descriptions := make([]string, 0)
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "required":
+			if target == schema {
+				required = true
+			}
+		case "required_without":
+			descriptions = append(descriptions, "required without "+jsonName(param, names))
+		case "excluded_with":
+			descriptions = append(descriptions, "must not be set together with "+jsonName(param, names))
+		case "min", "max":
+			applyBound(target, name, param)
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "datetime":
+			target.Format = "date-time"
+			if param == "2006-01-02" {
+				target.Format = "date"
+			}
+		case "timezone":
+			descriptions = append(descriptions, "IANA time zone name")
+		}
+	}
+
+	// siblings of $ref are ignored by OpenAPI 3.0
+	if len(descriptions) > 0 && schema.Ref == "" {
+		schema.Description = strings.Join(descriptions, ", ")
+	}
+
+	return required
+}
+
+func applyBound(schema *Schema, rule, param string) {
+	value, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		if rule == "min" {
+			schema.MinLength = &value
+		} else {
+			schema.MaxLength = &value
+		}
+	case "array":
+		if rule == "min" {
+			schema.MinItems = &value
+		} else {
+			schema.MaxItems = &value
+		}
+	case "integer", "number":
+		bound := float64(value)
+		if rule == "min" {
+			schema.Minimum = &bound
+		} else {
+			schema.Maximum = &bound
+		}
+	}
+}
+
+// jsonNames maps the Go names of the serialized fields of t, including embedded ones, to their JSON names.
+func jsonNames(t reflect.Type) map[string]string {
+	names := make(map[string]string)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			for goName, jsonName := range jsonNames(field.Type) {
+				names[goName] = jsonName
+			}
+			continue
+		}
+
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		names[field.Name] = name
+	}
+
+	return names
+}
+
+func jsonName(goName string, names map[string]string) string {
+	if name, ok := names[goName]; ok {
+		return name
+	}
+
+	return goName
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
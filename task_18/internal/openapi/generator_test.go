@@ -0,0 +1,71 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type window struct {
+	Date  string `json:"date,omitempty" validate:"required_without=Start,omitempty,datetime=2006-01-02"`
+	Start string `json:"start,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type tag struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type request struct {
+	Title  string          `json:"title" validate:"required,min=2,max=100"`
+	Count  int             `json:"count,omitempty" validate:"omitempty,min=1"`
+	Days   []string        `json:"days,omitempty" validate:"omitempty,max=7,dive,oneof=MO TU"`
+	Tag    *tag            `json:"tag,omitempty"`
+	Extra  json.RawMessage `json:"extra"`
+	hidden string
+	Secret string `json:"-"`
+	window
+}
+
+func TestGeneratorSchema(t *testing.T) {
+	g := NewGenerator()
+
+	ref := g.Schema(request{})
+	assert.Equal(t, "#/components/schemas/Request", ref.Ref)
+
+	schemas := g.Schemas()
+	require.Contains(t, schemas, "Request")
+	require.Contains(t, schemas, "Tag")
+
+	schema := schemas["Request"]
+	assert.ElementsMatch(t, []string{"title", "count", "days", "tag", "extra", "date", "start"}, keys(schema.Properties))
+	assert.Equal(t, []string{"title"}, schema.Required)
+
+	title := schema.Properties["title"]
+	assert.Equal(t, "string", title.Type)
+	assert.Equal(t, 2, *title.MinLength)
+	assert.Equal(t, 100, *title.MaxLength)
+
+	assert.Equal(t, 1.0, *schema.Properties["count"].Minimum)
+
+	days := schema.Properties["days"]
+	assert.Equal(t, 7, *days.MaxItems)
+	assert.Equal(t, []string{"MO", "TU"}, days.Items.Enum)
+
+	assert.Equal(t, "#/components/schemas/Tag", schema.Properties["tag"].Ref)
+	assert.Equal(t, "date", schema.Properties["date"].Format)
+	assert.Equal(t, "required without start", schema.Properties["date"].Description)
+	assert.Equal(t, "date-time", schema.Properties["start"].Format)
+
+	assert.Equal(t, []string{"name"}, schemas["Tag"].Required)
+}
+
+func keys(properties map[string]*Schema) []string {
+	result := make([]string, 0, len(properties))
+	for name := range properties {
+		result = append(result, name)
+	}
+
+	return result
+}
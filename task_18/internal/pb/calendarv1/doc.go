@@ -0,0 +1,4 @@
+// Package calendarv1 holds the code generated from api/calendar/v1/event_service.proto.
+package calendarv1
+
+//go:generate protoc -I ../../../api --go_out=. --go_opt=module=github.com/M-kos/wb_level2/task_18/internal/pb/calendarv1 --go-grpc_out=. --go-grpc_opt=module=github.com/M-kos/wb_level2/task_18/internal/pb/calendarv1 calendar/v1/event_service.proto
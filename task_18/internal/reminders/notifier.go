@@ -0,0 +1,104 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Reminder struct {
+	EventID      int
+	UserID       int
+	Title        string
+	Start        time.Time
+	RemindBefore time.Duration
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// Notifiers delivers a reminder to every notifier in the list.
+type Notifiers []Notifier
+
+func (ns Notifiers) Notify(ctx context.Context, reminder Reminder) error {
+	var errs []error
+
+	for _, notifier := range ns {
+		if err := notifier.Notify(ctx, reminder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, reminder Reminder) error {
+	slog.Info("[Reminder]",
+		"event_id", reminder.EventID,
+		"user_id", reminder.UserID,
+		"title", reminder.Title,
+		"start", reminder.Start.Format(time.RFC3339),
+		"remind_before", reminder.RemindBefore.String())
+
+	return nil
+}
+
+type webhookPayload struct {
+	EventID      int    `json:"event_id"`
+	UserID       int    `json:"user_id"`
+	Title        string `json:"title"`
+	Start        string `json:"start"`
+	RemindBefore string `json:"remind_before"`
+}
+
+// WebhookNotifier posts reminders as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	body, err := json.Marshal(webhookPayload{
+		EventID:      reminder.EventID,
+		UserID:       reminder.UserID,
+		Title:        reminder.Title,
+		Start:        reminder.Start.Format(time.RFC3339),
+		RemindBefore: reminder.RemindBefore.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package reminders
+
+import (
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// item is the next pending reminder of an event, the queue holds at most one item per event.
+type item struct {
+	event        *domains.Event
+	occurrence   time.Time
+	remindBefore time.Duration
+	fireAt       time.Time
+	index        int
+}
+
+// reminderQueue is a min-heap of items ordered by fireAt, it implements heap.Interface.
+type reminderQueue []*item
+
+func (q reminderQueue) Len() int {
+	return len(q)
+}
+
+func (q reminderQueue) Less(i, j int) bool {
+	return q[i].fireAt.Before(q[j].fireAt)
+}
+
+func (q reminderQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *reminderQueue) Push(x any) {
+	it := x.(*item)
+	it.index = len(*q)
+	*q = append(*q, it)
+}
+
+func (q *reminderQueue) Pop() any {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*q = old[:n-1]
+
+	return it
+}
@@ -0,0 +1,196 @@
+package reminders
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+const (
+	// lookahead limits how far in the future the next occurrence of an event is searched for
+	lookahead = 2 * 366 * 24 * time.Hour
+	// idleWait is how long the scheduler sleeps when the queue is empty
+	idleWait = time.Hour
+)
+
+type EventRepository interface {
+	ListAll(ctx context.Context, from, to time.Time) ([]*domains.Event, error)
+}
+
+// Scheduler sends the reminders of events at their RemindBefore offsets.
+// It keeps the next reminder of every event in a priority queue which is rebuilt
+// from the repository by Run and kept up to date through the services.EventListener methods.
+// Reminders that were due while the scheduler was not running are not sent.
+type Scheduler struct {
+	repo     EventRepository
+	notifier Notifier
+	now      func() time.Time
+
+	mu     sync.Mutex
+	queue  reminderQueue
+	items  map[int]*item
+	wakeup chan struct{}
+}
+
+func NewScheduler(repo EventRepository, notifier Notifier) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		notifier: notifier,
+		now:      time.Now,
+		queue:    make(reminderQueue, 0),
+		items:    make(map[int]*item),
+		wakeup:   make(chan struct{}, 1),
+	}
+}
+
+// Run loads the upcoming reminders and sends them until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.rebuild(ctx); err != nil {
+		return fmt.Errorf("rebuild reminders: %w", err)
+	}
+
+	timer := time.NewTimer(s.untilNext())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.wakeup:
+		case <-timer.C:
+			s.fireDue(ctx)
+		}
+
+		timer.Reset(s.untilNext())
+	}
+}
+
+func (s *Scheduler) EventCreated(_ context.Context, event *domains.Event) {
+	s.reschedule(event)
+}
+
+func (s *Scheduler) EventUpdated(_ context.Context, event *domains.Event) {
+	s.reschedule(event)
+}
+
+func (s *Scheduler) EventDeleted(_ context.Context, event *domains.Event) {
+	s.mu.Lock()
+	s.cancel(event.ID)
+	s.mu.Unlock()
+
+	s.wake()
+}
+
+func (s *Scheduler) reschedule(event *domains.Event) {
+	s.mu.Lock()
+	s.schedule(event, s.now())
+	s.mu.Unlock()
+
+	s.wake()
+}
+
+func (s *Scheduler) rebuild(ctx context.Context) error {
+	now := s.now()
+
+	events, err := s.repo.ListAll(ctx, now, now.Add(lookahead))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		s.schedule(event, now)
+	}
+
+	return nil
+}
+
+// schedule replaces the pending reminder of the event with the first one due after the given time.
+func (s *Scheduler) schedule(event *domains.Event, after time.Time) {
+	s.cancel(event.ID)
+
+	var next *item
+
+	for _, offset := range event.RemindBefore {
+		occurrence, ok := event.NextOccurrence(after.Add(offset), after.Add(lookahead))
+		if !ok {
+			continue
+		}
+
+		fireAt := occurrence.Add(-offset)
+		if next == nil || fireAt.Before(next.fireAt) {
+			next = &item{
+				event:        event,
+				occurrence:   occurrence,
+				remindBefore: offset,
+				fireAt:       fireAt,
+			}
+		}
+	}
+
+	if next != nil {
+		heap.Push(&s.queue, next)
+		s.items[event.ID] = next
+	}
+}
+
+func (s *Scheduler) cancel(eventId int) {
+	if it, ok := s.items[eventId]; ok {
+		heap.Remove(&s.queue, it.index)
+		delete(s.items, eventId)
+	}
+}
+
+func (s *Scheduler) fireDue(ctx context.Context) {
+	now := s.now()
+	due := make([]*item, 0)
+
+	s.mu.Lock()
+	for len(s.queue) > 0 && !s.queue[0].fireAt.After(now) {
+		it := heap.Pop(&s.queue).(*item)
+		delete(s.items, it.event.ID)
+		due = append(due, it)
+
+		s.schedule(it.event, it.fireAt)
+	}
+	s.mu.Unlock()
+
+	for _, it := range due {
+		err := s.notifier.Notify(ctx, Reminder{
+			EventID:      it.event.ID,
+			UserID:       it.event.UserID,
+			Title:        it.event.Title,
+			Start:        it.occurrence,
+			RemindBefore: it.remindBefore,
+		})
+		if err != nil {
+			slog.Error("[Reminders] error sending reminder", "event_id", it.event.ID, "error", err)
+		}
+	}
+}
+
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return idleWait
+	}
+
+	return max(s.queue[0].fireAt.Sub(s.now()), 0)
+}
+
+// wake makes Run recompute its timer after the queue has changed.
+func (s *Scheduler) wake() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
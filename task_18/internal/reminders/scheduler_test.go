@@ -0,0 +1,166 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepo struct {
+	events []*domains.Event
+}
+
+func (m *mockRepo) ListAll(_ context.Context, _, _ time.Time) ([]*domains.Event, error) {
+	return m.events, nil
+}
+
+type chanNotifier chan Reminder
+
+func (c chanNotifier) Notify(_ context.Context, reminder Reminder) error {
+	c <- reminder
+	return nil
+}
+
+func TestSchedule_PicksEarliestReminder(t *testing.T) {
+	now := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler(&mockRepo{}, LogNotifier{})
+
+	scheduler.schedule(&domains.Event{
+		ID:           1,
+		Date:         time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC),
+		RemindBefore: []time.Duration{time.Hour, 15 * time.Minute},
+	}, now)
+	scheduler.schedule(&domains.Event{
+		ID:           2,
+		Date:         time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC),
+		RemindBefore: []time.Duration{30 * time.Minute},
+	}, now)
+	scheduler.schedule(&domains.Event{
+		ID:           3,
+		Date:         time.Date(2026, 3, 11, 9, 10, 0, 0, time.UTC),
+		RemindBefore: []time.Duration{time.Hour},
+	}, now)
+
+	require.Len(t, scheduler.queue, 2)
+	assert.Equal(t, 2, scheduler.queue[0].event.ID)
+	assert.Equal(t, time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC), scheduler.items[1].fireAt)
+
+	scheduler.cancel(2)
+	require.Len(t, scheduler.queue, 1)
+	assert.Equal(t, 1, scheduler.queue[0].event.ID)
+}
+
+func TestSchedule_RecurringMovesToNextOccurrence(t *testing.T) {
+	now := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler(&mockRepo{}, LogNotifier{})
+
+	event := &domains.Event{
+		ID:           1,
+		Date:         time.Date(2026, 3, 1, 9, 5, 0, 0, time.UTC),
+		Recurrence:   &domains.Recurrence{Frequency: domains.FrequencyDaily},
+		RemindBefore: []time.Duration{10 * time.Minute},
+	}
+
+	scheduler.schedule(event, now)
+	require.Contains(t, scheduler.items, 1)
+	assert.Equal(t, time.Date(2026, 3, 12, 8, 55, 0, 0, time.UTC), scheduler.items[1].fireAt)
+
+	scheduler.schedule(event, scheduler.items[1].fireAt)
+	assert.Equal(t, time.Date(2026, 3, 13, 8, 55, 0, 0, time.UTC), scheduler.items[1].fireAt)
+	assert.Len(t, scheduler.queue, 1)
+}
+
+func TestRun_RebuildsAndSends(t *testing.T) {
+	start := time.Now().Add(200 * time.Millisecond)
+	repo := &mockRepo{
+		events: []*domains.Event{
+			{ID: 1, UserID: 7, Title: "stored", Date: start, RemindBefore: []time.Duration{150 * time.Millisecond}},
+		},
+	}
+	notifications := make(chanNotifier, 2)
+	scheduler := NewScheduler(repo, notifications)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	select {
+	case reminder := <-notifications:
+		assert.Equal(t, 1, reminder.EventID)
+		assert.Equal(t, 7, reminder.UserID)
+		assert.Equal(t, 150*time.Millisecond, reminder.RemindBefore)
+	case <-time.After(2 * time.Second):
+		t.Fatal("reminder of a stored event was not sent")
+	}
+
+	scheduler.EventCreated(ctx, &domains.Event{
+		ID:           2,
+		Title:        "created",
+		Date:         time.Now().Add(100 * time.Millisecond),
+		RemindBefore: []time.Duration{50 * time.Millisecond},
+	})
+	deleted := &domains.Event{
+		ID:           3,
+		Title:        "deleted",
+		Date:         time.Now().Add(100 * time.Millisecond),
+		RemindBefore: []time.Duration{0},
+	}
+	scheduler.EventCreated(ctx, deleted)
+	scheduler.EventDeleted(ctx, deleted)
+
+	select {
+	case reminder := <-notifications:
+		assert.Equal(t, "created", reminder.Title)
+	case <-time.After(2 * time.Second):
+		t.Fatal("reminder of a created event was not sent")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Empty(t, notifications)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan webhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, time.Second)
+	err := notifier.Notify(context.Background(), Reminder{
+		EventID:      1,
+		UserID:       2,
+		Title:        "meeting",
+		Start:        time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC),
+		RemindBefore: 15 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, webhookPayload{
+		EventID:      1,
+		UserID:       2,
+		Title:        "meeting",
+		Start:        "2026-03-11T10:00:00Z",
+		RemindBefore: "15m0s",
+	}, <-received)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, time.Second).Notify(context.Background(), Reminder{})
+	assert.ErrorContains(t, err, "502")
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// AppendAudit stores the entry, the entries are numbered apart from the events.
+func (er *EventRepository) AppendAudit(ctx context.Context, entry *domains.AuditEntry) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		entry.ID = len(er.audit) + 1
+
+		return er.record(walRecord{Op: walAudit, Audit: entry})
+	}
+}
+
+// AuditEntries returns the entries matching the filter, newest first.
+func (er *EventRepository) AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		entries := make([]*domains.AuditEntry, 0)
+
+		for i := len(er.audit) - 1; i >= 0; i-- {
+			if filter.Limit > 0 && len(entries) == filter.Limit {
+				break
+			}
+
+			if filter.Matches(er.audit[i]) {
+				entries = append(entries, er.audit[i])
+			}
+		}
+
+		return entries, nil
+	}
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRepository is implemented by both repositories, so they share the audit tests.
+type auditRepository interface {
+	AppendAudit(ctx context.Context, entry *domains.AuditEntry) error
+	AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error)
+}
+
+func testAudit(t *testing.T, repo auditRepository) {
+	ctx := context.Background()
+	at := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	event := &domains.Event{ID: 1, UserID: 1, Title: "planning", Date: date(2026, 3, 11), Version: 1}
+	renamed := &domains.Event{ID: 1, UserID: 1, Title: "review", Date: date(2026, 3, 11), Version: 2}
+
+	entries := []*domains.AuditEntry{
+		{Action: domains.AuditCreated, EventID: 1, OwnerID: 1, ActorID: 1, RequestID: "a", Time: at, After: event},
+		{Action: domains.AuditUpdated, EventID: 1, OwnerID: 1, ActorID: 2, RequestID: "b", Time: at.Add(time.Hour), Before: event, After: renamed},
+		{Action: domains.AuditCreated, EventID: 2, OwnerID: 3, ActorID: 3, Time: at.Add(2 * time.Hour)},
+		{Action: domains.AuditDeleted, EventID: 1, OwnerID: 1, ActorID: 2, Time: at.Add(3 * time.Hour), Before: renamed},
+	}
+	for i, entry := range entries {
+		require.NoError(t, repo.AppendAudit(ctx, entry))
+		assert.Equal(t, i+1, entry.ID)
+	}
+
+	found, err := repo.AuditEntries(ctx, domains.AuditFilter{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, 4, found[0].ID)
+	assert.Nil(t, found[0].After)
+	assert.Equal(t, "review", found[0].Before.Title)
+	assert.Equal(t, "b", found[1].RequestID)
+	assert.Equal(t, "planning", found[1].Before.Title)
+	assert.True(t, at.Add(time.Hour).Equal(found[1].Time))
+
+	// the actor sees the changes it made to events of other users
+	found, err = repo.AuditEntries(ctx, domains.AuditFilter{UserID: 2, Action: domains.AuditDeleted})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, 4, found[0].ID)
+
+	found, err = repo.AuditEntries(ctx, domains.AuditFilter{EventID: 1, From: at.Add(time.Hour), To: at.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, 2, found[0].ID)
+
+	found, err = repo.AuditEntries(ctx, domains.AuditFilter{BeforeID: 4, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, 3, found[0].ID)
+	assert.Equal(t, 2, found[1].ID)
+}
+
+func TestAudit(t *testing.T) {
+	testAudit(t, NewEventRepository())
+}
+
+func TestSQLAudit(t *testing.T) {
+	testAudit(t, newSQLRepo(t))
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+func (er *EventRepository) Calendar(ctx context.Context, id int) (*domains.Calendar, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		calendar, ok := er.calendars[id]
+		if !ok {
+			return nil, domains.ErrCalendarNotFound
+		}
+
+		return calendar, nil
+	}
+}
+
+// Calendars returns the calendars the user owns or which are shared with the user, ordered by id.
+func (er *EventRepository) Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return er.sortedCalendars(func(calendar *domains.Calendar) bool {
+			_, ok := calendar.Access(userId)
+
+			return ok
+		}), nil
+	}
+}
+
+func (er *EventRepository) CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		calendar.ID = er.currentCalendarId
+
+		if err := er.record(walRecord{Op: walCreateCalendar, Calendar: calendar}); err != nil {
+			return nil, err
+		}
+
+		return calendar, nil
+	}
+}
+
+// UpdateCalendar replaces the name and the shares of the calendar.
+func (er *EventRepository) UpdateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		if _, ok := er.calendars[calendar.ID]; !ok {
+			return nil, domains.ErrCalendarNotFound
+		}
+
+		if err := er.record(walRecord{Op: walUpdateCalendar, Calendar: calendar}); err != nil {
+			return nil, err
+		}
+
+		return calendar, nil
+	}
+}
+
+// DeleteCalendar removes the calendar, it fails with ErrCalendarNotEmpty while the calendar holds events.
+func (er *EventRepository) DeleteCalendar(ctx context.Context, id int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if _, ok := er.calendars[id]; !ok {
+			return domains.ErrCalendarNotFound
+		}
+
+		for _, event := range er.store {
+			if event.CalendarID == id {
+				return domains.ErrCalendarNotEmpty
+			}
+		}
+
+		return er.record(walRecord{Op: walDeleteCalendar, ID: id})
+	}
+}
+
+// CalendarEvents works like List without a page for the events of the calendar.
+func (er *EventRepository) CalendarEvents(ctx context.Context, calendarId int, from, to time.Time) ([]*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		events := make([]*domains.Event, 0)
+
+		for _, event := range er.store {
+			if event.CalendarID == calendarId && inRange(event, from, to) {
+				events = append(events, event)
+			}
+		}
+
+		domains.SortEvents(events)
+
+		return events, nil
+	}
+}
+
+// sortedCalendars returns the calendars matching keep ordered by id, it has to be called with the lock held.
+func (er *EventRepository) sortedCalendars(keep func(*domains.Calendar) bool) []*domains.Calendar {
+	calendars := make([]*domains.Calendar, 0)
+
+	for _, calendar := range er.calendars {
+		if keep(calendar) {
+			calendars = append(calendars, calendar)
+		}
+	}
+
+	slices.SortFunc(calendars, func(a, b *domains.Calendar) int {
+		return a.ID - b.ID
+	})
+
+	return calendars
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calendarRepository is implemented by both repositories, so they share the calendar tests.
+type calendarRepository interface {
+	Calendar(ctx context.Context, id int) (*domains.Calendar, error)
+	Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error)
+	CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error)
+	UpdateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error)
+	DeleteCalendar(ctx context.Context, id int) error
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	Delete(ctx context.Context, id int, version int) error
+}
+
+func testCalendars(t *testing.T, repo calendarRepository, events func(calendarId int) []*domains.Event) {
+	ctx := context.Background()
+
+	work, err := repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 1, Name: "work"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, work.ID)
+
+	_, err = repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 2, Name: "team", Shares: []domains.Share{
+		{UserID: 1, Permission: domains.PermissionWrite},
+	}})
+	require.NoError(t, err)
+	_, err = repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 2, Name: "private"})
+	require.NoError(t, err)
+
+	calendars, err := repo.Calendars(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, calendars, 2)
+	assert.Equal(t, "work", calendars[0].Name)
+	assert.Equal(t, []domains.Share{{UserID: 1, Permission: domains.PermissionWrite}}, calendars[1].Shares)
+
+	_, err = repo.UpdateCalendar(ctx, &domains.Calendar{ID: 1, OwnerID: 1, Name: "office", Shares: []domains.Share{
+		{UserID: 3, Permission: domains.PermissionRead},
+	}})
+	require.NoError(t, err)
+
+	office, err := repo.Calendar(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "office", office.Name)
+
+	calendars, err = repo.Calendars(ctx, 3)
+	require.NoError(t, err)
+	assert.Len(t, calendars, 1)
+
+	_, err = repo.UpdateCalendar(ctx, &domains.Calendar{ID: 9, OwnerID: 1, Name: "missing"})
+	assert.ErrorIs(t, err, domains.ErrCalendarNotFound)
+
+	repo.Create(ctx, &domains.Event{UserID: 1, CalendarID: 1, Title: "review", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "personal", Date: date(2026, 3, 11)})
+
+	inCalendar := events(1)
+	require.Len(t, inCalendar, 1)
+	assert.Equal(t, "review", inCalendar[0].Title)
+	assert.Equal(t, 1, inCalendar[0].CalendarID)
+
+	assert.ErrorIs(t, repo.DeleteCalendar(ctx, 1), domains.ErrCalendarNotEmpty)
+	require.NoError(t, repo.Delete(ctx, inCalendar[0].ID, 0))
+	require.NoError(t, repo.DeleteCalendar(ctx, 1))
+
+	_, err = repo.Calendar(ctx, 1)
+	assert.ErrorIs(t, err, domains.ErrCalendarNotFound)
+	assert.ErrorIs(t, repo.DeleteCalendar(ctx, 1), domains.ErrCalendarNotFound)
+}
+
+func TestCalendars(t *testing.T) {
+	repo := NewEventRepository()
+
+	testCalendars(t, repo, func(calendarId int) []*domains.Event {
+		events, err := repo.CalendarEvents(context.Background(), calendarId, date(2026, 3, 1), date(2026, 4, 1))
+		require.NoError(t, err)
+
+		return events
+	})
+}
+
+func TestSQLCalendars(t *testing.T) {
+	repo := newSQLRepo(t)
+
+	testCalendars(t, repo, func(calendarId int) []*domains.Event {
+		events, err := repo.CalendarEvents(context.Background(), calendarId, date(2026, 3, 1), date(2026, 4, 1))
+		require.NoError(t, err)
+
+		return events
+	})
+}
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+const (
+	walFile      = "events.wal"
+	snapshotFile = "events.snapshot"
+)
+
+type PersistenceOptions struct {
+	Sync SyncPolicy
+	// SyncInterval is how often the log is flushed with SyncInterval
+	SyncInterval time.Duration
+	// SnapshotInterval is how often the state is written to a snapshot and the log emptied,
+	// zero only writes one on Close
+	SnapshotInterval time.Duration
+}
+
+// snapshot is the compacted state of the repository, Seq is the last record it includes.
+type snapshot struct {
+	Seq            uint64                   `json:"seq"`
+	NextID         int                      `json:"next_id"`
+	Events         []*domains.Event         `json:"events"`
+	History        map[int][]*domains.Event `json:"history"`
+	Trash          []*domains.Event         `json:"trash"`
+	NextCalendarID int                      `json:"next_calendar_id"`
+	Calendars      []*domains.Calendar      `json:"calendars"`
+	Audit          []*domains.AuditEntry    `json:"audit"`
+}
+
+// persistence keeps the state of an EventRepository in dir as a snapshot and a write-ahead log of the changes since.
+type persistence struct {
+	dir  string
+	log  *wal
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewDurableEventRepository creates an EventRepository which survives restarts. It recovers the state from the
+// snapshot and the log in dir, then logs every change before applying it. Close has to be called on shutdown.
+func NewDurableEventRepository(dir string, options PersistenceOptions) (*EventRepository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	er := NewEventRepository()
+
+	seq, err := er.loadSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+
+	log, records, err := openWAL(filepath.Join(dir, walFile), options.Sync)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed := 0
+	for _, record := range records {
+		// records before a crash between writing a snapshot and emptying the log are in the snapshot already
+		if record.Seq <= seq {
+			continue
+		}
+
+		er.apply(record)
+		replayed++
+	}
+	log.seq = max(log.seq, seq)
+
+	slog.Info("[Repository] recovered events", "snapshot_seq", seq, "replayed", replayed, "events", len(er.store))
+
+	er.persistence = &persistence{
+		dir:  dir,
+		log:  log,
+		stop: make(chan struct{}),
+	}
+
+	if options.Sync == SyncInterval && options.SyncInterval > 0 {
+		er.persistence.every(options.SyncInterval, func() {
+			if err := log.sync(); err != nil {
+				slog.Error("[Repository] error syncing write-ahead log", "error", err)
+			}
+		})
+	}
+
+	if options.SnapshotInterval > 0 {
+		er.persistence.every(options.SnapshotInterval, func() {
+			if err := er.Snapshot(); err != nil {
+				slog.Error("[Repository] error writing snapshot", "error", err)
+			}
+		})
+	}
+
+	return er, nil
+}
+
+func (p *persistence) every(interval time.Duration, fn func()) {
+	p.done.Add(1)
+
+	go func() {
+		defer p.done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+// Snapshot writes the state to the snapshot file and empties the log. It does nothing for a volatile repository.
+func (er *EventRepository) Snapshot() error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if er.persistence == nil {
+		return nil
+	}
+
+	state := snapshot{
+		Seq:     er.persistence.log.seq,
+		NextID:  er.currentId,
+		Events:  make([]*domains.Event, 0, len(er.store)),
+		History: er.history,
+		Trash:   er.sortedTrash(func(*domains.Event) bool { return true }),
+
+		NextCalendarID: er.currentCalendarId,
+		Calendars:      er.sortedCalendars(func(*domains.Calendar) bool { return true }),
+		Audit:          er.audit,
+	}
+	for _, event := range er.store {
+		state.Events = append(state.Events, event)
+	}
+	domains.SortEvents(state.Events)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err = writeFileAtomic(filepath.Join(er.persistence.dir, snapshotFile), data); err != nil {
+		return err
+	}
+
+	return er.persistence.log.reset()
+}
+
+// Close writes a final snapshot and closes the log. It does nothing for a volatile repository.
+func (er *EventRepository) Close() error {
+	if er.persistence == nil {
+		return nil
+	}
+
+	close(er.persistence.stop)
+	er.persistence.done.Wait()
+
+	snapshotErr := er.Snapshot()
+
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	return errors.Join(snapshotErr, er.persistence.log.close())
+}
+
+// loadSnapshot restores the state of the snapshot at path if there is one and returns its sequence number.
+func (er *EventRepository) loadSnapshot(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var state snapshot
+	if err = json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	for _, event := range state.Events {
+		if err = restoreLocations(event); err != nil {
+			return 0, err
+		}
+
+		er.store[event.ID] = event
+		er.index.Add(event.ID, event.UserID, event.Title, event.Description)
+	}
+
+	for id, versions := range state.History {
+		for _, event := range versions {
+			if err = restoreLocations(event); err != nil {
+				return 0, err
+			}
+		}
+
+		er.history[id] = versions
+	}
+
+	for _, event := range state.Trash {
+		if err = restoreLocations(event); err != nil {
+			return 0, err
+		}
+
+		er.trash[event.ID] = event
+	}
+
+	er.currentId = max(er.currentId, state.NextID)
+
+	for _, calendar := range state.Calendars {
+		er.calendars[calendar.ID] = calendar
+	}
+	er.currentCalendarId = max(er.currentCalendarId, state.NextCalendarID)
+	er.audit = state.Audit
+
+	return state.Seq, nil
+}
+
+// writeFileAtomic replaces the file at path so that a crash leaves either the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+
+	// the rename is only durable once the directory is synced
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("open data directory: %w", err)
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
@@ -0,0 +1,333 @@
+package repositories
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDurable(t *testing.T, dir string) *EventRepository {
+	t.Helper()
+
+	repo, err := NewDurableEventRepository(dir, PersistenceOptions{Sync: SyncAlways})
+	require.NoError(t, err)
+
+	return repo
+}
+
+// crash drops the repository without the final snapshot of Close.
+func crash(t *testing.T, repo *EventRepository) {
+	t.Helper()
+
+	require.NoError(t, repo.persistence.log.file.Close())
+}
+
+func TestDurable_RecoversFromLog(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	repo := openDurable(t, dir)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "standup", Date: time.Date(2026, 3, 11, 9, 0, 0, 0, berlin), TimeZone: "Europe/Berlin"})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "lunch", Date: date(2026, 3, 12)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "gone", Date: date(2026, 3, 13)})
+	_, err = repo.Update(ctx, &domains.Event{ID: 2, UserID: 1, Title: "long lunch", Date: date(2026, 3, 12)})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, 3, 0))
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	standup, err := repo.Event(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, berlin, standup.Date.Location())
+	assert.Equal(t, 9, standup.Date.Hour())
+
+	lunch, err := repo.Event(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "long lunch", lunch.Title)
+	assert.Equal(t, 2, lunch.Version)
+
+	_, err = repo.Event(ctx, 3)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	history, err := repo.History(ctx, 3)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	found, err := repo.Search(ctx, 1, "long")
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	created, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "next", Date: date(2026, 3, 14)})
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.ID)
+}
+
+func TestDurable_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "first", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "second", Date: date(2026, 3, 12)})
+	require.NoError(t, repo.Snapshot())
+
+	info, err := os.Stat(filepath.Join(dir, walFile))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	require.NoError(t, repo.Delete(ctx, 1, 0))
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "second", events[0].Title)
+
+	require.NoError(t, repo.Close())
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	events, err = repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestDurable_SkipsRecordsInSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "first", Date: date(2026, 3, 11)})
+
+	// a crash after writing the snapshot but before emptying the log
+	logged, err := os.ReadFile(filepath.Join(dir, walFile))
+	require.NoError(t, err)
+	require.NoError(t, repo.Snapshot())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, walFile), logged, 0o644))
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestDurable_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "kept", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "torn", Date: date(2026, 3, 12)})
+	crash(t, repo)
+
+	path := filepath.Join(dir, walFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-5))
+
+	repo = openDurable(t, dir)
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "kept", events[0].Title)
+
+	// the torn record is cut off, so new records are not appended after garbage
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "after", Date: date(2026, 3, 13)})
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	events, err = repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestDurable_CorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "first", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "second", Date: date(2026, 3, 12)})
+	crash(t, repo)
+
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[walHeaderSize+2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = NewDurableEventRepository(dir, PersistenceOptions{Sync: SyncAlways})
+	assert.ErrorIs(t, err, ErrCorruptLog)
+}
+
+func TestDurable_DamagedLength(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	for _, title := range []string{"first", "second", "third"} {
+		repo.Create(ctx, &domains.Event{UserID: 1, Title: title, Date: date(2026, 3, 11)})
+	}
+	crash(t, repo)
+
+	path := filepath.Join(dir, walFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// the length of the second record now runs past the end of the file
+	second := walHeaderSize + int(binary.LittleEndian.Uint32(data[0:4]))
+	data[second+2] = 0x01
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = NewDurableEventRepository(dir, PersistenceOptions{Sync: SyncAlways})
+	assert.ErrorIs(t, err, ErrCorruptLog)
+
+	// nothing has been truncated
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, after, len(data))
+}
+
+func TestDurable_RecoversBatch(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	_, err := repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "first", Date: date(2026, 3, 11)}},
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "second", Date: date(2026, 3, 12)}},
+	})
+	require.NoError(t, err)
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestDurable_RecoversCalendars(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 1, Name: "work"})
+	repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 1, Name: "gone"})
+	require.NoError(t, repo.Snapshot())
+	_, err := repo.UpdateCalendar(ctx, &domains.Calendar{ID: 1, OwnerID: 1, Name: "office", Shares: []domains.Share{
+		{UserID: 2, Permission: domains.PermissionAdmin},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteCalendar(ctx, 2))
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	calendars, err := repo.Calendars(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, calendars, 1)
+	assert.Equal(t, "office", calendars[0].Name)
+
+	_, err = repo.Calendar(ctx, 2)
+	assert.ErrorIs(t, err, domains.ErrCalendarNotFound)
+
+	created, err := repo.CreateCalendar(ctx, &domains.Calendar{OwnerID: 1, Name: "next"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, created.ID)
+}
+
+func TestDurable_RecoversAudit(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	require.NoError(t, repo.AppendAudit(ctx, &domains.AuditEntry{Action: domains.AuditCreated, EventID: 1, OwnerID: 1}))
+	require.NoError(t, repo.Snapshot())
+	require.NoError(t, repo.AppendAudit(ctx, &domains.AuditEntry{Action: domains.AuditDeleted, EventID: 1, OwnerID: 1}))
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	entries, err := repo.AuditEntries(ctx, domains.AuditFilter{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, domains.AuditDeleted, entries[0].Action)
+
+	entry := &domains.AuditEntry{Action: domains.AuditCreated, EventID: 2, OwnerID: 1}
+	require.NoError(t, repo.AppendAudit(ctx, entry))
+	assert.Equal(t, 3, entry.ID)
+}
+
+func TestDurable_RecoversTrash(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openDurable(t, dir)
+	first, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "first", Date: date(2026, 3, 11), TimeZone: "UTC"})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "second", Date: date(2026, 3, 12), TimeZone: "UTC"})
+	require.NoError(t, err)
+	third, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "third", Date: date(2026, 3, 13), TimeZone: "UTC"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, first.ID, 0))
+	require.NoError(t, repo.Snapshot())
+	require.NoError(t, repo.Delete(ctx, second.ID, 0))
+	require.NoError(t, repo.Delete(ctx, third.ID, 0))
+
+	trashed, err := repo.TrashedEvent(ctx, second.ID)
+	require.NoError(t, err)
+	_, err = repo.Restore(ctx, trashed)
+	require.NoError(t, err)
+
+	_, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+
+	trash, err := repo.Trash(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	restored, err := repo.Event(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.Version)
+
+	require.NoError(t, repo.Delete(ctx, second.ID, 0))
+	require.NoError(t, repo.Snapshot())
+	crash(t, repo)
+
+	repo = openDurable(t, dir)
+	defer repo.Close()
+
+	trash, err = repo.Trash(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, "second", trash[0].Title)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+}
@@ -2,16 +2,31 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/search"
 )
 
 type EventRepository struct {
 	currentId int
 	mu        sync.RWMutex
 	store     map[int]*domains.Event
+	// history holds the replaced versions of every event, oldest first
+	history map[int][]*domains.Event
+	// trash holds the deleted events until they are restored or purged
+	trash map[int]*domains.Event
+	index *search.Index
+	// calendars are numbered apart from the events
+	currentCalendarId int
+	calendars         map[int]*domains.Calendar
+	// audit holds the audit entries in the order they were appended, an entry is at the index of its id minus one
+	audit []*domains.AuditEntry
+	// persistence is nil for a volatile repository
+	persistence *persistence
 }
 
 func NewEventRepository() *EventRepository {
@@ -19,6 +34,12 @@ func NewEventRepository() *EventRepository {
 		currentId: 1,
 		mu:        sync.RWMutex{},
 		store:     make(map[int]*domains.Event),
+		history:   make(map[int][]*domains.Event),
+		trash:     make(map[int]*domains.Event),
+		index:     search.NewIndex(),
+
+		currentCalendarId: 1,
+		calendars:         make(map[int]*domains.Calendar),
 	}
 }
 
@@ -40,7 +61,66 @@ func (er *EventRepository) Event(ctx context.Context, id int) (*domains.Event, e
 
 }
 
-func (er *EventRepository) List(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
+// List returns the events of the page and the total number of events in the range, recurring series are
+// returned whatever the page.
+func (er *EventRepository) List(ctx context.Context, userId int, from, to time.Time, page domains.Page) ([]*domains.Event, int, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	default:
+		series := make([]*domains.Event, 0)
+		single := make([]*domains.Event, 0)
+
+		for _, event := range er.store {
+			if event.UserID != userId || !inRange(event, from, to) {
+				continue
+			}
+
+			if event.IsRecurring() {
+				series = append(series, event)
+			} else {
+				single = append(single, event)
+			}
+		}
+
+		domains.SortEvents(single)
+		selected, _ := domains.Paginate(single, page.After, page.Limit)
+
+		events := append(series, selected...)
+		domains.SortEvents(events)
+
+		return events, len(series) + len(single), nil
+	}
+}
+
+// ListAll works like List without a page for the events of every user.
+func (er *EventRepository) ListAll(ctx context.Context, from, to time.Time) ([]*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		events := make([]*domains.Event, 0)
+
+		for _, event := range er.store {
+			if inRange(event, from, to) {
+				events = append(events, event)
+			}
+		}
+
+		domains.SortEvents(events)
+
+		return events, nil
+	}
+}
+
+// Attending works like List without a page for the events the user is invited to, whatever the answer.
+func (er *EventRepository) Attending(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
 	er.mu.RLock()
 	defer er.mu.RUnlock()
 
@@ -51,15 +131,42 @@ func (er *EventRepository) List(ctx context.Context, userId int, from, to time.T
 		events := make([]*domains.Event, 0)
 
 		for _, event := range er.store {
-			if event.UserID == userId && !event.Date.Before(from) && event.Date.Before(to) {
+			if _, invited := event.Attendee(userId); invited && inRange(event, from, to) {
 				events = append(events, event)
 			}
 		}
 
+		domains.SortEvents(events)
+
 		return events, nil
 	}
 }
 
+// Invitations returns the page of the events the user is invited to with the answer status, any answer if
+// it is empty, and the total number of them. Recurring events are paged as series.
+func (er *EventRepository) Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) ([]*domains.Event, int, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	default:
+		events := make([]*domains.Event, 0)
+
+		for _, event := range er.store {
+			if attendee, invited := event.Attendee(userId); invited && (status == "" || attendee.Status == status) {
+				events = append(events, event)
+			}
+		}
+
+		domains.SortEvents(events)
+		selected, _ := domains.Paginate(events, page.After, page.Limit)
+
+		return selected, len(events), nil
+	}
+}
+
 func (er *EventRepository) Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
 	er.mu.Lock()
 	defer er.mu.Unlock()
@@ -69,13 +176,17 @@ func (er *EventRepository) Create(ctx context.Context, newEvent *domains.Event)
 		return nil, ctx.Err()
 	default:
 		newEvent.ID = er.currentId
-		er.store[er.currentId] = newEvent
-		er.currentId++
+		newEvent.Version = 1
+
+		if err := er.record(walRecord{Op: walCreate, Event: newEvent}); err != nil {
+			return nil, err
+		}
 
 		return newEvent, nil
 	}
 }
 
+// Update replaces the event, a non-zero event.Version has to match the stored one.
 func (er *EventRepository) Update(ctx context.Context, event *domains.Event) (*domains.Event, error) {
 	er.mu.Lock()
 	defer er.mu.Unlock()
@@ -84,18 +195,23 @@ func (er *EventRepository) Update(ctx context.Context, event *domains.Event) (*d
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
-		_, ok := er.store[event.ID]
-		if !ok {
-			return nil, domains.ErrEventNotFound
+		stored, err := er.current(event.ID, event.Version)
+		if err != nil {
+			return nil, err
 		}
 
-		er.store[event.ID] = event
+		event.Version = stored.Version + 1
+
+		if err = er.record(walRecord{Op: walUpdate, Event: event}); err != nil {
+			return nil, err
+		}
 
 		return event, nil
 	}
 }
 
-func (er *EventRepository) Delete(ctx context.Context, id int) error {
+// Delete moves the event to the trash, a non-zero version has to match the stored one.
+func (er *EventRepository) Delete(ctx context.Context, id int, version int) error {
 	er.mu.Lock()
 	defer er.mu.Unlock()
 
@@ -103,14 +219,213 @@ func (er *EventRepository) Delete(ctx context.Context, id int) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		_, ok := er.store[id]
-		if !ok {
-			return domains.ErrEventNotFound
+		if _, err := er.current(id, version); err != nil {
+			return err
+		}
+
+		return er.record(walRecord{Op: walDelete, ID: id, Time: time.Now()})
+	}
+}
+
+// Apply runs the operations as a whole, if one fails none is applied and a *domains.BatchError is returned.
+// The result holds the stored event of every create and update, nil for deletions.
+func (er *EventRepository) Apply(ctx context.Context, operations []domains.Operation) ([]*domains.Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// versions holds the versions of the events changed by the operations checked so far, zero for deleted ones
+	versions := make(map[int]int)
+	nextId := er.currentId
+	records := make([]walRecord, 0, len(operations))
+	result := make([]*domains.Event, len(operations))
+
+	for i, operation := range operations {
+		switch operation.Kind {
+		case domains.OperationCreate:
+			operation.Event.ID = nextId
+			operation.Event.Version = 1
+			nextId++
+
+			records = append(records, walRecord{Op: walCreate, Event: operation.Event})
+		case domains.OperationUpdate:
+			stored, err := er.staged(versions, operation.Event.ID, operation.Event.Version)
+			if err != nil {
+				return nil, &domains.BatchError{Index: i, Err: err}
+			}
+			operation.Event.Version = stored + 1
+
+			records = append(records, walRecord{Op: walUpdate, Event: operation.Event})
+		case domains.OperationDelete:
+			if _, err := er.staged(versions, operation.ID, operation.Version); err != nil {
+				return nil, &domains.BatchError{Index: i, Err: err}
+			}
+			versions[operation.ID] = 0
+
+			records = append(records, walRecord{Op: walDelete, ID: operation.ID, Time: time.Now()})
+			continue
+		default:
+			return nil, &domains.BatchError{Index: i, Err: fmt.Errorf("unknown operation %q", operation.Kind)}
+		}
+
+		versions[operation.Event.ID] = operation.Event.Version
+		result[i] = operation.Event
+	}
+
+	if err := er.record(walRecord{Op: walBatch, Records: records}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Search returns the events of the user whose title or description match the query,
+// ordered by date and id. Recurring events are not expanded.
+func (er *EventRepository) Search(ctx context.Context, userId int, query string) ([]*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		events := make([]*domains.Event, 0)
+
+		for _, id := range er.index.Search(userId, query) {
+			events = append(events, er.store[id])
+		}
+
+		domains.SortEvents(events)
+
+		return events, nil
+	}
+}
+
+// History returns the replaced and deleted versions of the event, oldest first.
+func (er *EventRepository) History(ctx context.Context, id int) ([]*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return slices.Clone(er.history[id]), nil
+	}
+}
+
+// current returns the stored event if version is zero or matches it, it has to be called with the lock held.
+func (er *EventRepository) current(id int, version int) (*domains.Event, error) {
+	stored, ok := er.store[id]
+	if !ok {
+		return nil, domains.ErrEventNotFound
+	}
+
+	if version != 0 && version != stored.Version {
+		return nil, domains.ErrVersionMismatch
+	}
+
+	return stored, nil
+}
+
+// staged works like current, taking the changes of a batch checked so far into account.
+func (er *EventRepository) staged(versions map[int]int, id int, version int) (int, error) {
+	stored, ok := versions[id]
+	if !ok {
+		event, err := er.current(id, version)
+		if err != nil {
+			return 0, err
 		}
 
-		delete(er.store, id)
+		return event.Version, nil
+	}
 
-		return nil
+	if stored == 0 {
+		return 0, domains.ErrEventNotFound
+	}
 
+	if version != 0 && version != stored {
+		return 0, domains.ErrVersionMismatch
 	}
+
+	return stored, nil
+}
+
+// record logs the change if the repository is durable, then applies it. It has to be called with the write lock held.
+func (er *EventRepository) record(record walRecord) error {
+	if er.persistence != nil {
+		if err := er.persistence.log.append(record); err != nil {
+			return err
+		}
+	}
+
+	er.apply(record)
+
+	return nil
+}
+
+// apply changes the state, replaced versions of an event move to its history.
+func (er *EventRepository) apply(record walRecord) {
+	switch record.Op {
+	case walCreate:
+		event := record.Event
+		er.store[event.ID] = event
+		er.index.Add(event.ID, event.UserID, event.Title, event.Description)
+		er.currentId = max(er.currentId, event.ID+1)
+	case walUpdate:
+		event := record.Event
+		if stored, ok := er.store[event.ID]; ok {
+			er.history[event.ID] = append(er.history[event.ID], stored)
+		}
+		er.store[event.ID] = event
+		er.index.Add(event.ID, event.UserID, event.Title, event.Description)
+	case walDelete:
+		if stored, ok := er.store[record.ID]; ok {
+			er.history[record.ID] = append(er.history[record.ID], stored)
+
+			// deletions logged before the trash existed remove the event for good
+			if !record.Time.IsZero() {
+				trashed := *stored
+				trashed.DeletedAt = record.Time
+				er.trash[record.ID] = &trashed
+			}
+		}
+		delete(er.store, record.ID)
+		er.index.Remove(record.ID)
+	case walRestore:
+		event := record.Event
+		delete(er.trash, event.ID)
+		er.store[event.ID] = event
+		er.index.Add(event.ID, event.UserID, event.Title, event.Description)
+	case walPurge:
+		delete(er.trash, record.ID)
+		delete(er.history, record.ID)
+	case walBatch:
+		for _, nested := range record.Records {
+			er.apply(nested)
+		}
+	case walCreateCalendar, walUpdateCalendar:
+		er.calendars[record.Calendar.ID] = record.Calendar
+		er.currentCalendarId = max(er.currentCalendarId, record.Calendar.ID+1)
+	case walDeleteCalendar:
+		delete(er.calendars, record.ID)
+	case walAudit:
+		er.audit = append(er.audit, record.Audit)
+	}
+}
+
+// inRange reports whether the event may have an occurrence in [from, to).
+// Recurring events are returned whenever the series starts before to,
+// expanding them is up to the caller.
+func inRange(event *domains.Event, from, to time.Time) bool {
+	if event.IsRecurring() {
+		return event.Date.Before(to)
+	}
+
+	return event.Overlaps(from, to)
 }
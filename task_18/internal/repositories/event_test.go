@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -55,12 +56,66 @@ func TestList(t *testing.T) {
 	repo.Create(ctx, &domains.Event{UserID: 1, Title: "mar 13", Date: date(2026, 3, 13)})
 	repo.Create(ctx, &domains.Event{UserID: 2, Title: "other user", Date: date(2026, 3, 11)})
 
-	events, err := repo.List(ctx, 1, date(2026, 3, 11), date(2026, 3, 12))
+	events, _, err := repo.List(ctx, 1, date(2026, 3, 11), date(2026, 3, 12), domains.Page{})
 	require.NoError(t, err)
 	require.Len(t, events, 1)
 	assert.Equal(t, "mar 11", events[0].Title)
 }
 
+// listRepository is what testListPage needs of a repository.
+type listRepository interface {
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	List(ctx context.Context, userId int, from, to time.Time, page domains.Page) ([]*domains.Event, int, error)
+}
+
+func testListPage(t *testing.T, repo listRepository) {
+	ctx := context.Background()
+
+	for day := 11; day <= 14; day++ {
+		_, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "single", Date: date(2026, 3, day), TimeZone: "UTC"})
+		require.NoError(t, err)
+	}
+	weekly, err := repo.Create(ctx, &domains.Event{
+		UserID:     1,
+		Title:      "weekly",
+		Date:       date(2026, 1, 5),
+		TimeZone:   "UTC",
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyWeekly},
+	})
+	require.NoError(t, err)
+
+	titles := func(events []*domains.Event) []string {
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, fmt.Sprintf("%s %d", event.Title, event.ID))
+		}
+		return result
+	}
+
+	events, total, err := repo.List(ctx, 1, date(2026, 3, 1), date(2026, 4, 1), domains.Page{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	// the series comes whatever the page, its occurrences may follow the cursor
+	assert.Equal(t, []string{fmt.Sprintf("weekly %d", weekly.ID), "single 1", "single 2"}, titles(events))
+
+	events, total, err = repo.List(ctx, 1, date(2026, 3, 1), date(2026, 4, 1), domains.Page{
+		After: &domains.Cursor{Date: date(2026, 3, 12), ID: 2},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, []string{fmt.Sprintf("weekly %d", weekly.ID), "single 3", "single 4"}, titles(events))
+
+	events, total, err = repo.List(ctx, 1, date(2026, 3, 13), date(2026, 4, 1), domains.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, events, 3)
+}
+
+func TestListPage(t *testing.T) {
+	testListPage(t, NewEventRepository())
+}
+
 func TestUpdate(t *testing.T) {
 	repo := NewEventRepository()
 	ctx := context.Background()
@@ -88,13 +143,50 @@ func TestUpdate_NotFound(t *testing.T) {
 	assert.ErrorIs(t, err, domains.ErrEventNotFound)
 }
 
+func TestUpdate_Versions(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "original", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	updated, err := repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "modified", Date: date(2026, 3, 11), Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "stale", Date: date(2026, 3, 11), Version: 1})
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	err = repo.Delete(ctx, 1, 1)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	updated, err = repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "unchecked", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Version)
+
+	history, err := repo.History(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "original", history[0].Title)
+	assert.Equal(t, 1, history[0].Version)
+	assert.Equal(t, "modified", history[1].Title)
+	assert.Equal(t, 2, history[1].Version)
+
+	require.NoError(t, repo.Delete(ctx, 1, 3))
+
+	history, err = repo.History(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+}
+
 func TestDelete(t *testing.T) {
 	repo := NewEventRepository()
 	ctx := context.Background()
 
 	repo.Create(ctx, &domains.Event{UserID: 1, Title: "to delete", Date: date(2026, 3, 11)})
 
-	err := repo.Delete(ctx, 1)
+	err := repo.Delete(ctx, 1, 0)
 	require.NoError(t, err)
 
 	_, err = repo.Event(ctx, 1)
@@ -105,6 +197,176 @@ func TestDelete_NotFound(t *testing.T) {
 	repo := NewEventRepository()
 	ctx := context.Background()
 
-	err := repo.Delete(ctx, 999)
+	err := repo.Delete(ctx, 999, 0)
 	assert.ErrorIs(t, err, domains.ErrEventNotFound)
 }
+
+func TestSearch(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "Встреча с командой", Date: date(2026, 3, 12)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "обед", Description: "после встречи", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 2, Title: "встреча", Date: date(2026, 3, 11)})
+
+	events, err := repo.Search(ctx, 1, "ВСТРЕЧ")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "обед", events[0].Title)
+	assert.Equal(t, "Встреча с командой", events[1].Title)
+
+	_, err = repo.Update(ctx, &domains.Event{ID: 2, UserID: 1, Title: "обед", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, 1, 0))
+
+	events, err = repo.Search(ctx, 1, "встреча")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestList_Sorted(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "c", Date: date(2026, 3, 12)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "a", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "b", Date: date(2026, 3, 11)})
+
+	events, _, err := repo.List(ctx, 1, date(2026, 3, 1), date(2026, 4, 1), domains.Page{})
+	require.NoError(t, err)
+
+	var titles []string
+	for _, event := range events {
+		titles = append(titles, event.Title)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, titles)
+}
+
+func TestApply(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "kept", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "removed", Date: date(2026, 3, 12)})
+
+	events, err := repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "added", Date: date(2026, 3, 13)}},
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: 1, UserID: 1, Title: "renamed", Date: date(2026, 3, 11), Version: 1}},
+		{Kind: domains.OperationDelete, ID: 2},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, 3, events[0].ID)
+	assert.Equal(t, 2, events[1].Version)
+	assert.Nil(t, events[2])
+
+	_, err = repo.Event(ctx, 2)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	found, err := repo.Search(ctx, 1, "renamed")
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestApply_Atomic(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "kept", Date: date(2026, 3, 11)})
+
+	_, err := repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "added", Date: date(2026, 3, 13)}},
+		{Kind: domains.OperationDelete, ID: 1},
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: 1, UserID: 1, Title: "deleted before", Date: date(2026, 3, 11)}},
+	})
+	var batchErr *domains.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.Index)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "kept", events[0].Title)
+
+	created, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "next", Date: date(2026, 3, 14)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+}
+
+func TestAttending(t *testing.T) {
+	repo := NewEventRepository()
+	ctx := context.Background()
+
+	invited := []domains.Attendee{{UserID: 2, Status: domains.AttendeeDeclined}}
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "invited", Date: date(2026, 3, 11), Attendees: invited})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "not invited", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "later", Date: date(2026, 4, 11), Attendees: invited})
+
+	events, err := repo.Attending(ctx, 2, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "invited", events[0].Title)
+}
+
+// invitationRepository is what testInvitations needs of a repository.
+type invitationRepository interface {
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) ([]*domains.Event, int, error)
+}
+
+func testInvitations(t *testing.T, repo invitationRepository) {
+	ctx := context.Background()
+
+	invite := func(title string, d time.Time, status domains.AttendeeStatus, recurrence *domains.Recurrence) {
+		t.Helper()
+
+		_, err := repo.Create(ctx, &domains.Event{
+			UserID:     1,
+			Title:      title,
+			Date:       d,
+			Recurrence: recurrence,
+			Attendees:  []domains.Attendee{{UserID: 2, Status: status}, {UserID: 3, Status: domains.AttendeePending}},
+		})
+		require.NoError(t, err)
+	}
+
+	invite("third", date(2026, 3, 13), domains.AttendeePending, nil)
+	invite("first", date(2026, 3, 11), domains.AttendeeAccepted, nil)
+	invite("weekly", date(2026, 1, 5), domains.AttendeePending, &domains.Recurrence{Frequency: domains.FrequencyWeekly})
+	_, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "not invited", Date: date(2026, 3, 12)})
+	require.NoError(t, err)
+
+	titles := func(events []*domains.Event) []string {
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Title)
+		}
+		return result
+	}
+
+	// series are paged like the other events, by their first occurrence
+	events, total, err := repo.Invitations(ctx, 2, "", domains.Page{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"weekly", "first"}, titles(events))
+
+	events, total, err = repo.Invitations(ctx, 2, "", domains.Page{After: &domains.Cursor{Date: events[1].Date, ID: events[1].ID}, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"third"}, titles(events))
+
+	events, total, err = repo.Invitations(ctx, 2, domains.AttendeePending, domains.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []string{"weekly", "third"}, titles(events))
+
+	events, total, err = repo.Invitations(ctx, 4, "", domains.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, events)
+}
+
+func TestInvitations(t *testing.T) {
+	testInvitations(t, NewEventRepository())
+}
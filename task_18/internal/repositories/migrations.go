@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order, each one exactly once. Never edit an
+// already released migration, append a new one instead.
+var migrations = []string{
+	`CREATE TABLE events (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id     INTEGER NOT NULL,
+		title       TEXT    NOT NULL,
+		description TEXT    NOT NULL DEFAULT '',
+		date        INTEGER NOT NULL
+	)`,
+	`CREATE INDEX idx_events_user_date ON events (user_id, date)`,
+	`ALTER TABLE events ADD COLUMN recurrence TEXT`,
+	`ALTER TABLE events ADD COLUMN end_at INTEGER`,
+	`ALTER TABLE events ADD COLUMN time_zone TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE events ADD COLUMN remind_before TEXT`,
+	`ALTER TABLE events ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	`CREATE TABLE event_history (
+		id            INTEGER NOT NULL,
+		user_id       INTEGER NOT NULL,
+		title         TEXT    NOT NULL,
+		description   TEXT    NOT NULL,
+		date          INTEGER NOT NULL,
+		end_at        INTEGER,
+		time_zone     TEXT    NOT NULL,
+		recurrence    TEXT,
+		remind_before TEXT,
+		version       INTEGER NOT NULL,
+		archived_at   INTEGER NOT NULL,
+		PRIMARY KEY (id, version)
+	)`,
+	`ALTER TABLE events ADD COLUMN attendees TEXT`,
+	`ALTER TABLE event_history ADD COLUMN attendees TEXT`,
+	`CREATE TABLE calendars (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner_id INTEGER NOT NULL,
+		name     TEXT    NOT NULL,
+		shares   TEXT
+	)`,
+	`ALTER TABLE events ADD COLUMN calendar_id INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE event_history ADD COLUMN calendar_id INTEGER NOT NULL DEFAULT 0`,
+	`CREATE INDEX idx_events_calendar_date ON events (calendar_id, date)`,
+	`CREATE TABLE audit_log (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		action     TEXT    NOT NULL,
+		event_id   INTEGER NOT NULL,
+		owner_id   INTEGER NOT NULL,
+		actor_id   INTEGER NOT NULL,
+		request_id TEXT    NOT NULL,
+		at         INTEGER NOT NULL,
+		before     TEXT,
+		after      TEXT
+	)`,
+	`CREATE INDEX idx_audit_log_owner ON audit_log (owner_id, id)`,
+	`CREATE TABLE trash (
+		id            INTEGER PRIMARY KEY,
+		user_id       INTEGER NOT NULL,
+		calendar_id   INTEGER NOT NULL,
+		title         TEXT    NOT NULL,
+		description   TEXT    NOT NULL,
+		date          INTEGER NOT NULL,
+		end_at        INTEGER,
+		time_zone     TEXT    NOT NULL,
+		recurrence    TEXT,
+		remind_before TEXT,
+		attendees     TEXT,
+		version       INTEGER NOT NULL,
+		deleted_at    INTEGER NOT NULL
+	)`,
+	`CREATE INDEX idx_trash_user_date ON trash (user_id, date)`,
+	`CREATE INDEX idx_trash_deleted_at ON trash (deleted_at)`,
+	`ALTER TABLE events ADD COLUMN uid TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE events ADD COLUMN resource TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE event_history ADD COLUMN uid TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE event_history ADD COLUMN resource TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE trash ADD COLUMN uid TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE trash ADD COLUMN resource TEXT NOT NULL DEFAULT ''`,
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := applyMigration(ctx, db, version, migrations[version-1]); err != nil {
+			return fmt.Errorf("apply migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, query string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
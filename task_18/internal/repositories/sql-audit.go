@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// AppendAudit stores the entry, its time is kept in milliseconds.
+func (sr *SQLEventRepository) AppendAudit(ctx context.Context, entry *domains.AuditEntry) error {
+	before, err := encodeAuditEvent(entry.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := encodeAuditEvent(entry.After)
+	if err != nil {
+		return err
+	}
+
+	res, err := sr.db.ExecContext(ctx,
+		`INSERT INTO audit_log (action, event_id, owner_id, actor_id, request_id, at, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Action, entry.EventID, entry.OwnerID, entry.ActorID, entry.RequestID, entry.Time.UnixMilli(), before, after)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int(id)
+
+	return nil
+}
+
+// AuditEntries returns the entries matching the filter, newest first.
+func (sr *SQLEventRepository) AuditEntries(ctx context.Context, filter domains.AuditFilter) ([]*domains.AuditEntry, error) {
+	conditions := []string{"1 = 1"}
+	args := make([]any, 0)
+
+	if filter.UserID != 0 {
+		conditions = append(conditions, "(owner_id = ? OR actor_id = ?)")
+		args = append(args, filter.UserID, filter.UserID)
+	}
+	if filter.EventID != 0 {
+		conditions = append(conditions, "event_id = ?")
+		args = append(args, filter.EventID)
+	}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "at >= ?")
+		args = append(args, filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "at < ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	query := `SELECT id, action, event_id, owner_id, actor_id, request_id, at, before, after FROM audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := sr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*domains.AuditEntry, 0)
+
+	for rows.Next() {
+		var (
+			entry         domains.AuditEntry
+			at            int64
+			before, after sql.NullString
+		)
+
+		err := rows.Scan(&entry.ID, &entry.Action, &entry.EventID, &entry.OwnerID, &entry.ActorID, &entry.RequestID, &at, &before, &after)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Time = time.UnixMilli(at).UTC()
+
+		if entry.Before, err = decodeAuditEvent(before); err != nil {
+			return nil, fmt.Errorf("decode audit entry %d: %w", entry.ID, err)
+		}
+
+		if entry.After, err = decodeAuditEvent(after); err != nil {
+			return nil, fmt.Errorf("decode audit entry %d: %w", entry.ID, err)
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func encodeAuditEvent(event *domains.Event) (sql.NullString, error) {
+	if event == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := encodeJSON(event)
+	if err != nil {
+		return data, fmt.Errorf("encode audited event: %w", err)
+	}
+
+	return data, nil
+}
+
+func decodeAuditEvent(data sql.NullString) (*domains.Event, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+
+	var event domains.Event
+	if err := json.Unmarshal([]byte(data.String), &event); err != nil {
+		return nil, err
+	}
+
+	if err := restoreLocations(&event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
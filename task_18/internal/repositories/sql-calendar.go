@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+const calendarColumns = `id, owner_id, name, shares`
+
+func (sr *SQLEventRepository) Calendar(ctx context.Context, id int) (*domains.Calendar, error) {
+	row := sr.db.QueryRowContext(ctx,
+		`SELECT `+calendarColumns+` FROM calendars WHERE id = ?`, id)
+
+	calendar, err := scanCalendar(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domains.ErrCalendarNotFound
+		}
+
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+// Calendars returns the calendars the user owns or which are shared with the user, ordered by id.
+func (sr *SQLEventRepository) Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+calendarColumns+` FROM calendars
+		WHERE owner_id = ? OR EXISTS (SELECT 1 FROM json_each(calendars.shares) WHERE json_extract(value, '$.UserID') = ?)
+		ORDER BY id`,
+		userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calendars := make([]*domains.Calendar, 0)
+
+	for rows.Next() {
+		calendar, err := scanCalendar(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		calendars = append(calendars, calendar)
+	}
+
+	return calendars, rows.Err()
+}
+
+func (sr *SQLEventRepository) CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error) {
+	shares, err := encodeShares(calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sr.db.ExecContext(ctx,
+		`INSERT INTO calendars (owner_id, name, shares) VALUES (?, ?, ?)`,
+		calendar.OwnerID, calendar.Name, shares)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	calendar.ID = int(id)
+
+	return calendar, nil
+}
+
+// UpdateCalendar replaces the name and the shares of the calendar.
+func (sr *SQLEventRepository) UpdateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error) {
+	shares, err := encodeShares(calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sr.db.ExecContext(ctx,
+		`UPDATE calendars SET name = ?, shares = ? WHERE id = ?`,
+		calendar.Name, shares, calendar.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := expectRow(res, domains.ErrCalendarNotFound); err != nil {
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+// DeleteCalendar removes the calendar, it fails with ErrCalendarNotEmpty while the calendar holds events.
+func (sr *SQLEventRepository) DeleteCalendar(ctx context.Context, id int) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var used bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM events WHERE calendar_id = ?)`, id).Scan(&used)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return domains.ErrCalendarNotEmpty
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM calendars WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if err := expectRow(res, domains.ErrCalendarNotFound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CalendarEvents works like List without a page for the events of the calendar.
+func (sr *SQLEventRepository) CalendarEvents(ctx context.Context, calendarId int, from, to time.Time) ([]*domains.Event, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM events
+		WHERE calendar_id = ? AND date < ? AND (date >= ? OR end_at > ? OR recurrence IS NOT NULL)
+		ORDER BY date, id`,
+		calendarId, to.Unix(), from.Unix(), from.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+// expectRow returns notFound if the statement changed no row.
+func expectRow(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func scanCalendar(s scanner) (*domains.Calendar, error) {
+	var (
+		calendar domains.Calendar
+		shares   sql.NullString
+	)
+
+	if err := s.Scan(&calendar.ID, &calendar.OwnerID, &calendar.Name, &shares); err != nil {
+		return nil, err
+	}
+
+	if shares.Valid {
+		if err := json.Unmarshal([]byte(shares.String), &calendar.Shares); err != nil {
+			return nil, fmt.Errorf("decode shares of calendar %d: %w", calendar.ID, err)
+		}
+	}
+
+	return &calendar, nil
+}
+
+func encodeShares(calendar *domains.Calendar) (sql.NullString, error) {
+	if len(calendar.Shares) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	shares, err := encodeJSON(calendar.Shares)
+	if err != nil {
+		return shares, fmt.Errorf("encode shares: %w", err)
+	}
+
+	return shares, nil
+}
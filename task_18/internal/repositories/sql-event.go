@@ -0,0 +1,544 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/search"
+	_ "modernc.org/sqlite"
+)
+
+const eventColumns = `id, user_id, calendar_id, title, description, date, end_at, time_zone, recurrence, remind_before, attendees, version, uid, resource`
+
+type SQLEventRepository struct {
+	db *sql.DB
+	// index is the search index of the stored events, it is rebuilt when the repository is opened
+	index *search.Index
+}
+
+func NewSQLEventRepository(ctx context.Context, dsn string) (*SQLEventRepository, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// sqlite allows a single writer, and every connection to ":memory:" is a separate database
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	repo := &SQLEventRepository{
+		db:    db,
+		index: search.NewIndex(),
+	}
+
+	if err := repo.buildIndex(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (sr *SQLEventRepository) buildIndex(ctx context.Context) error {
+	rows, err := sr.db.QueryContext(ctx, `SELECT id, user_id, title, description FROM events`)
+	if err != nil {
+		return fmt.Errorf("build search index: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id, userId         int
+			title, description string
+		)
+
+		if err := rows.Scan(&id, &userId, &title, &description); err != nil {
+			return fmt.Errorf("build search index: %w", err)
+		}
+
+		sr.index.Add(id, userId, title, description)
+	}
+
+	return rows.Err()
+}
+
+func (sr *SQLEventRepository) Close() error {
+	return sr.db.Close()
+}
+
+func (sr *SQLEventRepository) Event(ctx context.Context, id int) (*domains.Event, error) {
+	row := sr.db.QueryRowContext(ctx,
+		`SELECT `+eventColumns+` FROM events WHERE id = ?`, id)
+
+	event, err := scanEvent(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domains.ErrEventNotFound
+		}
+
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// List returns the events of the page and the total number of events in the range, recurring series are
+// returned whatever the page.
+func (sr *SQLEventRepository) List(ctx context.Context, userId int, from, to time.Time, page domains.Page) ([]*domains.Event, int, error) {
+	var total int
+	err := sr.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM events
+		WHERE user_id = ? AND date < ? AND (date >= ? OR end_at > ? OR recurrence IS NOT NULL)`,
+		userId, to.Unix(), from.Unix(), from.Unix()).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	afterDate, afterId := int64(math.MinInt64), 0
+	if page.After != nil {
+		afterDate, afterId = page.After.Date.Unix(), page.After.ID
+	}
+
+	// a negative limit is no limit to sqlite
+	limit := page.Limit
+	if limit == 0 {
+		limit = -1
+	}
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM (
+			SELECT * FROM events
+			WHERE user_id = ? AND recurrence IS NULL AND date < ? AND (date >= ? OR end_at > ?)
+			AND (date > ? OR (date = ? AND id > ?))
+			ORDER BY date, id LIMIT ?
+		)
+		UNION ALL
+		SELECT `+eventColumns+` FROM events WHERE user_id = ? AND recurrence IS NOT NULL AND date < ?
+		ORDER BY date, id`,
+		userId, to.Unix(), from.Unix(), from.Unix(), afterDate, afterDate, afterId, limit, userId, to.Unix())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// Attending works like List without a page for the events the user is invited to, whatever the answer.
+func (sr *SQLEventRepository) Attending(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM events
+		WHERE EXISTS (SELECT 1 FROM json_each(events.attendees) WHERE json_extract(value, '$.UserID') = ?)
+		AND date < ? AND (date >= ? OR end_at > ? OR recurrence IS NOT NULL)
+		ORDER BY date, id`,
+		userId, to.Unix(), from.Unix(), from.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+// Invitations returns the page of the events the user is invited to with the answer status, any answer if
+// it is empty, and the total number of them. Recurring events are paged as series.
+func (sr *SQLEventRepository) Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) ([]*domains.Event, int, error) {
+	const invited = `EXISTS (SELECT 1 FROM json_each(events.attendees)
+		WHERE json_extract(value, '$.UserID') = ? AND (? = '' OR json_extract(value, '$.Status') = ?))`
+
+	var total int
+	err := sr.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM events WHERE `+invited,
+		userId, status, status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	afterDate, afterId := int64(math.MinInt64), 0
+	if page.After != nil {
+		afterDate, afterId = page.After.Date.Unix(), page.After.ID
+	}
+
+	// a negative limit is no limit to sqlite
+	limit := page.Limit
+	if limit == 0 {
+		limit = -1
+	}
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM events
+		WHERE `+invited+` AND (date > ? OR (date = ? AND id > ?))
+		ORDER BY date, id LIMIT ?`,
+		userId, status, status, afterDate, afterDate, afterId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// ListAll works like List without a page for the events of every user.
+func (sr *SQLEventRepository) ListAll(ctx context.Context, from, to time.Time) ([]*domains.Event, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM events
+		WHERE date < ? AND (date >= ? OR end_at > ? OR recurrence IS NOT NULL)
+		ORDER BY date, id`,
+		to.Unix(), from.Unix(), from.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+func scanEvents(rows *sql.Rows) ([]*domains.Event, error) {
+	defer rows.Close()
+
+	events := make([]*domains.Event, 0)
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (sr *SQLEventRepository) Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
+	if err := insertEvent(ctx, sr.db, newEvent); err != nil {
+		return nil, err
+	}
+
+	sr.index.Add(newEvent.ID, newEvent.UserID, newEvent.Title, newEvent.Description)
+
+	return newEvent, nil
+}
+
+// Update replaces the event, a non-zero event.Version has to match the stored one.
+func (sr *SQLEventRepository) Update(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := updateEvent(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sr.index.Add(event.ID, event.UserID, event.Title, event.Description)
+
+	return event, nil
+}
+
+// Delete moves the event to the trash, a non-zero version has to match the stored one.
+func (sr *SQLEventRepository) Delete(ctx context.Context, id int, version int) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteEvent(ctx, tx, id, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	sr.index.Remove(id)
+
+	return nil
+}
+
+// Apply runs the operations in a single transaction, if one fails none is applied and a *domains.BatchError is returned.
+// The result holds the stored event of every create and update, nil for deletions.
+func (sr *SQLEventRepository) Apply(ctx context.Context, operations []domains.Operation) ([]*domains.Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := make([]*domains.Event, len(operations))
+
+	for i, operation := range operations {
+		switch operation.Kind {
+		case domains.OperationCreate:
+			err = insertEvent(ctx, tx, operation.Event)
+			result[i] = operation.Event
+		case domains.OperationUpdate:
+			err = updateEvent(ctx, tx, operation.Event)
+			result[i] = operation.Event
+		case domains.OperationDelete:
+			err = deleteEvent(ctx, tx, operation.ID, operation.Version)
+		default:
+			err = fmt.Errorf("unknown operation %q", operation.Kind)
+		}
+
+		if err != nil {
+			return nil, &domains.BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, operation := range operations {
+		if operation.Kind == domains.OperationDelete {
+			sr.index.Remove(operation.ID)
+			continue
+		}
+
+		sr.index.Add(operation.Event.ID, operation.Event.UserID, operation.Event.Title, operation.Event.Description)
+	}
+
+	return result, nil
+}
+
+// Search returns the events of the user whose title or description match the query,
+// ordered by date and id. Recurring events are not expanded.
+func (sr *SQLEventRepository) Search(ctx context.Context, userId int, query string) ([]*domains.Event, error) {
+	ids := sr.index.Search(userId, query)
+	if len(ids) == 0 {
+		return make([]*domains.Event, 0), nil
+	}
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, userId)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM events
+		WHERE user_id = ? AND id IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)
+		ORDER BY date, id`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+// History returns the replaced and deleted versions of the event, oldest first.
+func (sr *SQLEventRepository) History(ctx context.Context, id int) ([]*domains.Event, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM event_history WHERE id = ? ORDER BY version`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEvents(rows)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertEvent(ctx context.Context, db execer, event *domains.Event) error {
+	recurrence, remindBefore, attendees, err := encodeLists(event)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO events (user_id, calendar_id, title, description, date, end_at, time_zone, recurrence, remind_before, attendees, uid, resource)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.UserID, event.CalendarID, event.Title, event.Description, event.Date.Unix(), encodeEnd(event.End),
+		event.TimeZone, recurrence, remindBefore, attendees, event.UID, event.Resource)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = int(id)
+	event.Version = 1
+
+	return nil
+}
+
+func updateEvent(ctx context.Context, tx *sql.Tx, event *domains.Event) error {
+	recurrence, remindBefore, attendees, err := encodeLists(event)
+	if err != nil {
+		return err
+	}
+
+	if err := archive(ctx, tx, event.ID, event.Version); err != nil {
+		return err
+	}
+
+	return tx.QueryRowContext(ctx,
+		`UPDATE events SET user_id = ?, calendar_id = ?, title = ?, description = ?, date = ?, end_at = ?, time_zone = ?,
+		recurrence = ?, remind_before = ?, attendees = ?, uid = ?, resource = ?, version = version + 1 WHERE id = ? RETURNING version`,
+		event.UserID, event.CalendarID, event.Title, event.Description, event.Date.Unix(), encodeEnd(event.End),
+		event.TimeZone, recurrence, remindBefore, attendees, event.UID, event.Resource, event.ID).Scan(&event.Version)
+}
+
+func deleteEvent(ctx context.Context, tx *sql.Tx, id int, version int) error {
+	if err := archive(ctx, tx, id, version); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO trash (`+eventColumns+`, deleted_at)
+		SELECT `+eventColumns+`, ? FROM events WHERE id = ?`,
+		time.Now().Unix(), id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM events WHERE id = ?`, id)
+
+	return err
+}
+
+// archive copies the stored version of the event to its history.
+func archive(ctx context.Context, tx *sql.Tx, id int, version int) error {
+	var stored int
+	err := tx.QueryRowContext(ctx, `SELECT version FROM events WHERE id = ?`, id).Scan(&stored)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domains.ErrEventNotFound
+		}
+
+		return err
+	}
+
+	if version != 0 && version != stored {
+		return domains.ErrVersionMismatch
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO event_history (`+eventColumns+`, archived_at)
+		SELECT `+eventColumns+`, ? FROM events WHERE id = ?`,
+		time.Now().Unix(), id)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(s scanner) (*domains.Event, error) {
+	var (
+		event        domains.Event
+		date         int64
+		end          sql.NullInt64
+		recurrence   sql.NullString
+		remindBefore sql.NullString
+		attendees    sql.NullString
+	)
+
+	err := s.Scan(&event.ID, &event.UserID, &event.CalendarID, &event.Title, &event.Description, &date, &end, &event.TimeZone,
+		&recurrence, &remindBefore, &attendees, &event.Version, &event.UID, &event.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(event.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("load time zone of event %d: %w", event.ID, err)
+	}
+
+	event.Date = time.Unix(date, 0).In(location)
+	if end.Valid {
+		event.End = time.Unix(end.Int64, 0).In(location)
+	}
+
+	if recurrence.Valid {
+		event.Recurrence = &domains.Recurrence{}
+		if err := json.Unmarshal([]byte(recurrence.String), event.Recurrence); err != nil {
+			return nil, fmt.Errorf("decode recurrence of event %d: %w", event.ID, err)
+		}
+	}
+
+	if remindBefore.Valid {
+		if err := json.Unmarshal([]byte(remindBefore.String), &event.RemindBefore); err != nil {
+			return nil, fmt.Errorf("decode reminders of event %d: %w", event.ID, err)
+		}
+	}
+
+	if attendees.Valid {
+		if err := json.Unmarshal([]byte(attendees.String), &event.Attendees); err != nil {
+			return nil, fmt.Errorf("decode attendees of event %d: %w", event.ID, err)
+		}
+	}
+
+	return &event, nil
+}
+
+// encodeLists returns the JSON encoded recurrence, reminders and attendees of the event.
+func encodeLists(event *domains.Event) (recurrence, remindBefore, attendees sql.NullString, err error) {
+	if event.Recurrence != nil {
+		if recurrence, err = encodeJSON(event.Recurrence); err != nil {
+			return recurrence, remindBefore, attendees, fmt.Errorf("encode recurrence: %w", err)
+		}
+	}
+
+	if len(event.RemindBefore) > 0 {
+		if remindBefore, err = encodeJSON(event.RemindBefore); err != nil {
+			return recurrence, remindBefore, attendees, fmt.Errorf("encode reminders: %w", err)
+		}
+	}
+
+	if len(event.Attendees) > 0 {
+		if attendees, err = encodeJSON(event.Attendees); err != nil {
+			return recurrence, remindBefore, attendees, fmt.Errorf("encode attendees: %w", err)
+		}
+	}
+
+	return recurrence, remindBefore, attendees, nil
+}
+
+func encodeJSON(v any) (sql.NullString, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func encodeEnd(end time.Time) sql.NullInt64 {
+	if end.IsZero() {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: end.Unix(), Valid: true}
+}
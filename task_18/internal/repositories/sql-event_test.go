@@ -0,0 +1,349 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLRepo(t *testing.T) *SQLEventRepository {
+	t.Helper()
+
+	repo, err := NewSQLEventRepository(context.Background(), ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	return repo
+}
+
+func TestSQLCreate(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	event, err := repo.Create(ctx, &domains.Event{
+		UserID: 1,
+		Title:  "test",
+		Date:   date(2026, 3, 11),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, event.ID)
+}
+
+func TestSQLEvent(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "test", Description: "desc", Date: date(2026, 3, 11), UID: "abc", Resource: "abc.ics"})
+
+	event, err := repo.Event(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "test", event.Title)
+	assert.Equal(t, "desc", event.Description)
+	assert.Equal(t, "abc", event.UID)
+	assert.Equal(t, "abc.ics", event.Resource)
+	assert.True(t, event.Date.Equal(date(2026, 3, 11)))
+}
+
+func TestSQLEvent_NotFound(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	_, err := repo.Event(ctx, 999)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestSQLList(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "mar 12", Date: date(2026, 3, 12)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "mar 11", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "mar 13", Date: date(2026, 3, 13)})
+	repo.Create(ctx, &domains.Event{UserID: 2, Title: "other user", Date: date(2026, 3, 11)})
+
+	events, _, err := repo.List(ctx, 1, date(2026, 3, 11), date(2026, 3, 13), domains.Page{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "mar 11", events[0].Title)
+	assert.Equal(t, "mar 12", events[1].Title)
+}
+
+func TestSQLListPage(t *testing.T) {
+	testListPage(t, newSQLRepo(t))
+}
+
+func TestSQLInvitations(t *testing.T) {
+	testInvitations(t, newSQLRepo(t))
+}
+
+func TestSQLUpdate(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "original", Date: date(2026, 3, 11)})
+
+	updated, err := repo.Update(ctx, &domains.Event{
+		ID:     1,
+		UserID: 1,
+		Title:  "modified",
+		Date:   date(2026, 3, 11),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "modified", updated.Title)
+
+	event, _ := repo.Event(ctx, 1)
+	assert.Equal(t, "modified", event.Title)
+}
+
+func TestSQLUpdate_NotFound(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	_, err := repo.Update(ctx, &domains.Event{ID: 999, Title: "nope"})
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestSQLUpdate_Versions(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "original", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	updated, err := repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "modified", Date: date(2026, 3, 11), Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "stale", Date: date(2026, 3, 11), Version: 1})
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	err = repo.Delete(ctx, 1, 1)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	updated, err = repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "unchecked", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Version)
+
+	history, err := repo.History(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "original", history[0].Title)
+	assert.Equal(t, 1, history[0].Version)
+	assert.Equal(t, "modified", history[1].Title)
+	assert.Equal(t, 2, history[1].Version)
+
+	require.NoError(t, repo.Delete(ctx, 1, 3))
+
+	history, err = repo.History(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+}
+
+func TestSQLDelete(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "to delete", Date: date(2026, 3, 11)})
+
+	err := repo.Delete(ctx, 1, 0)
+	require.NoError(t, err)
+
+	_, err = repo.Event(ctx, 1)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestSQLDelete_NotFound(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	err := repo.Delete(ctx, 999, 0)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestSQLPersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	repo, err := NewSQLEventRepository(ctx, path)
+	require.NoError(t, err)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "survivor", Date: date(2026, 3, 11)})
+	require.NoError(t, repo.Close())
+
+	repo, err = NewSQLEventRepository(ctx, path)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	event, err := repo.Event(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "survivor", event.Title)
+}
+
+func TestSQLList_IncludesRecurringSeries(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{
+		UserID: 1,
+		Title:  "weekly",
+		Date:   date(2026, 1, 5),
+		Recurrence: &domains.Recurrence{
+			Frequency:  domains.FrequencyWeekly,
+			ByDay:      []time.Weekday{time.Monday},
+			Exceptions: []time.Time{date(2026, 3, 9)},
+		},
+	})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "old", Date: date(2026, 1, 5)})
+
+	events, _, err := repo.List(ctx, 1, date(2026, 3, 1), date(2026, 4, 1), domains.Page{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.NotNil(t, events[0].Recurrence)
+	assert.Equal(t, domains.FrequencyWeekly, events[0].Recurrence.Frequency)
+	assert.Equal(t, []time.Weekday{time.Monday}, events[0].Recurrence.ByDay)
+	assert.True(t, events[0].Recurrence.Exceptions[0].Equal(date(2026, 3, 9)))
+}
+
+func TestSQLEvent_TimeZoneAndEnd(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	repo.Create(ctx, &domains.Event{
+		UserID:   1,
+		Title:    "meeting",
+		Date:     time.Date(2026, 3, 11, 23, 0, 0, 0, moscow),
+		End:      time.Date(2026, 3, 12, 1, 0, 0, 0, moscow),
+		TimeZone: "Europe/Moscow",
+	})
+
+	event, err := repo.Event(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", event.Date.Location().String())
+	assert.Equal(t, 23, event.Date.Hour())
+	assert.Equal(t, 2*time.Hour, event.Duration())
+
+	// still going on after midnight in Moscow
+	events, _, err := repo.List(ctx, 1, time.Date(2026, 3, 12, 0, 0, 0, 0, moscow), time.Date(2026, 3, 13, 0, 0, 0, 0, moscow), domains.Page{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestSQLSearch(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "Встреча с командой", Date: date(2026, 3, 12)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "обед", Description: "после встречи", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 2, Title: "встреча", Date: date(2026, 3, 11)})
+
+	events, err := repo.Search(ctx, 1, "ВСТРЕЧ")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "обед", events[0].Title)
+	assert.Equal(t, "Встреча с командой", events[1].Title)
+
+	_, err = repo.Update(ctx, &domains.Event{ID: 2, UserID: 1, Title: "обед", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, 1, 0))
+
+	events, err = repo.Search(ctx, 1, "встреча")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSQLSearch_IndexRebuiltOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	ctx := context.Background()
+
+	repo, err := NewSQLEventRepository(ctx, path)
+	require.NoError(t, err)
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "Ёлка во дворе", Date: date(2026, 12, 31)})
+	require.NoError(t, repo.Close())
+
+	repo, err = NewSQLEventRepository(ctx, path)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	events, err := repo.Search(ctx, 1, "елка")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].ID)
+}
+
+func TestSQLApply_Atomic(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "kept", Date: date(2026, 3, 11)})
+
+	_, err := repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "added", Date: date(2026, 3, 13)}},
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: 1, UserID: 1, Title: "stale", Date: date(2026, 3, 11), Version: 5}},
+	})
+	var batchErr *domains.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	events, err := repo.ListAll(ctx, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	found, err := repo.Search(ctx, 1, "added")
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	events, err = repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: &domains.Event{UserID: 1, Title: "added", Date: date(2026, 3, 13)}},
+		{Kind: domains.OperationUpdate, Event: &domains.Event{ID: 1, UserID: 1, Title: "renamed", Date: date(2026, 3, 11), Version: 1}},
+		{Kind: domains.OperationDelete, ID: 1, Version: 2},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, 2, events[1].Version)
+
+	_, err = repo.Event(ctx, 1)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	found, err = repo.Search(ctx, 1, "added")
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestSQLAttending(t *testing.T) {
+	repo := newSQLRepo(t)
+	ctx := context.Background()
+
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "invited", Date: date(2026, 3, 11), Attendees: []domains.Attendee{
+		{UserID: 2, Status: domains.AttendeeAccepted},
+		{UserID: 3, Status: domains.AttendeePending},
+	}})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "not invited", Date: date(2026, 3, 11)})
+	repo.Create(ctx, &domains.Event{UserID: 1, Title: "id in the title 2", Date: date(2026, 3, 12)})
+
+	events, err := repo.Attending(ctx, 2, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "invited", events[0].Title)
+	assert.Equal(t, []domains.Attendee{{UserID: 2, Status: domains.AttendeeAccepted}, {UserID: 3, Status: domains.AttendeePending}}, events[0].Attendees)
+
+	_, err = repo.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "invited", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	events, err = repo.Attending(ctx, 2, date(2026, 3, 1), date(2026, 4, 1))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	history, err := repo.History(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Len(t, history[0].Attendees, 2)
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// Trash returns the deleted events of the user ordered by date and id.
+func (sr *SQLEventRepository) Trash(ctx context.Context, userId int) ([]*domains.Event, error) {
+	rows, err := sr.db.QueryContext(ctx,
+		`SELECT `+eventColumns+`, deleted_at FROM trash WHERE user_id = ? ORDER BY date, id`, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTrash(rows)
+}
+
+func (sr *SQLEventRepository) TrashedEvent(ctx context.Context, id int) (*domains.Event, error) {
+	row := sr.db.QueryRowContext(ctx,
+		`SELECT `+eventColumns+`, deleted_at FROM trash WHERE id = ?`, id)
+
+	event, err := scanTrashed(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domains.ErrEventNotFound
+		}
+
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// Restore moves the trashed event with the id of event back to the events, storing event in its place
+// so the caller may change it on the way. The restored event gets the next version.
+func (sr *SQLEventRepository) Restore(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	recurrence, remindBefore, attendees, err := encodeLists(event)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `DELETE FROM trash WHERE id = ? RETURNING version`, event.ID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domains.ErrEventNotFound
+		}
+
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO events (`+eventColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID, event.UserID, event.CalendarID, event.Title, event.Description, event.Date.Unix(), encodeEnd(event.End),
+		event.TimeZone, recurrence, remindBefore, attendees, version+1, event.UID, event.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	event.DeletedAt = time.Time{}
+	event.Version = version + 1
+
+	sr.index.Add(event.ID, event.UserID, event.Title, event.Description)
+
+	return event, nil
+}
+
+// Purge permanently removes the events deleted before the given time together with their history
+// and returns them.
+func (sr *SQLEventRepository) Purge(ctx context.Context, before time.Time) ([]*domains.Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+eventColumns+`, deleted_at FROM trash WHERE deleted_at < ? ORDER BY date, id`, before.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	purged, err := scanTrash(rows)
+	if err != nil || len(purged) == 0 {
+		return purged, err
+	}
+
+	args := make([]any, 0, len(purged))
+	for _, event := range purged {
+		args = append(args, event.ID)
+	}
+	ids := `(?` + strings.Repeat(`, ?`, len(purged)-1) + `)`
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM trash WHERE id IN `+ids, args...); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_history WHERE id IN `+ids, args...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+func scanTrash(rows *sql.Rows) ([]*domains.Event, error) {
+	defer rows.Close()
+
+	events := make([]*domains.Event, 0)
+
+	for rows.Next() {
+		event, err := scanTrashed(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// scanTrashed scans the event columns followed by deleted_at.
+func scanTrashed(s scanner) (*domains.Event, error) {
+	var deletedAt int64
+
+	event, err := scanEvent(trashScanner{scanner: s, deletedAt: &deletedAt})
+	if err != nil {
+		return nil, err
+	}
+
+	event.DeletedAt = time.Unix(deletedAt, 0).UTC()
+
+	return event, nil
+}
+
+// trashScanner passes the destinations of scanEvent on with the one of deleted_at appended.
+type trashScanner struct {
+	scanner
+	deletedAt *int64
+}
+
+func (ts trashScanner) Scan(dest ...any) error {
+	return ts.scanner.Scan(append(dest, ts.deletedAt)...)
+}
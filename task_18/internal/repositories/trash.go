@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// Trash returns the deleted events of the user ordered by date and id.
+func (er *EventRepository) Trash(ctx context.Context, userId int) ([]*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return er.sortedTrash(func(event *domains.Event) bool {
+			return event.UserID == userId
+		}), nil
+	}
+}
+
+func (er *EventRepository) TrashedEvent(ctx context.Context, id int) (*domains.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		event, ok := er.trash[id]
+		if !ok {
+			return nil, domains.ErrEventNotFound
+		}
+
+		return event, nil
+	}
+}
+
+// Restore moves the trashed event with the id of event back to the events, storing event in its place
+// so the caller may change it on the way. The restored event gets the next version.
+func (er *EventRepository) Restore(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		trashed, ok := er.trash[event.ID]
+		if !ok {
+			return nil, domains.ErrEventNotFound
+		}
+
+		event.DeletedAt = time.Time{}
+		event.Version = trashed.Version + 1
+
+		if err := er.record(walRecord{Op: walRestore, Event: event}); err != nil {
+			return nil, err
+		}
+
+		return event, nil
+	}
+}
+
+// Purge permanently removes the events deleted before the given time together with their history
+// and returns them.
+func (er *EventRepository) Purge(ctx context.Context, before time.Time) ([]*domains.Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		purged := er.sortedTrash(func(event *domains.Event) bool {
+			return event.DeletedAt.Before(before)
+		})
+		if len(purged) == 0 {
+			return purged, nil
+		}
+
+		records := make([]walRecord, 0, len(purged))
+		for _, event := range purged {
+			records = append(records, walRecord{Op: walPurge, ID: event.ID})
+		}
+
+		if err := er.record(walRecord{Op: walBatch, Records: records}); err != nil {
+			return nil, err
+		}
+
+		return purged, nil
+	}
+}
+
+// sortedTrash returns the trashed events matching keep ordered by date and id,
+// it has to be called with the lock held.
+func (er *EventRepository) sortedTrash(keep func(*domains.Event) bool) []*domains.Event {
+	events := make([]*domains.Event, 0)
+
+	for _, event := range er.trash {
+		if keep(event) {
+			events = append(events, event)
+		}
+	}
+
+	domains.SortEvents(events)
+
+	return events
+}
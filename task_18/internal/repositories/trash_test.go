@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trashRepository is implemented by both repositories, so they share the trash tests.
+type trashRepository interface {
+	Event(ctx context.Context, id int) (*domains.Event, error)
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	Update(ctx context.Context, event *domains.Event) (*domains.Event, error)
+	Delete(ctx context.Context, id int, version int) error
+	History(ctx context.Context, id int) ([]*domains.Event, error)
+	Search(ctx context.Context, userId int, query string) ([]*domains.Event, error)
+	Trash(ctx context.Context, userId int) ([]*domains.Event, error)
+	TrashedEvent(ctx context.Context, id int) (*domains.Event, error)
+	Restore(ctx context.Context, event *domains.Event) (*domains.Event, error)
+	Purge(ctx context.Context, before time.Time) ([]*domains.Event, error)
+}
+
+func testTrash(t *testing.T, repo trashRepository) {
+	ctx := context.Background()
+
+	planning, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "planning", Date: date(2026, 3, 12), TimeZone: "UTC", UID: "abc", Resource: "abc.ics"})
+	require.NoError(t, err)
+	review, err := repo.Create(ctx, &domains.Event{UserID: 1, Title: "review", Date: date(2026, 3, 11), TimeZone: "UTC"})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, &domains.Event{UserID: 2, Title: "other", Date: date(2026, 3, 11), TimeZone: "UTC"})
+	require.NoError(t, err)
+
+	planning.Title = "planning v2"
+	_, err = repo.Update(ctx, planning)
+	require.NoError(t, err)
+
+	deletedAt := time.Now()
+	for _, event := range []*domains.Event{planning, review, other} {
+		require.NoError(t, repo.Delete(ctx, event.ID, 0))
+	}
+
+	_, err = repo.Event(ctx, planning.ID)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	trash, err := repo.Trash(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, trash, 2)
+	assert.Equal(t, review.ID, trash[0].ID)
+	assert.Equal(t, planning.ID, trash[1].ID)
+	assert.Equal(t, "planning v2", trash[1].Title)
+	assert.Equal(t, 2, trash[1].Version)
+	assert.WithinDuration(t, deletedAt, trash[1].DeletedAt, 2*time.Second)
+
+	trashed, err := repo.TrashedEvent(ctx, planning.ID)
+	require.NoError(t, err)
+
+	restored, err := repo.Restore(ctx, trashed)
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Version)
+	assert.True(t, restored.DeletedAt.IsZero())
+
+	stored, err := repo.Event(ctx, planning.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "planning v2", stored.Title)
+	assert.Equal(t, 3, stored.Version)
+	assert.Equal(t, "abc.ics", stored.Resource)
+
+	found, err := repo.Search(ctx, 1, "planning")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	_, err = repo.TrashedEvent(ctx, planning.ID)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+	_, err = repo.Restore(ctx, &domains.Event{ID: planning.ID, UserID: 1, TimeZone: "UTC"})
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	// nothing has been deleted before the cutoff yet
+	purged, err := repo.Purge(ctx, deletedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, purged, 2)
+	assert.Equal(t, review.ID, purged[0].ID)
+	assert.Equal(t, other.ID, purged[1].ID)
+
+	trash, err = repo.Trash(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	history, err := repo.History(ctx, review.ID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	// the restored event keeps its history
+	history, err = repo.History(ctx, planning.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestTrash(t *testing.T) {
+	testTrash(t, NewEventRepository())
+}
+
+func TestSQLTrash(t *testing.T) {
+	testTrash(t, newSQLRepo(t))
+}
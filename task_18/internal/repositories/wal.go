@@ -0,0 +1,332 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// SyncPolicy is when the write-ahead log is flushed to disk.
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs every record before the change is acknowledged
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsyncs in the background, a crash loses at most the changes of the last interval
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system
+	SyncNever SyncPolicy = "never"
+)
+
+var ErrCorruptLog = errors.New("write-ahead log is corrupt")
+
+type walOp string
+
+const (
+	walCreate walOp = "create"
+	walUpdate walOp = "update"
+	walDelete walOp = "delete"
+	// walBatch holds the records of an atomic batch, so a crash can not leave a part of it applied
+	walBatch walOp = "batch"
+
+	walCreateCalendar walOp = "create_calendar"
+	walUpdateCalendar walOp = "update_calendar"
+	walDeleteCalendar walOp = "delete_calendar"
+
+	walAudit walOp = "audit"
+
+	walRestore walOp = "restore"
+	walPurge   walOp = "purge"
+)
+
+// walRecord is a change of the repository. Event and Calendar hold the event or calendar as stored after
+// the change, Audit an appended audit entry, ID is only set for deletions and purges, Time is when
+// an event was deleted and Records holds the records of a batch.
+type walRecord struct {
+	Seq      uint64              `json:"seq"`
+	Op       walOp               `json:"op"`
+	Event    *domains.Event      `json:"event,omitempty"`
+	Calendar *domains.Calendar   `json:"calendar,omitempty"`
+	Audit    *domains.AuditEntry `json:"audit,omitempty"`
+	ID       int                 `json:"id,omitempty"`
+	Time     time.Time           `json:"time,omitzero"`
+	Records  []walRecord         `json:"records,omitempty"`
+}
+
+// A record is framed as the length and the CRC-32C of its JSON payload, both little endian uint32,
+// followed by the payload. A crash in the middle of an append leaves a torn record at the end of the
+// file, which is cut off on recovery. A damaged record followed by further data is not the result of
+// a crash, so recovery refuses to guess and fails with ErrCorruptLog.
+const walHeaderSize = 8
+
+// maxRecordSize guards against allocating a bogus length read from a damaged header
+const maxRecordSize = 16 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type wal struct {
+	mu     sync.Mutex
+	file   *os.File
+	policy SyncPolicy
+	// seq is the sequence number of the last appended record
+	seq uint64
+	// dirty is set when records were written since the last sync
+	dirty bool
+}
+
+// openWAL opens or creates the log at path and returns the records it holds. A torn final record is truncated.
+func openWAL(path string, policy SyncPolicy) (*wal, []walRecord, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open write-ahead log: %w", err)
+	}
+
+	records, end, err := readRecords(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	if err = file.Truncate(end); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("truncate torn record: %w", err)
+	}
+
+	if _, err = file.Seek(end, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("seek write-ahead log: %w", err)
+	}
+
+	log := &wal{file: file, policy: policy}
+	if len(records) > 0 {
+		log.seq = records[len(records)-1].Seq
+	}
+
+	return log, records, nil
+}
+
+// readRecords decodes the records of the file and returns the offset after the last complete one.
+func readRecords(file *os.File) ([]walRecord, int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("stat write-ahead log: %w", err)
+	}
+	size := info.Size()
+
+	reader := bufio.NewReader(file)
+	records := make([]walRecord, 0)
+	header := make([]byte, walHeaderSize)
+	var offset int64
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				// nothing or a partial header after the last record
+				return records, offset, nil
+			}
+
+			return nil, 0, fmt.Errorf("read write-ahead log: %w", err)
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+		end := offset + walHeaderSize + int64(length)
+
+		if end > size {
+			// a length damaged into a larger one also runs past the end, but then complete records follow it
+			rest, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, 0, fmt.Errorf("read write-ahead log: %w", err)
+			}
+
+			if containsRecord(rest) {
+				return nil, 0, fmt.Errorf("%w: record at offset %d has a damaged length", ErrCorruptLog, offset)
+			}
+
+			return records, offset, nil
+		}
+
+		if length > maxRecordSize {
+			return nil, 0, fmt.Errorf("%w: record at offset %d is %d bytes long", ErrCorruptLog, offset, length)
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return nil, 0, fmt.Errorf("read write-ahead log: %w", err)
+		}
+
+		var record walRecord
+		if crc32.Checksum(payload, crcTable) != checksum || json.Unmarshal(payload, &record) != nil {
+			if end == size {
+				return records, offset, nil
+			}
+
+			return nil, 0, fmt.Errorf("%w: damaged record at offset %d", ErrCorruptLog, offset)
+		}
+
+		if err := restoreRecordLocations(record); err != nil {
+			return nil, 0, err
+		}
+
+		records = append(records, record)
+		offset = end
+	}
+}
+
+// containsRecord reports whether a complete record starts somewhere in data. Every payload is a JSON object
+// starting with its sequence number, so only the positions before such a prefix are checked.
+func containsRecord(data []byte) bool {
+	prefix := []byte(`{"seq":`)
+
+	for start := 0; ; {
+		index := bytes.Index(data[start:], prefix)
+		if index < 0 {
+			return false
+		}
+		payload := start + index
+		start = payload + 1
+
+		if payload < walHeaderSize {
+			continue
+		}
+
+		header := data[payload-walHeaderSize : payload]
+		length := int(binary.LittleEndian.Uint32(header[0:4]))
+		if length > len(data)-payload {
+			continue
+		}
+
+		if crc32.Checksum(data[payload:payload+length], crcTable) == binary.LittleEndian.Uint32(header[4:8]) {
+			return true
+		}
+	}
+}
+
+// append writes the record with the next sequence number, it is durable on return with SyncAlways.
+func (w *wal) append(record walRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	record.Seq = w.seq + 1
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode write-ahead log record: %w", err)
+	}
+
+	frame := make([]byte, walHeaderSize, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crcTable))
+	frame = append(frame, payload...)
+
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("seek write-ahead log: %w", err)
+	}
+
+	if _, err = w.file.Write(frame); err != nil {
+		// a partial frame would hide the records appended after it
+		if truncateErr := w.file.Truncate(offset); truncateErr == nil {
+			w.file.Seek(offset, io.SeekStart)
+		}
+
+		return fmt.Errorf("write write-ahead log: %w", err)
+	}
+
+	if w.policy == SyncAlways {
+		if err = w.file.Sync(); err != nil {
+			return fmt.Errorf("sync write-ahead log: %w", err)
+		}
+	} else {
+		w.dirty = true
+	}
+
+	w.seq = record.Seq
+
+	return nil
+}
+
+func (w *wal) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.dirty {
+		return nil
+	}
+
+	w.dirty = false
+
+	return w.file.Sync()
+}
+
+// reset empties the log once a snapshot holds its records, sequence numbers keep growing.
+func (w *wal) reset() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate write-ahead log: %w", err)
+	}
+
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek write-ahead log: %w", err)
+	}
+
+	w.dirty = false
+
+	return w.file.Sync()
+}
+
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("sync write-ahead log: %w", err)
+	}
+
+	return w.file.Close()
+}
+
+func restoreRecordLocations(record walRecord) error {
+	if record.Event != nil {
+		if err := restoreLocations(record.Event); err != nil {
+			return err
+		}
+	}
+
+	for _, nested := range record.Records {
+		if err := restoreRecordLocations(nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreLocations puts the times of a decoded event back into the location of its time zone,
+// JSON only keeps their offsets.
+func restoreLocations(event *domains.Event) error {
+	location, err := time.LoadLocation(event.TimeZone)
+	if err != nil {
+		return fmt.Errorf("load time zone of event %d: %w", event.ID, err)
+	}
+
+	event.Date = event.Date.In(location)
+	if !event.End.IsZero() {
+		event.End = event.End.In(location)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package rpc
+
+import (
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"github.com/M-kos/wb_level2/task_18/internal/pb/calendarv1"
+)
+
+// The messages mirror the JSON DTOs, so requests are converted to them and share their validation.
+
+func createEventFromInput(input *calendarv1.EventInput) *dto.CreateEvent {
+	createEvent := &dto.CreateEvent{
+		UserId:      int(input.GetUserId()),
+		Title:       input.GetTitle(),
+		Description: input.GetDescription(),
+		Recurrence:  recurrenceToDto(input.GetRecurrence()),
+	}
+	createEvent.Date = input.GetDate()
+	createEvent.Start = input.GetStart()
+	createEvent.End = input.GetEnd()
+	createEvent.TimeZone = input.GetTimeZone()
+	createEvent.RemindBefore = input.GetRemindBefore()
+	createEvent.Attendees = attendeeIds(input.GetAttendees())
+	createEvent.CalendarId = int(input.GetCalendarId())
+
+	return createEvent
+}
+
+func updateEventFromInput(input *calendarv1.EventInput) *dto.UpdateEvent {
+	updateEvent := &dto.UpdateEvent{
+		UserId:      int(input.GetUserId()),
+		Title:       input.GetTitle(),
+		Description: input.GetDescription(),
+		Recurrence:  recurrenceToDto(input.GetRecurrence()),
+	}
+	updateEvent.Date = input.GetDate()
+	updateEvent.Start = input.GetStart()
+	updateEvent.End = input.GetEnd()
+	updateEvent.TimeZone = input.GetTimeZone()
+	updateEvent.RemindBefore = input.GetRemindBefore()
+	updateEvent.Attendees = attendeeIds(input.GetAttendees())
+	updateEvent.CalendarId = int(input.GetCalendarId())
+
+	return updateEvent
+}
+
+func attendeeIds(ids []int64) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, int(id))
+	}
+
+	return result
+}
+
+func attendeesFromDto(attendees []dto.AttendeeDto) []*calendarv1.Attendee {
+	result := make([]*calendarv1.Attendee, 0, len(attendees))
+	for _, attendee := range attendees {
+		result = append(result, &calendarv1.Attendee{UserId: int64(attendee.UserID), Status: attendee.Status})
+	}
+
+	return result
+}
+
+func recurrenceToDto(recurrence *calendarv1.Recurrence) *dto.Recurrence {
+	if recurrence == nil {
+		return nil
+	}
+
+	return &dto.Recurrence{
+		Frequency:  recurrence.GetFrequency(),
+		Interval:   int(recurrence.GetInterval()),
+		ByDay:      recurrence.GetByDay(),
+		Count:      int(recurrence.GetCount()),
+		Until:      recurrence.GetUntil(),
+		Exceptions: recurrence.GetExceptions(),
+	}
+}
+
+func eventFromDomain(event *domains.Event) *calendarv1.Event {
+	eventDto := dto.EventDtoFromDomain(event)
+
+	return &calendarv1.Event{
+		Id:           int64(eventDto.ID),
+		UserId:       int64(eventDto.UserID),
+		Title:        eventDto.Title,
+		Description:  eventDto.Description,
+		Date:         eventDto.Date,
+		Start:        eventDto.Start,
+		End:          eventDto.End,
+		TimeZone:     eventDto.TimeZone,
+		RemindBefore: eventDto.RemindBefore,
+		Recurrence:   recurrenceFromDto(eventDto.Recurrence),
+		Version:      int64(eventDto.Version),
+		Attendees:    attendeesFromDto(eventDto.Attendees),
+		CalendarId:   int64(eventDto.CalendarID),
+	}
+}
+
+func recurrenceFromDto(recurrence *dto.Recurrence) *calendarv1.Recurrence {
+	if recurrence == nil {
+		return nil
+	}
+
+	return &calendarv1.Recurrence{
+		Frequency:  recurrence.Frequency,
+		Interval:   int32(recurrence.Interval),
+		ByDay:      recurrence.ByDay,
+		Count:      int32(recurrence.Count),
+		Until:      recurrence.Until,
+		Exceptions: recurrence.Exceptions,
+	}
+}
+
+func eventsResponse(events []*domains.Event) *calendarv1.EventsResponse {
+	response := &calendarv1.EventsResponse{
+		Events: make([]*calendarv1.Event, 0, len(events)),
+	}
+
+	for _, event := range events {
+		response.Events = append(response.Events, eventFromDomain(event))
+	}
+
+	return response
+}
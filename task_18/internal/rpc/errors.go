@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/dto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps the errors of the service to gRPC status codes the way the resource API maps them to HTTP ones.
+func toStatus(err error) error {
+	var validationErrors dto.ValidationErrors
+
+	switch {
+	case errors.As(err, &validationErrors):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domains.ErrEventNotFound), errors.Is(err, domains.ErrOccurrenceNotFound), errors.Is(err, domains.ErrNotInvited),
+		errors.Is(err, domains.ErrCalendarNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domains.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domains.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domains.ErrInvalidTimeRange):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domains.ErrVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, domains.ErrEventNotRecurring), errors.Is(err, domains.ErrEventConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "something went wrong")
+	}
+}
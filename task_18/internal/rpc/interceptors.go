@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDKey = "x-request-id"
+
+// maxRequestIDLength keeps client supplied ids out of the logs if they are unreasonably long
+const maxRequestIDLength = 128
+
+// callContext prepares the context of a call like the HTTP middlewares prepare a request: it takes over
+// or generates the request id, then authenticates the "authorization: Bearer <jwt>" metadata.
+func callContext(ctx context.Context, secret []byte) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestId := first(md.Get(requestIDKey))
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		requestId = tracing.NewRequestID()
+	}
+	ctx = tracing.WithRequestID(ctx, requestId)
+
+	token, ok := strings.CutPrefix(first(md.Get("authorization")), "Bearer ")
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	userId, err := auth.ParseToken(secret, token)
+	if err != nil {
+		slog.ErrorContext(ctx, "[RPC Auth] error parsing token", "error", err)
+		return ctx, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return auth.WithUser(ctx, userId), nil
+}
+
+func unaryInterceptor(secret []byte) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, err = callContext(ctx, secret)
+		defer logCall(ctx, info.FullMethod, time.Now(), &err)
+		defer recoverCall(ctx, info.FullMethod, &err)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamInterceptor(secret []byte) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, err := callContext(stream.Context(), secret)
+		defer logCall(ctx, info.FullMethod, time.Now(), &err)
+		defer recoverCall(ctx, info.FullMethod, &err)
+
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// recoverCall turns a panic of the handler into an Internal error instead of crashing the server.
+func recoverCall(ctx context.Context, method string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	slog.ErrorContext(ctx, "[RPC Recovery] handler panicked",
+		"method", method,
+		"error", recovered,
+		"stack", string(debug.Stack()))
+
+	*err = status.Error(codes.Internal, "internal server error")
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err *error) {
+	slog.InfoContext(ctx, "[RPC Logger]",
+		"method", method,
+		"code", status.Code(*err).String(),
+		"duration", time.Since(start).String())
+}
+
+// contextStream replaces the context of a server stream with the one of the call.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,233 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+	"github.com/M-kos/wb_level2/task_18/internal/pb/calendarv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type EventService interface {
+	EventsForDay(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
+	EventsForWeek(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
+	EventsForMonth(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)
+	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
+	Update(ctx context.Context, event *domains.Event) (*domains.Event, error)
+	Delete(ctx context.Context, eventId int, version int) error
+	UpdateOccurrence(ctx context.Context, eventId int, occurrence time.Time, event *domains.Event) (*domains.Event, error)
+	DeleteOccurrence(ctx context.Context, eventId int, occurrence time.Time, version int) error
+}
+
+type ChangeBus interface {
+	Subscribe(userId int, resume bool, lastID uint64) (*changes.Subscription, []changes.Change, bool)
+}
+
+// Server serves calendarv1.EventService on top of the same service as the HTTP handlers.
+type Server struct {
+	calendarv1.UnimplementedEventServiceServer
+
+	service EventService
+	bus     ChangeBus
+}
+
+// NewServer creates a gRPC server with the event service registered, calls are authenticated with JWTs signed with secret.
+func NewServer(service EventService, bus ChangeBus, secret []byte) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptor(secret)),
+		grpc.ChainStreamInterceptor(streamInterceptor(secret)),
+	)
+
+	calendarv1.RegisterEventServiceServer(server, &Server{
+		service: service,
+		bus:     bus,
+	})
+
+	return server
+}
+
+func (s *Server) EventsForDay(ctx context.Context, req *calendarv1.PeriodRequest) (*calendarv1.EventsResponse, error) {
+	return s.period(ctx, req, s.service.EventsForDay)
+}
+
+func (s *Server) EventsForWeek(ctx context.Context, req *calendarv1.PeriodRequest) (*calendarv1.EventsResponse, error) {
+	return s.period(ctx, req, s.service.EventsForWeek)
+}
+
+func (s *Server) EventsForMonth(ctx context.Context, req *calendarv1.PeriodRequest) (*calendarv1.EventsResponse, error) {
+	return s.period(ctx, req, s.service.EventsForMonth)
+}
+
+func (s *Server) CreateEvent(ctx context.Context, req *calendarv1.CreateEventRequest) (*calendarv1.Event, error) {
+	createEvent := createEventFromInput(req.GetEvent())
+	if err := createEvent.Validate(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	event, err := s.service.Create(ctx, createEvent.ToDomain())
+	if err != nil {
+		slog.ErrorContext(ctx, "[RPC Create] error creating event", "error", err)
+		return nil, toStatus(err)
+	}
+
+	return eventFromDomain(event), nil
+}
+
+// UpdateEvent replaces the event, with an occurrence it detaches that occurrence of a recurring event instead.
+func (s *Server) UpdateEvent(ctx context.Context, req *calendarv1.UpdateEventRequest) (*calendarv1.Event, error) {
+	updateEvent := updateEventFromInput(req.GetEvent())
+	if err := updateEvent.Validate(); err != nil {
+		return nil, toStatus(err)
+	}
+
+	occurrence, hasOccurrence, err := parseOccurrence(req.GetOccurrence())
+	if err != nil {
+		return nil, err
+	}
+
+	eventId := int(req.GetId())
+	event := updateEvent.ToDomain(eventId)
+	event.Version = int(req.GetVersion())
+
+	if hasOccurrence {
+		event, err = s.service.UpdateOccurrence(ctx, eventId, occurrence, event)
+	} else {
+		event, err = s.service.Update(ctx, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "[RPC Update] error updating event", "error", err)
+		return nil, toStatus(err)
+	}
+
+	return eventFromDomain(event), nil
+}
+
+func (s *Server) DeleteEvent(ctx context.Context, req *calendarv1.DeleteEventRequest) (*calendarv1.DeleteEventResponse, error) {
+	occurrence, hasOccurrence, err := parseOccurrence(req.GetOccurrence())
+	if err != nil {
+		return nil, err
+	}
+
+	if hasOccurrence {
+		err = s.service.DeleteOccurrence(ctx, int(req.GetId()), occurrence, int(req.GetVersion()))
+	} else {
+		err = s.service.Delete(ctx, int(req.GetId()), int(req.GetVersion()))
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "[RPC Delete] error deleting event", "error", err)
+		return nil, toStatus(err)
+	}
+
+	return &calendarv1.DeleteEventResponse{}, nil
+}
+
+// Watch sends the changes of the events of the user. A client passing the id of the last change it got
+// first receives the ones it missed, a reset change tells it they are no longer buffered. The call ends
+// with Unavailable when the client falls behind or the server shuts down, it resumes with last_change_id.
+func (s *Server) Watch(req *calendarv1.WatchRequest, stream grpc.ServerStreamingServer[calendarv1.Change]) error {
+	userId := int(req.GetUserId())
+	if authenticated, _ := auth.UserFromContext(stream.Context()); authenticated != userId {
+		return toStatus(domains.ErrForbidden)
+	}
+
+	subscription, replay, complete := s.bus.Subscribe(userId, req.LastChangeId != nil, req.GetLastChangeId())
+	defer subscription.Close()
+
+	if !complete {
+		if err := stream.Send(&calendarv1.Change{Kind: calendarv1.ChangeKind_CHANGE_KIND_RESET}); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range replay {
+		if err := stream.Send(changeFromBus(change)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case change, ok := <-subscription.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "watch closed, resume with last_change_id")
+			}
+
+			if err := stream.Send(changeFromBus(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) period(ctx context.Context, req *calendarv1.PeriodRequest, serviceFn func(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error)) (*calendarv1.EventsResponse, error) {
+	location := time.UTC
+	if req.GetTimeZone() != "" {
+		var err error
+		if location, err = time.LoadLocation(req.GetTimeZone()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid time_zone, expected an IANA time zone name")
+		}
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, req.GetDate(), location)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid date, expected format: YYYY-MM-DD")
+	}
+
+	calendarIds := make([]int, 0, len(req.GetCalendarIds()))
+	for _, calendarId := range req.GetCalendarIds() {
+		if calendarId < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid calendar id")
+		}
+
+		calendarIds = append(calendarIds, int(calendarId))
+	}
+
+	// the gRPC API returns every event of the period
+	events, err := serviceFn(ctx, int(req.GetUserId()), date, domains.Page{}, calendarIds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "[RPC Get Events] error getting events", "error", err)
+		return nil, toStatus(err)
+	}
+
+	return eventsResponse(events.Events), nil
+}
+
+func parseOccurrence(value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	occurrence, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false, status.Error(codes.InvalidArgument, "invalid occurrence, expected format: YYYY-MM-DD")
+	}
+
+	return occurrence, true, nil
+}
+
+func changeFromBus(change changes.Change) *calendarv1.Change {
+	kind := calendarv1.ChangeKind_CHANGE_KIND_UNSPECIFIED
+	switch change.Kind {
+	case changes.KindCreated:
+		kind = calendarv1.ChangeKind_CHANGE_KIND_CREATED
+	case changes.KindUpdated:
+		kind = calendarv1.ChangeKind_CHANGE_KIND_UPDATED
+	case changes.KindDeleted:
+		kind = calendarv1.ChangeKind_CHANGE_KIND_DELETED
+	}
+
+	return &calendarv1.Change{
+		Id:    change.ID,
+		Kind:  kind,
+		Event: eventFromDomain(change.Event),
+		At:    change.At.Format(time.RFC3339Nano),
+	}
+}
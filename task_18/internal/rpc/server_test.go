@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
+	"github.com/M-kos/wb_level2/task_18/internal/pb/calendarv1"
+	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testSecret = []byte("secret")
+
+func newTestClient(t *testing.T) (calendarv1.EventServiceClient, *changes.Bus) {
+	t.Helper()
+
+	bus := changes.NewBus(10)
+	service := services.NewEventService(repositories.NewEventRepository(), services.ConflictIgnore, bus)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(service, bus, testSecret)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return calendarv1.NewEventServiceClient(conn), bus
+}
+
+func asUser(t *testing.T, userId int) context.Context {
+	t.Helper()
+
+	token, err := auth.NewToken(testSecret, userId, time.Minute)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestServerEvents(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := asUser(t, 1)
+
+	created, err := client.CreateEvent(ctx, &calendarv1.CreateEventRequest{Event: &calendarv1.EventInput{
+		UserId: 1,
+		Title:  "standup",
+		Date:   "2026-03-11",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "standup", created.GetTitle())
+	assert.EqualValues(t, 1, created.GetVersion())
+
+	day, err := client.EventsForDay(ctx, &calendarv1.PeriodRequest{UserId: 1, Date: "2026-03-11"})
+	require.NoError(t, err)
+	require.Len(t, day.GetEvents(), 1)
+	assert.Equal(t, created.GetId(), day.GetEvents()[0].GetId())
+
+	_, err = client.UpdateEvent(ctx, &calendarv1.UpdateEventRequest{
+		Id:      created.GetId(),
+		Event:   &calendarv1.EventInput{UserId: 1, Title: "retro", Date: "2026-03-12"},
+		Version: 5,
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	updated, err := client.UpdateEvent(ctx, &calendarv1.UpdateEventRequest{
+		Id:      created.GetId(),
+		Event:   &calendarv1.EventInput{UserId: 1, Title: "retro", Date: "2026-03-12"},
+		Version: created.GetVersion(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "retro", updated.GetTitle())
+
+	_, err = client.DeleteEvent(ctx, &calendarv1.DeleteEventRequest{Id: created.GetId()})
+	require.NoError(t, err)
+
+	_, err = client.DeleteEvent(ctx, &calendarv1.DeleteEventRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerErrors(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.EventsForDay(context.Background(), &calendarv1.PeriodRequest{UserId: 1, Date: "2026-03-11"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := asUser(t, 1)
+
+	_, err = client.EventsForDay(ctx, &calendarv1.PeriodRequest{UserId: 1, Date: "11.03.2026"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.EventsForDay(ctx, &calendarv1.PeriodRequest{UserId: 2, Date: "2026-03-11"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateEvent(ctx, &calendarv1.CreateEventRequest{Event: &calendarv1.EventInput{UserId: 1, Title: "x"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerWatch(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := asUser(t, 1)
+
+	_, err := client.CreateEvent(ctx, &calendarv1.CreateEventRequest{Event: &calendarv1.EventInput{
+		UserId: 1,
+		Title:  "missed",
+		Date:   "2026-03-11",
+	}})
+	require.NoError(t, err)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lastChangeId := uint64(0)
+	stream, err := client.Watch(watchCtx, &calendarv1.WatchRequest{UserId: 1, LastChangeId: &lastChangeId})
+	require.NoError(t, err)
+
+	change, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, calendarv1.ChangeKind_CHANGE_KIND_CREATED, change.GetKind())
+	assert.Equal(t, "missed", change.GetEvent().GetTitle())
+
+	_, err = client.CreateEvent(ctx, &calendarv1.CreateEventRequest{Event: &calendarv1.EventInput{
+		UserId: 1,
+		Title:  "live",
+		Date:   "2026-03-12",
+	}})
+	require.NoError(t, err)
+
+	change, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, calendarv1.ChangeKind_CHANGE_KIND_CREATED, change.GetKind())
+	assert.Equal(t, "live", change.GetEvent().GetTitle())
+
+	forbidden, err := client.Watch(ctx, &calendarv1.WatchRequest{UserId: 2})
+	require.NoError(t, err)
+	_, err = forbidden.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,183 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Index is an inverted index of the words of the events of every user. A query matches
+// the events which contain a word starting with each of its words, so "встреч" finds "Встреча".
+type Index struct {
+	mu    sync.RWMutex
+	users map[int]*userIndex
+	// docs holds the owner and the words of every indexed event
+	docs map[int]document
+}
+
+type userIndex struct {
+	// terms is sorted, so the words with a prefix are found with a binary search
+	terms    []string
+	postings map[string]map[int]struct{}
+}
+
+type document struct {
+	userId int
+	terms  []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		users: make(map[int]*userIndex),
+		docs:  make(map[int]document),
+	}
+}
+
+// Tokenize splits text into lower-cased words of letters and digits, ё is folded into е.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		tokens = append(tokens, strings.ReplaceAll(strings.ToLower(word), "ё", "е"))
+	}
+
+	return tokens
+}
+
+// Add indexes the texts of the event, replacing what was indexed for it before.
+func (idx *Index) Add(eventId, userId int, texts ...string) {
+	var terms []string
+	for _, text := range texts {
+		terms = append(terms, Tokenize(text)...)
+	}
+
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(eventId)
+
+	if len(terms) == 0 {
+		return
+	}
+
+	user, ok := idx.users[userId]
+	if !ok {
+		user = &userIndex{postings: make(map[string]map[int]struct{})}
+		idx.users[userId] = user
+	}
+
+	for _, term := range terms {
+		posting, ok := user.postings[term]
+		if !ok {
+			posting = make(map[int]struct{})
+			user.postings[term] = posting
+
+			i, _ := slices.BinarySearch(user.terms, term)
+			user.terms = slices.Insert(user.terms, i, term)
+		}
+
+		posting[eventId] = struct{}{}
+	}
+
+	idx.docs[eventId] = document{userId: userId, terms: terms}
+}
+
+// Remove drops the event from the index.
+func (idx *Index) Remove(eventId int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(eventId)
+}
+
+// Search returns the ids of the events of the user which match every word of the query in ascending order.
+// A query without words matches nothing.
+func (idx *Index) Search(userId int, query string) []int {
+	words := Tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	user, ok := idx.users[userId]
+	if !ok {
+		return nil
+	}
+
+	var matched map[int]struct{}
+	for _, word := range words {
+		found := user.withPrefix(word)
+		if matched == nil {
+			matched = found
+		} else {
+			for id := range matched {
+				if _, ok := found[id]; !ok {
+					delete(matched, id)
+				}
+			}
+		}
+
+		if len(matched) == 0 {
+			return nil
+		}
+	}
+
+	ids := make([]int, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// withPrefix returns the ids of the events with a word starting with prefix.
+func (u *userIndex) withPrefix(prefix string) map[int]struct{} {
+	ids := make(map[int]struct{})
+
+	i, _ := slices.BinarySearch(u.terms, prefix)
+	for ; i < len(u.terms) && strings.HasPrefix(u.terms[i], prefix); i++ {
+		for id := range u.postings[u.terms[i]] {
+			ids[id] = struct{}{}
+		}
+	}
+
+	return ids
+}
+
+// remove drops the event from the index, it has to be called with the write lock held.
+func (idx *Index) remove(eventId int) {
+	doc, ok := idx.docs[eventId]
+	if !ok {
+		return
+	}
+
+	delete(idx.docs, eventId)
+
+	user := idx.users[doc.userId]
+	for _, term := range doc.terms {
+		posting := user.postings[term]
+		delete(posting, eventId)
+
+		if len(posting) == 0 {
+			delete(user.postings, term)
+
+			if i, found := slices.BinarySearch(user.terms, term); found {
+				user.terms = slices.Delete(user.terms, i, i+1)
+			}
+		}
+	}
+
+	if len(user.terms) == 0 {
+		delete(idx.users, doc.userId)
+	}
+}
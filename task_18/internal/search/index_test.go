@@ -0,0 +1,47 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"встреча", "с", "ольгой", "в", "10", "00", "еж"}, Tokenize("Встреча с Ольгой, в 10:00 — ЁЖ"))
+	assert.Equal(t, []string{"team", "sync", "q3"}, Tokenize("Team-sync (Q3)"))
+	assert.Empty(t, Tokenize(" ,.- "))
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(1, 1, "Встреча с командой", "обсудить релиз")
+	idx.Add(2, 1, "Встречи по пятницам", "")
+	idx.Add(3, 1, "Team sync", "release planning")
+	idx.Add(4, 2, "Встреча", "чужое событие")
+
+	assert.Equal(t, []int{1, 2}, idx.Search(1, "ВСТРЕЧ"))
+	assert.Equal(t, []int{1}, idx.Search(1, "встреча релиз"))
+	assert.Equal(t, []int{3}, idx.Search(1, "rel"))
+	assert.Equal(t, []int{4}, idx.Search(2, "встреча"))
+	assert.Empty(t, idx.Search(1, "встреча пятниц релиз"))
+	assert.Empty(t, idx.Search(1, "   "))
+	assert.Empty(t, idx.Search(3, "встреча"))
+}
+
+func TestIndexUpdateAndRemove(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(1, 1, "Ёлка", "")
+
+	assert.Equal(t, []int{1}, idx.Search(1, "елка"))
+
+	idx.Add(1, 1, "Новый год", "")
+	assert.Empty(t, idx.Search(1, "елка"))
+	assert.Equal(t, []int{1}, idx.Search(1, "новый"))
+
+	idx.Remove(1)
+	assert.Empty(t, idx.Search(1, "новый"))
+	assert.Empty(t, idx.users)
+	assert.Empty(t, idx.docs)
+
+	idx.Remove(1)
+}
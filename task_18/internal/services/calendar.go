@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// CreateCalendar creates a calendar of the authenticated user, it is not shared with anyone.
+func (es *EventService) CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error) {
+	if err := authorize(ctx, calendar.OwnerID); err != nil {
+		return nil, err
+	}
+
+	calendar.Shares = nil
+
+	return es.repo.CreateCalendar(ctx, calendar)
+}
+
+// Calendars returns the calendars the user owns or which are shared with the user.
+func (es *EventService) Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return es.repo.Calendars(ctx, userId)
+}
+
+// Calendar returns the calendar to its owner and the users it is shared with.
+func (es *EventService) Calendar(ctx context.Context, calendarId int) (*domains.Calendar, error) {
+	return es.permittedCalendar(ctx, calendarId, domains.PermissionRead)
+}
+
+// RenameCalendar changes the name of the calendar, it needs the admin permission.
+func (es *EventService) RenameCalendar(ctx context.Context, calendarId int, name string) (*domains.Calendar, error) {
+	calendar, err := es.permittedCalendar(ctx, calendarId, domains.PermissionAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stored calendar may be shared with readers, so change a copy
+	updated := *calendar
+	updated.Name = name
+
+	return es.repo.UpdateCalendar(ctx, &updated)
+}
+
+// Share grants the user the permission on the calendar or changes the permission the user has,
+// it needs the admin permission.
+func (es *EventService) Share(ctx context.Context, calendarId int, userId int, permission domains.Permission) (*domains.Calendar, error) {
+	calendar, err := es.permittedCalendar(ctx, calendarId, domains.PermissionAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if userId == calendar.OwnerID {
+		return nil, domains.ErrShareWithOwner
+	}
+
+	updated := *calendar
+	updated.Shares = slices.DeleteFunc(slices.Clone(calendar.Shares), func(share domains.Share) bool {
+		return share.UserID == userId
+	})
+	updated.Shares = append(updated.Shares, domains.Share{UserID: userId, Permission: permission})
+
+	return es.repo.UpdateCalendar(ctx, &updated)
+}
+
+// Unshare takes the access to the calendar away from the user. It needs the admin permission,
+// unless users leave a calendar shared with them.
+func (es *EventService) Unshare(ctx context.Context, calendarId int, userId int) (*domains.Calendar, error) {
+	required := domains.PermissionAdmin
+	if current, ok := auth.UserFromContext(ctx); ok && current == userId {
+		required = domains.PermissionRead
+	}
+
+	calendar, err := es.permittedCalendar(ctx, calendarId, required)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *calendar
+	updated.Shares = slices.DeleteFunc(slices.Clone(calendar.Shares), func(share domains.Share) bool {
+		return share.UserID == userId
+	})
+
+	return es.repo.UpdateCalendar(ctx, &updated)
+}
+
+// DeleteCalendar removes an empty calendar, only its owner may delete it.
+func (es *EventService) DeleteCalendar(ctx context.Context, calendarId int) error {
+	calendar, err := es.repo.Calendar(ctx, calendarId)
+	if err != nil {
+		return err
+	}
+
+	if err := authorize(ctx, calendar.OwnerID); err != nil {
+		return err
+	}
+
+	return es.repo.DeleteCalendar(ctx, calendarId)
+}
+
+// CalendarEvents returns the stored events of the calendar which have an occurrence in [from, to), recurring
+// events are not expanded. A range from the zero time to maxTime or later returns every stored event.
+// The user needs the read permission on the calendar, zero selects the personal events.
+func (es *EventService) CalendarEvents(ctx context.Context, userId int, calendarId int, from, to time.Time) ([]*domains.Event, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	events, err := es.calendarEvents(ctx, userId, []int{calendarId}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() && !to.Before(maxTime) {
+		return events, nil
+	}
+
+	return slices.DeleteFunc(events, func(event *domains.Event) bool {
+		return !occurs(event, from, to)
+	}), nil
+}
+
+// calendarEvents returns the events of the calendars the user may read in [from, to), zero selects
+// the personal events of the user.
+func (es *EventService) calendarEvents(ctx context.Context, userId int, calendarIds []int, from, to time.Time) ([]*domains.Event, error) {
+	events := make([]*domains.Event, 0)
+
+	for _, calendarId := range slices.Compact(slices.Sorted(slices.Values(calendarIds))) {
+		if calendarId == 0 {
+			personal, _, err := es.repo.List(ctx, userId, from, to, domains.Page{})
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, slices.DeleteFunc(personal, func(event *domains.Event) bool {
+				return event.CalendarID != 0
+			})...)
+			continue
+		}
+
+		if _, err := es.permittedCalendar(ctx, calendarId, domains.PermissionRead); err != nil {
+			return nil, err
+		}
+
+		shared, err := es.repo.CalendarEvents(ctx, calendarId, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, shared...)
+	}
+
+	return events, nil
+}
+
+func (es *EventService) permittedCalendar(ctx context.Context, calendarId int, required domains.Permission) (*domains.Calendar, error) {
+	calendar, err := es.repo.Calendar(ctx, calendarId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := permit(ctx, calendar, required); err != nil {
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+// access checks that the authenticated user has the permission on the event. The owner has every permission
+// on personal events, the permission on the events of a calendar is the one on the calendar.
+func (es *EventService) access(ctx context.Context, event *domains.Event, required domains.Permission) error {
+	if event.CalendarID == 0 {
+		return authorize(ctx, event.UserID)
+	}
+
+	_, err := es.permittedCalendar(ctx, event.CalendarID, required)
+
+	return err
+}
+
+// place checks that the authenticated user may store the event in its calendar,
+// it makes the owner of the calendar the owner of the event.
+func (es *EventService) place(ctx context.Context, event *domains.Event) error {
+	if event.CalendarID == 0 {
+		return authorize(ctx, event.UserID)
+	}
+
+	calendar, err := es.permittedCalendar(ctx, event.CalendarID, domains.PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	event.UserID = calendar.OwnerID
+
+	return nil
+}
+
+// permit checks that the authenticated user has the permission on the calendar.
+func permit(ctx context.Context, calendar *domains.Calendar, required domains.Permission) error {
+	userId, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return domains.ErrUnauthenticated
+	}
+
+	if permission, ok := calendar.Access(userId); !ok || !permission.Allows(required) {
+		return domains.ErrForbidden
+	}
+
+	return nil
+}
@@ -2,63 +2,807 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
 	"time"
 
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
 )
 
+// EventRepository stores the events. List and Search return the events ordered by domains.CompareEvents.
 type EventRepository interface {
 	Event(ctx context.Context, id int) (*domains.Event, error)
-	List(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error)
+	// List returns the events of the user in [from, to) and the total number of them. The page selects the events
+	// which are not recurring, recurring series are returned whatever the page as their occurrences may follow
+	// its cursor.
+	List(ctx context.Context, userId int, from, to time.Time, page domains.Page) ([]*domains.Event, int, error)
+	// Attending works like List without a page for the events the user is invited to
+	Attending(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error)
+	// Invitations returns the page of the events the user is invited to with the answer status, any answer if it
+	// is empty, and the total number of them. Recurring events are paged as series.
+	Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) ([]*domains.Event, int, error)
 	Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error)
 	Update(ctx context.Context, event *domains.Event) (*domains.Event, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int, version int) error
+	History(ctx context.Context, id int) ([]*domains.Event, error)
+	Search(ctx context.Context, userId int, query string) ([]*domains.Event, error)
+	// Apply runs the operations as a whole, failing with a *domains.BatchError if one of them fails
+	Apply(ctx context.Context, operations []domains.Operation) ([]*domains.Event, error)
+	Calendar(ctx context.Context, id int) (*domains.Calendar, error)
+	// Calendars returns the calendars the user owns or which are shared with the user, ordered by id
+	Calendars(ctx context.Context, userId int) ([]*domains.Calendar, error)
+	CreateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error)
+	UpdateCalendar(ctx context.Context, calendar *domains.Calendar) (*domains.Calendar, error)
+	// DeleteCalendar fails with domains.ErrCalendarNotEmpty while the calendar holds events
+	DeleteCalendar(ctx context.Context, id int) error
+	// CalendarEvents works like List without a page for the events of the calendar
+	CalendarEvents(ctx context.Context, calendarId int, from, to time.Time) ([]*domains.Event, error)
+	// Trash returns the deleted events of the user, TrashedEvent a deleted event
+	Trash(ctx context.Context, userId int) ([]*domains.Event, error)
+	TrashedEvent(ctx context.Context, id int) (*domains.Event, error)
+	// Restore moves the trashed event with the id of event back to the events, storing event in its place
+	Restore(ctx context.Context, event *domains.Event) (*domains.Event, error)
+	// Purge permanently removes the events deleted before the given time and returns them
+	Purge(ctx context.Context, before time.Time) ([]*domains.Event, error)
+}
+
+// maxTime is the upper bound of unbounded range queries
+var maxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// conflictHorizon limits how far from its start a recurring event is checked for conflicts
+const conflictHorizon = 366 * 24 * time.Hour
+
+// ConflictPolicy is what Create and Update do with events which overlap other events of the user.
+type ConflictPolicy string
+
+const (
+	ConflictIgnore ConflictPolicy = "ignore"
+	// ConflictWarn stores the event and logs the conflict
+	ConflictWarn ConflictPolicy = "warn"
+	// ConflictReject fails with a *domains.ConflictError
+	ConflictReject ConflictPolicy = "reject"
+)
+
+// EventListener is notified after a change of an event has been stored.
+// Changing a single occurrence is reported as an update of the series.
+type EventListener interface {
+	EventCreated(ctx context.Context, event *domains.Event)
+	EventUpdated(ctx context.Context, event *domains.Event)
+	EventDeleted(ctx context.Context, event *domains.Event)
 }
 
 type EventService struct {
-	repo EventRepository
+	repo      EventRepository
+	conflicts ConflictPolicy
+	listeners []EventListener
 }
 
-func NewEventService(repo EventRepository) *EventService {
+func NewEventService(repo EventRepository, conflicts ConflictPolicy, listeners ...EventListener) *EventService {
 	return &EventService{
-		repo: repo,
+		repo:      repo,
+		conflicts: conflicts,
+		listeners: listeners,
 	}
 }
 
-func (es *EventService) EventsForDay(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error) {
+// EventsForDay, EventsForWeek and EventsForMonth compute their windows in the location of date,
+// so days are not assumed to be 24 hours long around DST transitions. Besides the events of the user
+// they return the events the user is invited to and has not declined. Given calendar ids they only return
+// the events of those calendars, the user needs the read permission on each of them and zero selects
+// the personal events of the user. They return the page of the events, the zero page returns all of them.
+func (es *EventService) EventsForDay(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error) {
 	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	to := from.Add(24 * time.Hour)
+	to := from.AddDate(0, 0, 1)
 
-	return es.repo.List(ctx, userId, from, to)
+	return es.agenda(ctx, userId, from, to, page, calendarIds)
 }
 
-func (es *EventService) EventsForWeek(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error) {
+func (es *EventService) EventsForWeek(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error) {
 	weekday := int(date.Weekday())
 	if weekday == 0 {
 		weekday = 7
 	}
 
 	from := time.Date(date.Year(), date.Month(), date.Day()-weekday+1, 0, 0, 0, 0, date.Location())
-	to := from.Add(7 * 24 * time.Hour)
+	to := from.AddDate(0, 0, 7)
 
-	return es.repo.List(ctx, userId, from, to)
+	return es.agenda(ctx, userId, from, to, page, calendarIds)
 }
 
-func (es *EventService) EventsForMonth(ctx context.Context, userId int, date time.Time) ([]*domains.Event, error) {
+func (es *EventService) EventsForMonth(ctx context.Context, userId int, date time.Time, page domains.Page, calendarIds ...int) (*domains.EventPage, error) {
 	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
 	to := from.AddDate(0, 1, 0)
 
-	return es.repo.List(ctx, userId, from, to)
+	return es.agenda(ctx, userId, from, to, page, calendarIds)
+}
+
+// EventsBetween returns the page of the events in [from, to) with recurring events expanded into their occurrences.
+func (es *EventService) EventsBetween(ctx context.Context, userId int, from, to time.Time, page domains.Page) (*domains.EventPage, error) {
+	return es.list(ctx, userId, from, to, page)
+}
+
+// Event returns the stored event to the users who may read it and the invited users, recurring events are not expanded.
+func (es *EventService) Event(ctx context.Context, eventId int) (*domains.Event, error) {
+	event, err := es.repo.Event(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	if userId, ok := auth.UserFromContext(ctx); ok {
+		if _, invited := event.Attendee(userId); invited {
+			return event, nil
+		}
+	}
+
+	if err := es.access(ctx, event, domains.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// Invitations returns a page of the events the user is invited to with the answer status, any answer if it is
+// empty, without expanding recurring events.
+func (es *EventService) Invitations(ctx context.Context, userId int, status domains.AttendeeStatus, page domains.Page) (*domains.EventPage, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	events, total, err := es.repo.Invitations(ctx, userId, status, listedPage(page))
+	if err != nil {
+		return nil, err
+	}
+
+	result, next := domains.Paginate(events, page.After, page.Limit)
+
+	return &domains.EventPage{Events: result, Total: total, Next: next}, nil
+}
+
+// Respond stores the answer of an invited user, the event gets a new version like after an update.
+func (es *EventService) Respond(ctx context.Context, eventId int, userId int, status domains.AttendeeStatus) (*domains.Event, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	event, err := es.repo.Event(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, invited := event.Attendee(userId); !invited {
+		return nil, domains.ErrNotInvited
+	}
+
+	// the stored event may be shared with readers, so change a copy,
+	// it keeps the version of the event so concurrent changes are not overwritten
+	updated := *event
+	updated.Attendees = slices.Clone(event.Attendees)
+	for i := range updated.Attendees {
+		if updated.Attendees[i].UserID == userId {
+			updated.Attendees[i].Status = status
+		}
+	}
+
+	return es.update(ctx, &updated)
+}
+
+// Search returns the events of the user whose title or description contain a word starting with every word
+// of the query, ordered by date. Given a range, recurring events are expanded into their occurrences inside it.
+func (es *EventService) Search(ctx context.Context, userId int, query string, from, to time.Time) ([]*domains.Event, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	events, err := es.repo.Search(ctx, userId, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() && to.IsZero() {
+		return events, nil
+	}
+
+	return expand(events, from, to), nil
+}
+
+// UserEvents returns every event of the user without expanding recurring events.
+func (es *EventService) UserEvents(ctx context.Context, userId int) ([]*domains.Event, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	events, _, err := es.repo.List(ctx, userId, time.Time{}, maxTime, domains.Page{})
+
+	return events, err
 }
 
 func (es *EventService) Create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
-	return es.repo.Create(ctx, newEvent)
+	if err := es.place(ctx, newEvent); err != nil {
+		return nil, err
+	}
+
+	if err := es.checkConflicts(ctx, newEvent); err != nil {
+		return nil, err
+	}
+
+	return es.create(ctx, newEvent)
 }
 
+func (es *EventService) create(ctx context.Context, newEvent *domains.Event) (*domains.Event, error) {
+	event, err := es.repo.Create(ctx, newEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listener := range es.listeners {
+		listener.EventCreated(ctx, event)
+	}
+
+	return event, nil
+}
+
+// Update replaces the event, it needs the write permission on the stored event and on the calendar it is moved to.
+// A non-zero event.Version has to match the stored version or ErrVersionMismatch is returned.
 func (es *EventService) Update(ctx context.Context, event *domains.Event) (*domains.Event, error) {
-	return es.repo.Update(ctx, event)
+	stored, err := es.permittedEvent(ctx, event.ID, domains.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+	keepStored(event, stored)
+
+	if err := es.place(ctx, event); err != nil {
+		return nil, err
+	}
+
+	if err := es.checkConflicts(ctx, event, event.ID); err != nil {
+		return nil, err
+	}
+
+	return es.update(ctx, event)
+}
+
+func (es *EventService) update(ctx context.Context, event *domains.Event) (*domains.Event, error) {
+	event, err := es.repo.Update(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listener := range es.listeners {
+		listener.EventUpdated(ctx, event)
+	}
+
+	return event, nil
+}
+
+// Delete moves the event to the trash, a non-zero version has to match the stored one.
+func (es *EventService) Delete(ctx context.Context, eventId int, version int) error {
+	event, err := es.permittedEvent(ctx, eventId, domains.PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	if err := es.repo.Delete(ctx, eventId, version); err != nil {
+		return err
+	}
+
+	for _, listener := range es.listeners {
+		listener.EventDeleted(ctx, event)
+	}
+
+	return nil
+}
+
+// BatchResult is the outcome of an operation of a batch, Event is nil for deletions.
+type BatchResult struct {
+	Event *domains.Event
+	Err   error
+}
+
+// Batch runs the operations in order. An atomic batch is checked as a whole before anything is stored,
+// if an operation fails none is applied and a *domains.BatchError is returned. Otherwise every operation
+// runs on its own and its error is reported in its result. Conflicts are checked against the stored events,
+// not against the other events of the batch.
+func (es *EventService) Batch(ctx context.Context, operations []domains.Operation, atomic bool) ([]BatchResult, error) {
+	if !atomic {
+		results := make([]BatchResult, len(operations))
+		for i, operation := range operations {
+			results[i].Event, results[i].Err = es.run(ctx, operation)
+		}
+
+		return results, nil
+	}
+
+	// the deleted events are kept to notify the listeners
+	deleted := make([]*domains.Event, len(operations))
+	for i, operation := range operations {
+		var err error
+		if deleted[i], err = es.check(ctx, operation); err != nil {
+			return nil, &domains.BatchError{Index: i, Err: err}
+		}
+	}
+
+	events, err := es.repo.Apply(ctx, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(operations))
+	for i, operation := range operations {
+		results[i].Event = events[i]
+
+		for _, listener := range es.listeners {
+			switch operation.Kind {
+			case domains.OperationCreate:
+				listener.EventCreated(ctx, events[i])
+			case domains.OperationUpdate:
+				listener.EventUpdated(ctx, events[i])
+			case domains.OperationDelete:
+				listener.EventDeleted(ctx, deleted[i])
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// run applies a single operation of a best-effort batch.
+func (es *EventService) run(ctx context.Context, operation domains.Operation) (*domains.Event, error) {
+	switch operation.Kind {
+	case domains.OperationCreate:
+		return es.Create(ctx, operation.Event)
+	case domains.OperationUpdate:
+		return es.Update(ctx, operation.Event)
+	case domains.OperationDelete:
+		return nil, es.Delete(ctx, operation.ID, operation.Version)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", operation.Kind)
+	}
+}
+
+// check runs the checks Create, Update and Delete do before storing, it returns the event a deletion removes.
+func (es *EventService) check(ctx context.Context, operation domains.Operation) (*domains.Event, error) {
+	switch operation.Kind {
+	case domains.OperationCreate:
+		if err := es.place(ctx, operation.Event); err != nil {
+			return nil, err
+		}
+
+		return nil, es.checkConflicts(ctx, operation.Event)
+	case domains.OperationUpdate:
+		stored, err := es.permittedEvent(ctx, operation.Event.ID, domains.PermissionWrite)
+		if err != nil {
+			return nil, err
+		}
+		keepStored(operation.Event, stored)
+
+		if err := es.place(ctx, operation.Event); err != nil {
+			return nil, err
+		}
+
+		return nil, es.checkConflicts(ctx, operation.Event, operation.Event.ID)
+	case domains.OperationDelete:
+		return es.permittedEvent(ctx, operation.ID, domains.PermissionWrite)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", operation.Kind)
+	}
+}
+
+// UpdateOccurrence detaches the occurrence of a recurring event on the given date from the series
+// and stores event as a standalone replacement for it. A non-zero event.Version is checked against the series.
+// Both changes are stored as a whole, so a failure can not lose the occurrence.
+func (es *EventService) UpdateOccurrence(ctx context.Context, eventId int, occurrence time.Time, event *domains.Event) (*domains.Event, error) {
+	if err := es.place(ctx, event); err != nil {
+		return nil, err
+	}
+
+	seriesVersion := event.Version
+	event.ID = 0
+	event.Version = 0
+	event.Recurrence = nil
+
+	// the replaced occurrence is not a conflict, so the series is left out of the check
+	if err := es.checkConflicts(ctx, event, eventId); err != nil {
+		return nil, err
+	}
+
+	series, err := es.excludeOccurrence(ctx, eventId, occurrence, seriesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := es.repo.Apply(ctx, []domains.Operation{
+		{Kind: domains.OperationUpdate, Event: series},
+		{Kind: domains.OperationCreate, Event: event},
+	})
+	if err != nil {
+		var batchErr *domains.BatchError
+		if errors.As(err, &batchErr) {
+			return nil, batchErr.Err
+		}
+
+		return nil, err
+	}
+
+	for _, listener := range es.listeners {
+		listener.EventUpdated(ctx, events[0])
+		listener.EventCreated(ctx, events[1])
+	}
+
+	return events[1], nil
+}
+
+// DeleteOccurrence removes the occurrence of a recurring event on the given date, keeping the rest of the series.
+func (es *EventService) DeleteOccurrence(ctx context.Context, eventId int, occurrence time.Time, version int) error {
+	series, err := es.excludeOccurrence(ctx, eventId, occurrence, version)
+	if err != nil {
+		return err
+	}
+
+	// removing an occurrence can not add conflicts
+	_, err = es.update(ctx, series)
+
+	return err
+}
+
+// History returns the previous versions of the event, oldest first. The history of an event in the trash
+// is kept until it is purged, the read permission is then checked on the trashed event.
+func (es *EventService) History(ctx context.Context, eventId int) ([]*domains.Event, error) {
+	_, err := es.permittedEvent(ctx, eventId, domains.PermissionRead)
+	if errors.Is(err, domains.ErrEventNotFound) {
+		_, err = es.permittedTrashedEvent(ctx, eventId, domains.PermissionRead)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return es.repo.History(ctx, eventId)
 }
 
-func (es *EventService) Delete(ctx context.Context, eventId int) error {
-	return es.repo.Delete(ctx, eventId)
+// excludeOccurrence returns a copy of the series without the occurrence on the given date, it is not stored yet.
+func (es *EventService) excludeOccurrence(ctx context.Context, eventId int, occurrence time.Time, version int) (*domains.Event, error) {
+	series, err := es.permittedEvent(ctx, eventId, domains.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && version != series.Version {
+		return nil, domains.ErrVersionMismatch
+	}
+
+	if !series.IsRecurring() {
+		return nil, domains.ErrEventNotRecurring
+	}
+
+	if !series.Recurrence.HasOccurrence(series.Date, occurrence) {
+		return nil, domains.ErrOccurrenceNotFound
+	}
+
+	// the stored event may be shared with readers, so change a copy,
+	// it keeps the version of the series so concurrent changes are not overwritten
+	updated := *series
+	recurrence := *series.Recurrence
+	recurrence.Exceptions = append(slices.Clone(recurrence.Exceptions), occurrence)
+	updated.Recurrence = &recurrence
+
+	return &updated, nil
+}
+
+// permittedEvent returns the event if the authenticated user has the permission on it.
+func (es *EventService) permittedEvent(ctx context.Context, eventId int, required domains.Permission) (*domains.Event, error) {
+	event, err := es.repo.Event(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := es.access(ctx, event, required); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// keepStored keeps what an update does not change: the answers of the users the stored event invited,
+// newly invited users are pending, and the CalDAV UID and resource name of the event.
+func keepStored(event, stored *domains.Event) {
+	event.UID, event.Resource = stored.UID, stored.Resource
+
+	for i, attendee := range event.Attendees {
+		if previous, invited := stored.Attendee(attendee.UserID); invited {
+			event.Attendees[i].Status = previous.Status
+		}
+	}
+}
+
+// agenda works like list, adding the events the user is invited to and has not declined.
+// Given calendar ids it returns the events of those calendars instead.
+func (es *EventService) agenda(ctx context.Context, userId int, from, to time.Time, page domains.Page, calendarIds []int) (*domains.EventPage, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	if len(calendarIds) > 0 {
+		events, err := es.calendarEvents(ctx, userId, calendarIds, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		return domains.PageOf(expand(events, from, to), page), nil
+	}
+
+	events, total, err := es.repo.List(ctx, userId, from, to, listedPage(page))
+	if err != nil {
+		return nil, err
+	}
+
+	attended, err := es.attended(ctx, userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandPage(append(events, attended...), total+len(attended), from, to, page), nil
+}
+
+// attended returns the events in [from, to) the user is invited to and has not declined.
+func (es *EventService) attended(ctx context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
+	attending, err := es.repo.Attending(ctx, userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domains.Event, 0, len(attending))
+	for _, event := range attending {
+		if event.Attends(userId) {
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+// list returns the page of the events in [from, to) with recurring events expanded into their occurrences.
+func (es *EventService) list(ctx context.Context, userId int, from, to time.Time, page domains.Page) (*domains.EventPage, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	events, total, err := es.repo.List(ctx, userId, from, to, listedPage(page))
+	if err != nil {
+		return nil, err
+	}
+
+	return expandPage(events, total, from, to, page), nil
+}
+
+// listedPage is the page passed to EventRepository.List for a page of the expanded events. It asks for one
+// more event, so the last event of a full page tells whether more events follow.
+func listedPage(page domains.Page) domains.Page {
+	if page.Limit > 0 {
+		page.Limit++
+	}
+
+	return page
+}
+
+// expandPage returns the page of the occurrences of the events returned by EventRepository.List for listedPage,
+// out of total events. The occurrences of a recurring series take its place in the total.
+func expandPage(events []*domains.Event, total int, from, to time.Time, page domains.Page) *domains.EventPage {
+	expanded := expand(events, from, to)
+	result, next := domains.Paginate(expanded, page.After, page.Limit)
+
+	return &domains.EventPage{Events: result, Total: total + len(expanded) - len(events), Next: next}
+}
+
+// expand replaces the recurring events with their occurrences which overlap [from, to) and drops the other
+// events outside of it, the result is ordered by domains.CompareEvents.
+func expand(events []*domains.Event, from, to time.Time) []*domains.Event {
+	result := make([]*domains.Event, 0, len(events))
+
+	for _, event := range events {
+		if !event.IsRecurring() {
+			if event.Overlaps(from, to) {
+				result = append(result, event)
+			}
+			continue
+		}
+
+		// occurrences started before from may still be going on
+		duration := event.Duration()
+
+		for _, occurrence := range event.Recurrence.Occurrences(event.Date, from.Add(-duration), to) {
+			instance := *event
+			instance.Date = occurrence
+			if duration > 0 {
+				instance.End = occurrence.Add(duration)
+			}
+
+			if instance.Overlaps(from, to) {
+				result = append(result, &instance)
+			}
+		}
+	}
+
+	domains.SortEvents(result)
+
+	return result
+}
+
+// occurs reports whether an occurrence of the event overlaps [from, to), it stops at the first one.
+func occurs(event *domains.Event, from, to time.Time) bool {
+	if !event.IsRecurring() {
+		return event.Overlaps(from, to)
+	}
+
+	duration := event.Duration()
+
+	for occurrence := range event.Recurrence.All(event.Date, from.Add(-duration), to) {
+		instance := domains.Event{Date: occurrence}
+		if duration > 0 {
+			instance.End = occurrence.Add(duration)
+		}
+
+		if instance.Overlaps(from, to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Conflicts returns the occurrences of the other events of the owner which overlap an occurrence of event,
+// events with the ignored ids are left out. Only events with a duration take up time. The caller has to check
+// that the authenticated user may write the event.
+func (es *EventService) Conflicts(ctx context.Context, event *domains.Event, ignore ...int) ([]*domains.Event, error) {
+	own := occurrenceIntervals(event)
+	if len(own) == 0 {
+		return nil, nil
+	}
+
+	from, to := own[0].Start, own[len(own)-1].End
+
+	stored, _, err := es.repo.List(ctx, event.UserID, from, to, domains.Page{})
+	if err != nil {
+		return nil, err
+	}
+	others := expand(stored, from, to)
+
+	result := make([]*domains.Event, 0)
+
+	for _, other := range others {
+		if other.Duration() <= 0 || (event.ID != 0 && other.ID == event.ID) || slices.Contains(ignore, other.ID) {
+			continue
+		}
+
+		interval := domains.Interval{Start: other.Date, End: other.End}
+		// own is sorted by start and end, so only the first interval ending after the start can overlap
+		i := sort.Search(len(own), func(i int) bool { return own[i].End.After(interval.Start) })
+		if i < len(own) && own[i].Overlaps(interval) {
+			result = append(result, other)
+		}
+	}
+
+	return result, nil
+}
+
+// FreeBusy returns when the users are busy in [from, to) and the free gaps of at least length in it.
+// Users are busy during their own events and the invitations they have not declined.
+// Any authenticated user may see when others are busy, the events themselves are not returned.
+func (es *EventService) FreeBusy(ctx context.Context, userIds []int, from, to time.Time, length time.Duration) (*domains.FreeBusy, error) {
+	if _, ok := auth.UserFromContext(ctx); !ok {
+		return nil, domains.ErrUnauthenticated
+	}
+
+	freeBusy := &domains.FreeBusy{
+		Users: make(map[int][]domains.Interval, len(userIds)),
+	}
+	all := make([]domains.Interval, 0)
+
+	for _, userId := range userIds {
+		if _, ok := freeBusy.Users[userId]; ok {
+			continue
+		}
+
+		events, _, err := es.repo.List(ctx, userId, from, to, domains.Page{})
+		if err != nil {
+			return nil, err
+		}
+
+		attended, err := es.attended(ctx, userId, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		busy := make([]domains.Interval, 0)
+		for _, event := range expand(append(events, attended...), from, to) {
+			if event.Duration() <= 0 {
+				continue
+			}
+
+			busy = append(busy, domains.Interval{Start: maxOf(event.Date, from), End: minOf(event.End, to)})
+		}
+
+		freeBusy.Users[userId] = domains.MergeIntervals(busy)
+		all = append(all, busy...)
+	}
+
+	freeBusy.Busy = domains.MergeIntervals(all)
+	freeBusy.Free = domains.FreeSlots(freeBusy.Busy, from, to, length)
+
+	return freeBusy, nil
+}
+
+func (es *EventService) checkConflicts(ctx context.Context, event *domains.Event, ignore ...int) error {
+	if es.conflicts != ConflictWarn && es.conflicts != ConflictReject {
+		return nil
+	}
+
+	conflicts, err := es.Conflicts(ctx, event, ignore...)
+	if err != nil || len(conflicts) == 0 {
+		return err
+	}
+
+	if es.conflicts == ConflictReject {
+		return &domains.ConflictError{Conflicts: conflicts}
+	}
+
+	ids := make([]int, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		ids = append(ids, conflict.ID)
+	}
+	slog.WarnContext(ctx, "[EventService] event overlaps other events", "event_id", event.ID, "conflicts", ids)
+
+	return nil
+}
+
+// occurrenceIntervals returns the sorted time ranges of the event, recurring events up to conflictHorizon from their start.
+func occurrenceIntervals(event *domains.Event) []domains.Interval {
+	duration := event.Duration()
+	if duration <= 0 {
+		return nil
+	}
+
+	if !event.IsRecurring() {
+		return []domains.Interval{{Start: event.Date, End: event.End}}
+	}
+
+	starts := event.Recurrence.Occurrences(event.Date, event.Date, event.Date.Add(conflictHorizon))
+	result := make([]domains.Interval, 0, len(starts))
+	for _, start := range starts {
+		result = append(result, domains.Interval{Start: start, End: start.Add(duration)})
+	}
+
+	return result
+}
+
+func maxOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func minOf(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+// authorize checks that the authenticated user is the owner of the data.
+func authorize(ctx context.Context, ownerId int) error {
+	userId, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return domains.ErrUnauthenticated
+	}
+
+	if userId != ownerId {
+		return domains.ErrForbidden
+	}
+
+	return nil
 }
@@ -2,16 +2,26 @@ package services
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/M-kos/wb_level2/task_18/internal/auth"
 	"github.com/M-kos/wb_level2/task_18/internal/domains"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type mockRepo struct {
-	events []*domains.Event
+	events    []*domains.Event
+	history   map[int][]*domains.Event
+	calendars []*domains.Calendar
+	trash     []*domains.Event
+	// createErr fails the creation of events when set
+	createErr error
 }
 
 func (m *mockRepo) Event(_ context.Context, id int) (*domains.Event, error) {
@@ -23,18 +33,45 @@ func (m *mockRepo) Event(_ context.Context, id int) (*domains.Event, error) {
 	return nil, domains.ErrEventNotFound
 }
 
-func (m *mockRepo) List(_ context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
+// List returns every event of the range whatever the page, the service pages them again anyway.
+func (m *mockRepo) List(_ context.Context, userId int, from, to time.Time, _ domains.Page) ([]*domains.Event, int, error) {
 	var result []*domains.Event
 	for _, e := range m.events {
-		if e.UserID == userId && !e.Date.Before(from) && e.Date.Before(to) {
+		if e.UserID == userId && (e.IsRecurring() && e.Date.Before(to) || e.Overlaps(from, to)) {
+			result = append(result, e)
+		}
+	}
+	return result, len(result), nil
+}
+
+func (m *mockRepo) Attending(_ context.Context, userId int, from, to time.Time) ([]*domains.Event, error) {
+	var result []*domains.Event
+	for _, e := range m.events {
+		if _, invited := e.Attendee(userId); invited && (e.IsRecurring() && e.Date.Before(to) || e.Overlaps(from, to)) {
 			result = append(result, e)
 		}
 	}
 	return result, nil
 }
 
+func (m *mockRepo) Invitations(_ context.Context, userId int, status domains.AttendeeStatus, page domains.Page) ([]*domains.Event, int, error) {
+	var result []*domains.Event
+	for _, e := range m.events {
+		if attendee, invited := e.Attendee(userId); invited && (status == "" || attendee.Status == status) {
+			result = append(result, e)
+		}
+	}
+	domains.SortEvents(result)
+	selected, _ := domains.Paginate(result, page.After, page.Limit)
+	return selected, len(result), nil
+}
+
 func (m *mockRepo) Create(_ context.Context, e *domains.Event) (*domains.Event, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
 	e.ID = len(m.events) + 1
+	e.Version = 1
 	m.events = append(m.events, e)
 	return e, nil
 }
@@ -42,6 +79,11 @@ func (m *mockRepo) Create(_ context.Context, e *domains.Event) (*domains.Event,
 func (m *mockRepo) Update(_ context.Context, e *domains.Event) (*domains.Event, error) {
 	for i, ev := range m.events {
 		if ev.ID == e.ID {
+			if e.Version != 0 && e.Version != ev.Version {
+				return nil, domains.ErrVersionMismatch
+			}
+			m.archive(ev)
+			e.Version = ev.Version + 1
 			m.events[i] = e
 			return e, nil
 		}
@@ -49,16 +91,189 @@ func (m *mockRepo) Update(_ context.Context, e *domains.Event) (*domains.Event,
 	return nil, domains.ErrEventNotFound
 }
 
-func (m *mockRepo) Delete(_ context.Context, id int) error {
+func (m *mockRepo) Delete(_ context.Context, id int, version int) error {
 	for i, e := range m.events {
 		if e.ID == id {
+			if version != 0 && version != e.Version {
+				return domains.ErrVersionMismatch
+			}
+			m.archive(e)
 			m.events = append(m.events[:i], m.events[i+1:]...)
+			trashed := *e
+			trashed.DeletedAt = time.Now()
+			m.trash = append(m.trash, &trashed)
 			return nil
 		}
 	}
 	return domains.ErrEventNotFound
 }
 
+func (m *mockRepo) Search(_ context.Context, userId int, query string) ([]*domains.Event, error) {
+	var result []*domains.Event
+	for _, e := range m.events {
+		if e.UserID == userId && strings.Contains(strings.ToLower(e.Title), strings.ToLower(query)) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepo) History(_ context.Context, id int) ([]*domains.Event, error) {
+	return m.history[id], nil
+}
+
+func (m *mockRepo) Apply(ctx context.Context, operations []domains.Operation) ([]*domains.Event, error) {
+	events := slices.Clone(m.events)
+	history := maps.Clone(m.history)
+	trash := slices.Clone(m.trash)
+	result := make([]*domains.Event, len(operations))
+
+	for i, operation := range operations {
+		var err error
+		switch operation.Kind {
+		case domains.OperationCreate:
+			result[i], err = m.Create(ctx, operation.Event)
+		case domains.OperationUpdate:
+			result[i], err = m.Update(ctx, operation.Event)
+		case domains.OperationDelete:
+			err = m.Delete(ctx, operation.ID, operation.Version)
+		}
+
+		if err != nil {
+			m.events, m.history, m.trash = events, history, trash
+			return nil, &domains.BatchError{Index: i, Err: err}
+		}
+	}
+
+	return result, nil
+}
+
+func (m *mockRepo) Calendar(_ context.Context, id int) (*domains.Calendar, error) {
+	for _, c := range m.calendars {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, domains.ErrCalendarNotFound
+}
+
+func (m *mockRepo) Calendars(_ context.Context, userId int) ([]*domains.Calendar, error) {
+	var result []*domains.Calendar
+	for _, c := range m.calendars {
+		if _, ok := c.Access(userId); ok {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepo) CreateCalendar(_ context.Context, c *domains.Calendar) (*domains.Calendar, error) {
+	c.ID = len(m.calendars) + 1
+	m.calendars = append(m.calendars, c)
+	return c, nil
+}
+
+func (m *mockRepo) UpdateCalendar(_ context.Context, c *domains.Calendar) (*domains.Calendar, error) {
+	for i, stored := range m.calendars {
+		if stored.ID == c.ID {
+			m.calendars[i] = c
+			return c, nil
+		}
+	}
+	return nil, domains.ErrCalendarNotFound
+}
+
+func (m *mockRepo) DeleteCalendar(_ context.Context, id int) error {
+	for _, e := range m.events {
+		if e.CalendarID == id {
+			return domains.ErrCalendarNotEmpty
+		}
+	}
+	for i, c := range m.calendars {
+		if c.ID == id {
+			m.calendars = append(m.calendars[:i], m.calendars[i+1:]...)
+			return nil
+		}
+	}
+	return domains.ErrCalendarNotFound
+}
+
+func (m *mockRepo) CalendarEvents(_ context.Context, calendarId int, from, to time.Time) ([]*domains.Event, error) {
+	var result []*domains.Event
+	for _, e := range m.events {
+		if e.CalendarID == calendarId && (e.IsRecurring() && e.Date.Before(to) || e.Overlaps(from, to)) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepo) Trash(_ context.Context, userId int) ([]*domains.Event, error) {
+	var result []*domains.Event
+	for _, e := range m.trash {
+		if e.UserID == userId {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepo) TrashedEvent(_ context.Context, id int) (*domains.Event, error) {
+	for _, e := range m.trash {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, domains.ErrEventNotFound
+}
+
+func (m *mockRepo) Restore(_ context.Context, event *domains.Event) (*domains.Event, error) {
+	for i, e := range m.trash {
+		if e.ID == event.ID {
+			event.DeletedAt = time.Time{}
+			event.Version = e.Version + 1
+			m.trash = append(m.trash[:i], m.trash[i+1:]...)
+			m.events = append(m.events, event)
+			return event, nil
+		}
+	}
+	return nil, domains.ErrEventNotFound
+}
+
+func (m *mockRepo) Purge(_ context.Context, before time.Time) ([]*domains.Event, error) {
+	var purged, kept []*domains.Event
+	for _, e := range m.trash {
+		if e.DeletedAt.Before(before) {
+			purged = append(purged, e)
+			delete(m.history, e.ID)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	m.trash = kept
+	return purged, nil
+}
+
+func (m *mockRepo) archive(e *domains.Event) {
+	if m.history == nil {
+		m.history = make(map[int][]*domains.Event)
+	}
+	m.history[e.ID] = append(m.history[e.ID], e)
+}
+
+func userCtx(userId int) context.Context {
+	return auth.WithUser(context.Background(), userId)
+}
+
+// eventsOf returns the events of a page of a listing.
+func eventsOf(page *domains.EventPage, err error) ([]*domains.Event, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Events, nil
+}
+
 func date(year int, month time.Month, day int) time.Time {
 	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
@@ -78,14 +293,14 @@ func setupService() (*EventService, *mockRepo) {
 			newEvent(6, 2, date(2026, 3, 11), "other user"),
 		},
 	}
-	return NewEventService(repo), repo
+	return NewEventService(repo, ConflictIgnore), repo
 }
 
 func TestEventsForDay(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	events, err := svc.EventsForDay(ctx, 1, date(2026, 3, 11))
+	events, err := eventsOf(svc.EventsForDay(ctx, 1, date(2026, 3, 11), domains.Page{}))
 	require.NoError(t, err)
 	require.Len(t, events, 1)
 	assert.Equal(t, "monday", events[0].Title)
@@ -93,18 +308,18 @@ func TestEventsForDay(t *testing.T) {
 
 func TestEventsForDay_NoEvents(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	events, err := svc.EventsForDay(ctx, 1, date(2026, 3, 13))
+	events, err := eventsOf(svc.EventsForDay(ctx, 1, date(2026, 3, 13), domains.Page{}))
 	require.NoError(t, err)
 	assert.Empty(t, events)
 }
 
 func TestEventsForDay_FiltersByUserId(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(2)
 
-	events, err := svc.EventsForDay(ctx, 2, date(2026, 3, 11))
+	events, err := eventsOf(svc.EventsForDay(ctx, 2, date(2026, 3, 11), domains.Page{}))
 	require.NoError(t, err)
 	require.Len(t, events, 1)
 	assert.Equal(t, "other user", events[0].Title)
@@ -112,34 +327,34 @@ func TestEventsForDay_FiltersByUserId(t *testing.T) {
 
 func TestEventsForWeek(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	events, err := svc.EventsForWeek(ctx, 1, date(2026, 3, 13))
+	events, err := eventsOf(svc.EventsForWeek(ctx, 1, date(2026, 3, 13), domains.Page{}))
 	require.NoError(t, err)
 	assert.Len(t, events, 3)
 }
 
 func TestEventsForWeek_Sunday(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	events, err := svc.EventsForWeek(ctx, 1, date(2026, 3, 17))
+	events, err := eventsOf(svc.EventsForWeek(ctx, 1, date(2026, 3, 17), domains.Page{}))
 	require.NoError(t, err)
 	assert.Len(t, events, 3)
 }
 
 func TestEventsForMonth(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	events, err := svc.EventsForMonth(ctx, 1, date(2026, 3, 15))
+	events, err := eventsOf(svc.EventsForMonth(ctx, 1, date(2026, 3, 15), domains.Page{}))
 	require.NoError(t, err)
 	assert.Len(t, events, 4)
 }
 
 func TestCreate(t *testing.T) {
 	svc, repo := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
 	event, err := svc.Create(ctx, &domains.Event{
 		UserID: 1,
@@ -153,7 +368,7 @@ func TestCreate(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
 	updated, err := svc.Update(ctx, &domains.Event{
 		ID:     1,
@@ -167,7 +382,7 @@ func TestUpdate(t *testing.T) {
 
 func TestUpdate_NotFound(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
 	_, err := svc.Update(ctx, &domains.Event{ID: 999, Title: "nope"})
 	assert.ErrorIs(t, err, domains.ErrEventNotFound)
@@ -175,17 +390,815 @@ func TestUpdate_NotFound(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	svc, repo := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
 
-	err := svc.Delete(ctx, 1)
+	err := svc.Delete(ctx, 1, 0)
 	require.NoError(t, err)
 	assert.Len(t, repo.events, 5)
 }
 
 func TestDelete_NotFound(t *testing.T) {
 	svc, _ := setupService()
-	ctx := context.Background()
+	ctx := userCtx(1)
+
+	err := svc.Delete(ctx, 999, 0)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestEventsForMonth_ExpandsRecurring(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:     7,
+		UserID: 3,
+		Title:  "standup",
+		Date:   date(2026, 2, 2),
+		Recurrence: &domains.Recurrence{
+			Frequency: domains.FrequencyWeekly,
+			ByDay:     []time.Weekday{time.Monday, time.Thursday},
+		},
+	})
+
+	events, err := eventsOf(svc.EventsForMonth(ctx, 3, date(2026, 3, 15), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 9)
+	assert.Equal(t, date(2026, 3, 2), events[0].Date)
+	assert.Equal(t, date(2026, 3, 5), events[1].Date)
+	assert.Equal(t, date(2026, 3, 30), events[8].Date)
+
+	for _, event := range events {
+		assert.Equal(t, 7, event.ID)
+	}
+}
+
+func TestUpdateOccurrence(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     3,
+		Title:      "daily",
+		Date:       date(2026, 3, 1),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily, Count: 5},
+	})
+
+	moved, err := svc.UpdateOccurrence(ctx, 7, date(2026, 3, 3), &domains.Event{
+		ID:     7,
+		UserID: 3,
+		Title:  "moved",
+		Date:   date(2026, 3, 10),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, 7, moved.ID)
+	assert.Nil(t, moved.Recurrence)
+
+	events, err := eventsOf(svc.EventsForMonth(ctx, 3, date(2026, 3, 1), domains.Page{}))
+	require.NoError(t, err)
+
+	var titles []string
+	for _, event := range events {
+		titles = append(titles, event.Date.Format(time.DateOnly)+" "+event.Title)
+	}
+	assert.ElementsMatch(t, []string{
+		"2026-03-01 daily",
+		"2026-03-02 daily",
+		"2026-03-04 daily",
+		"2026-03-05 daily",
+		"2026-03-10 moved",
+	}, titles)
+}
+
+func TestUpdateOccurrence_FailureKeepsOccurrence(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     3,
+		Title:      "daily",
+		Date:       date(2026, 3, 1),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily, Count: 5},
+	})
+	repo.createErr = errors.New("disk full")
+
+	_, err := svc.UpdateOccurrence(ctx, 7, date(2026, 3, 3), &domains.Event{ID: 7, UserID: 3, Title: "moved", Date: date(2026, 3, 10)})
+	require.ErrorIs(t, err, repo.createErr)
+
+	series, err := svc.Event(ctx, 7)
+	require.NoError(t, err)
+	assert.Empty(t, series.Recurrence.Exceptions)
+	assert.Equal(t, 0, series.Version)
+}
+
+func TestDeleteOccurrence(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     3,
+		Title:      "daily",
+		Date:       date(2026, 3, 1),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily, Count: 3},
+	})
+
+	err := svc.DeleteOccurrence(ctx, 7, date(2026, 3, 2), 0)
+	require.NoError(t, err)
+
+	events, err := eventsOf(svc.EventsForMonth(ctx, 3, date(2026, 3, 1), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, date(2026, 3, 1), events[0].Date)
+	assert.Equal(t, date(2026, 3, 3), events[1].Date)
+}
+
+func TestDeleteOccurrence_Errors(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     3,
+		Title:      "daily",
+		Date:       date(2026, 3, 1),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily, Count: 3},
+	})
+
+	err := svc.DeleteOccurrence(userCtx(1), 1, date(2026, 3, 11), 0)
+	assert.ErrorIs(t, err, domains.ErrEventNotRecurring)
+
+	err = svc.DeleteOccurrence(ctx, 7, date(2026, 3, 4), 0)
+	assert.ErrorIs(t, err, domains.ErrOccurrenceNotFound)
+
+	err = svc.DeleteOccurrence(ctx, 999, date(2026, 3, 4), 0)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestEventsForDay_DSTTransition(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	repo := &mockRepo{
+		events: []*domains.Event{
+			{ID: 1, UserID: 1, Title: "late", Date: time.Date(2026, 3, 29, 23, 30, 0, 0, berlin)},
+			{ID: 2, UserID: 1, Title: "next day", Date: time.Date(2026, 3, 30, 0, 30, 0, 0, berlin)},
+		},
+	}
+	svc := NewEventService(repo, ConflictIgnore)
+
+	// the day clocks go forward is 23 hours long
+	events, err := eventsOf(svc.EventsForDay(userCtx(1), 1, time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "late", events[0].Title)
+}
+
+func TestEventsForDay_RequestTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	repo := &mockRepo{
+		events: []*domains.Event{
+			{ID: 1, UserID: 1, Title: "utc evening", Date: time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)},
+		},
+	}
+	svc := NewEventService(repo, ConflictIgnore)
+
+	events, err := eventsOf(svc.EventsForDay(userCtx(1), 1, time.Date(2026, 3, 11, 0, 0, 0, 0, tokyo), domains.Page{}))
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	events, err = eventsOf(svc.EventsForDay(userCtx(1), 1, date(2026, 3, 11), domains.Page{}))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestEventsForWeek_RecurringKeepsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	repo := &mockRepo{
+		events: []*domains.Event{
+			{
+				ID:         1,
+				UserID:     1,
+				Title:      "standup",
+				Date:       time.Date(2026, 3, 23, 10, 0, 0, 0, berlin),
+				End:        time.Date(2026, 3, 23, 10, 15, 0, 0, berlin),
+				TimeZone:   "Europe/Berlin",
+				Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily},
+			},
+		},
+	}
+	svc := NewEventService(repo, ConflictIgnore)
+
+	events, err := eventsOf(svc.EventsForWeek(userCtx(1), 1, time.Date(2026, 3, 25, 0, 0, 0, 0, berlin), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 7)
+
+	for _, event := range events {
+		assert.Equal(t, 10, event.Date.Hour())
+		assert.Equal(t, 15*time.Minute, event.End.Sub(event.Date))
+	}
+
+	assert.Equal(t, time.Date(2026, 3, 29, 8, 0, 0, 0, time.UTC), events[6].Date.UTC())
+}
+
+func TestEventsForDay_IncludesEventsStartedBefore(t *testing.T) {
+	repo := &mockRepo{
+		events: []*domains.Event{
+			{
+				ID:     1,
+				UserID: 1,
+				Title:  "night shift",
+				Date:   time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC),
+				End:    time.Date(2026, 3, 11, 6, 0, 0, 0, time.UTC),
+			},
+			{
+				ID:         2,
+				UserID:     1,
+				Title:      "nightly backup",
+				Date:       time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC),
+				End:        time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC),
+				Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily},
+			},
+		},
+	}
+	svc := NewEventService(repo, ConflictIgnore)
+
+	events, err := eventsOf(svc.EventsForDay(userCtx(1), 1, date(2026, 3, 11), domains.Page{}))
+	require.NoError(t, err)
+
+	var starts []string
+	for _, event := range events {
+		starts = append(starts, event.Title+" "+event.Date.Format(time.RFC3339))
+	}
+	assert.ElementsMatch(t, []string{
+		"night shift 2026-03-10T22:00:00Z",
+		"nightly backup 2026-03-10T23:00:00Z",
+		"nightly backup 2026-03-11T23:00:00Z",
+	}, starts)
+}
+
+type recordingListener struct {
+	created []int
+	updated []int
+	deleted []int
+}
+
+func (l *recordingListener) EventCreated(_ context.Context, event *domains.Event) {
+	l.created = append(l.created, event.ID)
+}
+
+func (l *recordingListener) EventUpdated(_ context.Context, event *domains.Event) {
+	l.updated = append(l.updated, event.ID)
+}
+
+func (l *recordingListener) EventDeleted(_ context.Context, event *domains.Event) {
+	l.deleted = append(l.deleted, event.ID)
+}
+
+func TestListenersAreNotified(t *testing.T) {
+	_, repo := setupService()
+	listener := &recordingListener{}
+	svc := NewEventService(repo, ConflictIgnore, listener)
+	ctx := userCtx(1)
+
+	created, err := svc.Create(ctx, &domains.Event{UserID: 1, Title: "new", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, &domains.Event{ID: 1, UserID: 1, Title: "changed", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+
+	_, err = svc.Update(ctx, &domains.Event{ID: 999, Title: "missing"})
+	require.Error(t, err)
+
+	require.NoError(t, svc.Delete(ctx, 2, 0))
+
+	assert.Equal(t, []int{created.ID}, listener.created)
+	assert.Equal(t, []int{1}, listener.updated)
+	assert.Equal(t, []int{2}, listener.deleted)
+}
+
+func TestAuthorization(t *testing.T) {
+	svc, repo := setupService()
+	other := userCtx(2)
+
+	_, err := eventsOf(svc.EventsForDay(other, 1, date(2026, 3, 11), domains.Page{}))
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.UserEvents(other, 1)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Create(other, &domains.Event{UserID: 1, Title: "foreign", Date: date(2026, 3, 11)})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Update(other, &domains.Event{ID: 1, UserID: 2, Title: "stolen", Date: date(2026, 3, 11)})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Update(userCtx(1), &domains.Event{ID: 1, UserID: 2, Title: "given away", Date: date(2026, 3, 11)})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	err = svc.Delete(other, 1, 0)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = eventsOf(svc.EventsForDay(context.Background(), 1, date(2026, 3, 11), domains.Page{}))
+	assert.ErrorIs(t, err, domains.ErrUnauthenticated)
+
+	assert.Len(t, repo.events, 6)
+	assert.Equal(t, "monday", repo.events[0].Title)
+}
+
+func TestEventsBetween(t *testing.T) {
+	svc, _ := setupService()
+	ctx := userCtx(1)
+
+	events, err := eventsOf(svc.EventsBetween(ctx, 1, date(2026, 3, 12), date(2026, 3, 26), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "tuesday", events[0].Title)
+
+	_, err = eventsOf(svc.EventsBetween(userCtx(2), 1, date(2026, 3, 12), date(2026, 3, 26), domains.Page{}))
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+}
+
+func TestEvent(t *testing.T) {
+	svc, _ := setupService()
+
+	event, err := svc.Event(userCtx(1), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "monday", event.Title)
+
+	_, err = svc.Event(userCtx(1), 6)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Event(userCtx(1), 999)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestVersions(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(3)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     3,
+		Title:      "daily",
+		Date:       date(2026, 3, 1),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily, Count: 3},
+		Version:    1,
+	})
+
+	renamed := *repo.events[6]
+	renamed.Title = "renamed"
+	updated, err := svc.Update(ctx, &renamed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	stale := *updated
+	stale.Version = 1
+	_, err = svc.Update(ctx, &stale)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	err = svc.DeleteOccurrence(ctx, 7, date(2026, 3, 2), 1)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	err = svc.DeleteOccurrence(ctx, 7, date(2026, 3, 2), 2)
+	require.NoError(t, err)
+
+	err = svc.Delete(ctx, 7, 2)
+	assert.ErrorIs(t, err, domains.ErrVersionMismatch)
+
+	history, err := svc.History(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "daily", history[0].Title)
+	assert.Equal(t, "renamed", history[1].Title)
+
+	_, err = svc.History(userCtx(1), 7)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	require.NoError(t, svc.Delete(ctx, 7, 3))
+}
+
+func TestSearch(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(1)
+
+	repo.events = append(repo.events, &domains.Event{
+		ID:         7,
+		UserID:     1,
+		Title:      "March planning",
+		Date:       date(2026, 2, 2),
+		Recurrence: &domains.Recurrence{Frequency: domains.FrequencyWeekly},
+	}, newEvent(8, 1, date(2026, 4, 2), "march review"))
+
+	events, err := svc.Search(ctx, 1, "march", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "late march", events[0].Title)
+	assert.Equal(t, "March planning", events[1].Title)
+	assert.Equal(t, "march review", events[2].Title)
+
+	events, err = svc.Search(ctx, 1, "march", date(2026, 3, 16), date(2026, 3, 31))
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, date(2026, 3, 16), events[0].Date)
+	assert.Equal(t, date(2026, 3, 23), events[1].Date)
+	assert.Equal(t, "late march", events[2].Title)
+	assert.Equal(t, date(2026, 3, 30), events[3].Date)
+
+	// events outside the range are left out
+	events, err = svc.Search(ctx, 1, "review", date(2026, 3, 16), date(2026, 3, 31))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = svc.Search(userCtx(2), 1, "march", time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+}
+
+func meeting(id, userId int, start time.Time, duration time.Duration, title string) *domains.Event {
+	return &domains.Event{ID: id, UserID: userId, Date: start, End: start.Add(duration), Title: title}
+}
+
+func TestConflicts(t *testing.T) {
+	nine := date(2026, 3, 11).Add(9 * time.Hour)
+	repo := &mockRepo{events: []*domains.Event{
+		meeting(1, 1, nine, time.Hour, "planning"),
+		newEvent(2, 1, nine, "no duration"),
+		meeting(3, 2, nine, time.Hour, "other user"),
+		{ID: 4, UserID: 1, Date: nine.AddDate(0, 0, -7).Add(3 * time.Hour), End: nine.AddDate(0, 0, -7).Add(4 * time.Hour), Title: "weekly", Recurrence: &domains.Recurrence{Frequency: domains.FrequencyWeekly}},
+	}}
+	ctx := userCtx(1)
+
+	svc := NewEventService(repo, ConflictReject)
+
+	_, err := svc.Create(ctx, meeting(0, 1, nine.Add(30*time.Minute), time.Hour, "overlapping"))
+	var conflictErr *domains.ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.ErrorIs(t, err, domains.ErrEventConflict)
+	require.Len(t, conflictErr.Conflicts, 1)
+	assert.Equal(t, "planning", conflictErr.Conflicts[0].Title)
+
+	// touching intervals do not overlap
+	_, err = svc.Create(ctx, meeting(0, 1, nine.Add(time.Hour), time.Hour, "after planning"))
+	require.NoError(t, err)
+
+	// a daily series collides with an occurrence of the weekly one
+	_, err = svc.Create(ctx, &domains.Event{UserID: 1, Date: nine.Add(3 * time.Hour), End: nine.Add(3*time.Hour + 30*time.Minute), Title: "daily", Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily}})
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, "weekly", conflictErr.Conflicts[0].Title)
+
+	// an event does not conflict with its previous version
+	_, err = svc.Update(ctx, meeting(1, 1, nine.Add(-30*time.Minute), time.Hour, "earlier planning"))
+	require.NoError(t, err)
+
+	_, err = NewEventService(repo, ConflictWarn).Create(ctx, meeting(0, 1, nine, time.Hour, "double booked"))
+	require.NoError(t, err)
+}
+
+func TestFreeBusy(t *testing.T) {
+	day := date(2026, 3, 11)
+	repo := &mockRepo{events: []*domains.Event{
+		meeting(1, 1, day.Add(9*time.Hour), time.Hour, "planning"),
+		meeting(2, 2, day.Add(9*time.Hour+30*time.Minute), time.Hour, "review"),
+		meeting(3, 2, day.Add(-time.Hour), 9*time.Hour, "night shift"),
+		newEvent(4, 1, day.Add(12*time.Hour), "no duration"),
+		{ID: 5, UserID: 1, Date: day.AddDate(0, 0, -1).Add(13 * time.Hour), End: day.AddDate(0, 0, -1).Add(14 * time.Hour), Title: "daily", Recurrence: &domains.Recurrence{Frequency: domains.FrequencyDaily}},
+	}}
+	svc := NewEventService(repo, ConflictIgnore)
+
+	// invitations take up time unless they are declined
+	accepted := meeting(6, 4, day.Add(11*time.Hour), time.Hour, "lunch")
+	accepted.Attendees = []domains.Attendee{{UserID: 1, Status: domains.AttendeeAccepted}}
+	declined := meeting(7, 4, day.Add(14*time.Hour), time.Hour, "retro")
+	declined.Attendees = []domains.Attendee{{UserID: 2, Status: domains.AttendeeDeclined}}
+	repo.events = append(repo.events, accepted, declined)
+
+	freeBusy, err := svc.FreeBusy(userCtx(3), []int{1, 2, 2}, day.Add(7*time.Hour), day.Add(15*time.Hour), time.Hour)
+	require.NoError(t, err)
+
+	interval := func(fromHour, toHour float64) domains.Interval {
+		return domains.Interval{Start: day.Add(time.Duration(fromHour * float64(time.Hour))), End: day.Add(time.Duration(toHour * float64(time.Hour)))}
+	}
+
+	assert.Equal(t, []domains.Interval{interval(9, 10), interval(11, 12), interval(13, 14)}, freeBusy.Users[1])
+	assert.Equal(t, []domains.Interval{interval(7, 8), interval(9.5, 10.5)}, freeBusy.Users[2])
+	assert.Equal(t, []domains.Interval{interval(7, 8), interval(9, 10.5), interval(11, 12), interval(13, 14)}, freeBusy.Busy)
+	assert.Equal(t, []domains.Interval{interval(8, 9), interval(12, 13), interval(14, 15)}, freeBusy.Free)
+
+	_, err = svc.FreeBusy(context.Background(), []int{1}, day, day.AddDate(0, 0, 1), time.Hour)
+	assert.ErrorIs(t, err, domains.ErrUnauthenticated)
+}
+
+func TestBatch(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(1)
+
+	results, err := svc.Batch(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: newEvent(0, 1, date(2026, 3, 20), "added")},
+		{Kind: domains.OperationUpdate, Event: newEvent(1, 1, date(2026, 3, 11), "renamed")},
+		{Kind: domains.OperationDelete, ID: 2},
+	}, true)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "added", results[0].Event.Title)
+	assert.Nil(t, results[2].Event)
+	assert.Len(t, repo.events, 6)
+
+	_, err = svc.Batch(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: newEvent(0, 1, date(2026, 3, 21), "not stored")},
+		{Kind: domains.OperationDelete, ID: 6},
+	}, true)
+	var batchErr *domains.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+	assert.Len(t, repo.events, 6)
+
+	results, err = svc.Batch(ctx, []domains.Operation{
+		{Kind: domains.OperationCreate, Event: newEvent(0, 1, date(2026, 3, 21), "stored")},
+		{Kind: domains.OperationDelete, ID: 6},
+		{Kind: domains.OperationDelete, ID: 2},
+	}, false)
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, domains.ErrForbidden)
+	assert.ErrorIs(t, results[2].Err, domains.ErrEventNotFound)
+	assert.Len(t, repo.events, 7)
+}
+
+func TestAttendees(t *testing.T) {
+	svc, _ := setupService()
+	owner := userCtx(1)
+	guest := userCtx(2)
+
+	event, err := svc.Create(owner, &domains.Event{
+		UserID:    1,
+		Title:     "planning",
+		Date:      date(2026, 3, 20),
+		Attendees: []domains.Attendee{{UserID: 2, Status: domains.AttendeePending}},
+	})
+	require.NoError(t, err)
+
+	day, err := eventsOf(svc.EventsForDay(guest, 2, date(2026, 3, 20), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, day, 1)
+	assert.Equal(t, "planning", day[0].Title)
+
+	shown, err := svc.Event(guest, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, shown.ID)
+
+	_, err = svc.Event(userCtx(3), event.ID)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	accepted, err := svc.Respond(guest, event.ID, 2, domains.AttendeeAccepted)
+	require.NoError(t, err)
+	assert.Equal(t, domains.AttendeeAccepted, accepted.Attendees[0].Status)
+
+	// the owner invites another user, the answer of the first one is kept
+	replaced := &domains.Event{
+		ID:        event.ID,
+		UserID:    1,
+		Title:     "planning",
+		Date:      date(2026, 3, 20),
+		Attendees: []domains.Attendee{{UserID: 2, Status: domains.AttendeePending}, {UserID: 3, Status: domains.AttendeePending}},
+	}
+	updated, err := svc.Update(owner, replaced)
+	require.NoError(t, err)
+	assert.Equal(t, []domains.Attendee{{UserID: 2, Status: domains.AttendeeAccepted}, {UserID: 3, Status: domains.AttendeePending}}, updated.Attendees)
+
+	_, err = svc.Respond(guest, event.ID, 2, domains.AttendeeDeclined)
+	require.NoError(t, err)
+
+	day, err = eventsOf(svc.EventsForDay(guest, 2, date(2026, 3, 20), domains.Page{}))
+	require.NoError(t, err)
+	assert.Empty(t, day)
+
+	invitations, err := eventsOf(svc.Invitations(guest, 2, "", domains.Page{}))
+	require.NoError(t, err)
+	assert.Len(t, invitations, 1)
+
+	invitations, err = eventsOf(svc.Invitations(guest, 2, domains.AttendeeAccepted, domains.Page{}))
+	require.NoError(t, err)
+	assert.Empty(t, invitations)
+
+	_, err = svc.Respond(userCtx(4), event.ID, 4, domains.AttendeeAccepted)
+	assert.ErrorIs(t, err, domains.ErrNotInvited)
+
+	_, err = svc.Respond(guest, event.ID, 3, domains.AttendeeAccepted)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+}
+
+func TestCalendarSharing(t *testing.T) {
+	svc, repo := setupService()
+	owner := userCtx(1)
+
+	team, err := svc.CreateCalendar(owner, &domains.Calendar{OwnerID: 1, Name: "team"})
+	require.NoError(t, err)
+
+	_, err = svc.CreateCalendar(owner, &domains.Calendar{OwnerID: 2, Name: "not mine"})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Share(owner, team.ID, 2, domains.PermissionRead)
+	require.NoError(t, err)
+	_, err = svc.Share(owner, team.ID, 3, domains.PermissionWrite)
+	require.NoError(t, err)
+	_, err = svc.Share(owner, team.ID, 1, domains.PermissionRead)
+	assert.ErrorIs(t, err, domains.ErrShareWithOwner)
+
+	reader, writer := userCtx(2), userCtx(3)
+
+	// a writer creates the event in the calendar, it belongs to the owner of the calendar
+	planning, err := svc.Create(writer, &domains.Event{UserID: 3, CalendarID: team.ID, Title: "planning", Date: date(2026, 3, 11)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, planning.UserID)
+
+	_, err = svc.Create(reader, &domains.Event{UserID: 2, CalendarID: team.ID, Title: "reader", Date: date(2026, 3, 11)})
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	events, err := eventsOf(svc.EventsForDay(reader, 2, date(2026, 3, 11), domains.Page{}, team.ID))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "planning", events[0].Title)
+
+	// without calendar ids the agenda holds the own events only
+	events, err = eventsOf(svc.EventsForDay(reader, 2, date(2026, 3, 11), domains.Page{}))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "other user", events[0].Title)
+
+	// zero selects the personal events, so the owner sees both
+	events, err = eventsOf(svc.EventsForDay(owner, 1, date(2026, 3, 11), domains.Page{}, 0, team.ID))
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	events, err = eventsOf(svc.EventsForDay(owner, 1, date(2026, 3, 11), domains.Page{}, 0))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "monday", events[0].Title)
+
+	_, err = eventsOf(svc.EventsForDay(userCtx(4), 4, date(2026, 3, 11), domains.Page{}, team.ID))
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = eventsOf(svc.EventsForDay(owner, 1, date(2026, 3, 11), domains.Page{}, 42))
+	assert.ErrorIs(t, err, domains.ErrCalendarNotFound)
+
+	shown, err := svc.Event(reader, planning.ID)
+	require.NoError(t, err)
+	assert.Equal(t, planning.ID, shown.ID)
+
+	changed := &domains.Event{ID: planning.ID, UserID: 1, CalendarID: team.ID, Title: "planning", Date: date(2026, 3, 12)}
+	_, err = svc.Update(reader, changed)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	// a writer may change the events of the calendar
+	_, err = svc.Update(writer, changed)
+	require.NoError(t, err)
+
+	_, err = svc.RenameCalendar(writer, team.ID, "renamed")
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Share(owner, team.ID, 3, domains.PermissionAdmin)
+	require.NoError(t, err)
+
+	renamed, err := svc.RenameCalendar(writer, team.ID, "renamed")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", renamed.Name)
+	assert.Len(t, renamed.Shares, 2)
+
+	// users may leave a calendar, but not remove others without the admin permission
+	_, err = svc.Unshare(reader, team.ID, 3)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+	_, err = svc.Unshare(reader, team.ID, 2)
+	require.NoError(t, err)
+
+	_, err = svc.Calendar(reader, team.ID)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	calendars, err := svc.Calendars(writer, 3)
+	require.NoError(t, err)
+	assert.Len(t, calendars, 1)
+
+	require.NoError(t, svc.Delete(writer, planning.ID, 0))
+
+	assert.ErrorIs(t, svc.DeleteCalendar(writer, team.ID), domains.ErrForbidden)
+	require.NoError(t, svc.DeleteCalendar(owner, team.ID))
+	assert.Empty(t, repo.calendars)
+}
+
+func TestTrash(t *testing.T) {
+	_, repo := setupService()
+	listener := &recordingListener{}
+	svc := NewEventService(repo, ConflictReject, listener)
+	ctx := userCtx(1)
+	nine := date(2026, 4, 1).Add(9 * time.Hour)
+
+	planning, err := svc.Create(ctx, meeting(0, 1, nine, time.Hour, "planning"))
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, planning.ID, 0))
+
+	_, err = svc.Event(ctx, planning.ID)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	// the history of a trashed event is still readable by its owner
+	history, err := svc.History(ctx, planning.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "planning", history[0].Title)
+
+	_, err = svc.History(userCtx(2), planning.ID)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.History(ctx, 999)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	trash, err := svc.Trash(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, planning.ID, trash[0].ID)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+
+	_, err = svc.Trash(ctx, 2)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	_, err = svc.Restore(userCtx(2), planning.ID)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
+
+	// the slot of the deleted event has been taken in the meantime
+	blocker := meeting(100, 1, nine.Add(30*time.Minute), time.Hour, "blocker")
+	repo.events = append(repo.events, blocker)
+
+	var conflict *domains.ConflictError
+	_, err = svc.Restore(ctx, planning.ID)
+	require.ErrorAs(t, err, &conflict)
+
+	require.NoError(t, svc.Delete(ctx, blocker.ID, 0))
+
+	restored, err := svc.Restore(ctx, planning.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "planning", restored.Title)
+	assert.Equal(t, 2, restored.Version)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.Equal(t, []int{planning.ID, planning.ID}, listener.created)
+
+	shown, err := svc.Event(ctx, planning.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "planning", shown.Title)
+
+	_, err = svc.Restore(ctx, planning.ID)
+	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+}
+
+func TestTrash_DeletedCalendar(t *testing.T) {
+	svc, _ := setupService()
+	owner := userCtx(1)
+
+	team, err := svc.CreateCalendar(owner, &domains.Calendar{OwnerID: 1, Name: "team"})
+	require.NoError(t, err)
+
+	event, err := svc.Create(owner, &domains.Event{UserID: 1, CalendarID: team.ID, Title: "planning", Date: date(2026, 4, 1)})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(owner, event.ID, 0))
+	require.NoError(t, svc.DeleteCalendar(owner, team.ID))
+
+	// the calendar is gone, so the event returns to the personal events of its owner
+	_, err = svc.Restore(userCtx(2), event.ID)
+	assert.ErrorIs(t, err, domains.ErrForbidden)
 
-	err := svc.Delete(ctx, 999)
+	restored, err := svc.Restore(owner, event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored.CalendarID)
+	assert.Equal(t, 1, restored.UserID)
+}
+
+func TestPurger(t *testing.T) {
+	svc, repo := setupService()
+	ctx := userCtx(1)
+
+	require.NoError(t, svc.Delete(ctx, 1, 0))
+	require.NoError(t, svc.Delete(ctx, 2, 0))
+	repo.trash[0].DeletedAt = time.Now().Add(-48 * time.Hour)
+
+	purger := NewPurger(repo, 24*time.Hour, time.Hour)
+
+	purged, err := purger.Purge(context.Background())
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+	assert.Equal(t, 1, purged[0].ID)
+
+	_, err = svc.Restore(ctx, 1)
 	assert.ErrorIs(t, err, domains.ErrEventNotFound)
+
+	assert.Empty(t, repo.history[1])
+
+	trash, err := svc.Trash(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, 2, trash[0].ID)
 }
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/M-kos/wb_level2/task_18/internal/domains"
+)
+
+// Trash returns the deleted events of the user ordered by date and id, recurring events are not expanded.
+func (es *EventService) Trash(ctx context.Context, userId int) ([]*domains.Event, error) {
+	if err := authorize(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return es.repo.Trash(ctx, userId)
+}
+
+// Restore moves the event from the trash back to the events, it needs the write permission on the event.
+// An event whose calendar has been deleted in the meantime is restored as a personal event of its owner.
+// The restored event is checked for conflicts like a new one.
+func (es *EventService) Restore(ctx context.Context, eventId int) (*domains.Event, error) {
+	event, err := es.permittedTrashedEvent(ctx, eventId, domains.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := es.checkConflicts(ctx, event); err != nil {
+		return nil, err
+	}
+
+	restored, err := es.repo.Restore(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listener := range es.listeners {
+		listener.EventCreated(ctx, restored)
+	}
+
+	return restored, nil
+}
+
+// permittedTrashedEvent returns a copy of the trashed event if the authenticated user has the permission on it.
+// If the calendar of the event has been deleted, the copy is a personal event of its owner.
+func (es *EventService) permittedTrashedEvent(ctx context.Context, eventId int, required domains.Permission) (*domains.Event, error) {
+	trashed, err := es.repo.TrashedEvent(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	event := *trashed
+
+	err = es.access(ctx, &event, required)
+	if errors.Is(err, domains.ErrCalendarNotFound) {
+		event.CalendarID = 0
+		err = es.access(ctx, &event, required)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// Purger permanently deletes the events which have been in the trash for longer than the retention period.
+type Purger struct {
+	repo      EventRepository
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func NewPurger(repo EventRepository, retention, interval time.Duration) *Purger {
+	return &Purger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run purges the trash every interval until the context is done.
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Purge(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "[Purger] error purging trash", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes the events trashed before the retention period and returns them.
+func (p *Purger) Purge(ctx context.Context) ([]*domains.Event, error) {
+	purged, err := p.repo.Purge(ctx, p.now().Add(-p.retention))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(purged) > 0 {
+		slog.InfoContext(ctx, "[Purger] purged trashed events", "count", len(purged))
+	}
+
+	return purged, nil
+}
@@ -1,38 +1,298 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/M-kos/wb_level2/task_18/internal/audit"
+	"github.com/M-kos/wb_level2/task_18/internal/changes"
 	"github.com/M-kos/wb_level2/task_18/internal/config"
 	"github.com/M-kos/wb_level2/task_18/internal/handlers"
 	"github.com/M-kos/wb_level2/task_18/internal/middlewares"
+	"github.com/M-kos/wb_level2/task_18/internal/reminders"
 	"github.com/M-kos/wb_level2/task_18/internal/repositories"
+	"github.com/M-kos/wb_level2/task_18/internal/rpc"
 	"github.com/M-kos/wb_level2/task_18/internal/services"
+	"github.com/M-kos/wb_level2/task_18/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
+	slog.SetDefault(slog.New(tracing.NewLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
 	conf, err := config.Load()
 	if err != nil {
 		slog.Error("error loading config", "error", err)
 		return
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	router := http.NewServeMux()
 
-	eventRepository := repositories.NewEventRepository()
-	eventService := services.NewEventService(eventRepository)
+	eventRepository, closeRepository, err := newEventRepository(conf)
+	if err != nil {
+		slog.Error("error creating event repository", "error", err)
+		return
+	}
+	defer closeRepository()
+
+	auditSink, closeAudit, err := newAuditSink(conf, eventRepository)
+	if err != nil {
+		slog.Error("error creating audit sink", "error", err)
+		return
+	}
+	defer closeAudit()
 
-	handlers.NewEventHandler(router, eventService, middlewares.LoggingMiddleware)
+	var serviceRepository services.EventRepository = eventRepository
+	if auditSink != nil {
+		serviceRepository = audit.NewRepository(eventRepository, auditSink)
+	}
+
+	scheduler := reminders.NewScheduler(eventRepository, newNotifier(conf))
+	changeBus := changes.NewBus(conf.StreamBufferSize)
+	eventService := services.NewEventService(serviceRepository, services.ConflictPolicy(conf.ConflictPolicy), scheduler, changeBus)
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+
+		if err := scheduler.Run(schedulerCtx); err != nil {
+			slog.Error("error running reminder scheduler", "error", err)
+		}
+	}()
+	defer func() {
+		stopScheduler()
+		<-schedulerDone
+	}()
+
+	if conf.TrashRetention > 0 {
+		// purging goes through the audited repository, so purged events are recorded as well
+		purger := services.NewPurger(serviceRepository, conf.TrashRetention, conf.TrashPurgeInterval)
+
+		purgerCtx, stopPurger := context.WithCancel(context.Background())
+		purgerDone := make(chan struct{})
+		go func() {
+			defer close(purgerDone)
+
+			if err := purger.Run(purgerCtx); err != nil {
+				slog.Error("error running trash purger", "error", err)
+			}
+		}()
+		defer func() {
+			stopPurger()
+			<-purgerDone
+		}()
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	metrics := middlewares.NewMetrics(registry)
+
+	router.Handle("GET /metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	rateLimiter, err := newRateLimiter(conf)
+	if err != nil {
+		slog.Error("error creating rate limiter", "error", err)
+		return
+	}
+
+	eventMiddlewares := []middlewares.Middleware{
+		middlewares.RequestIDMiddleware,
+		metrics.Middleware,
+		middlewares.RecoveryMiddleware,
+		middlewares.LoggingMiddleware,
+		middlewares.AuthMiddleware([]byte(conf.JWTSecret)),
+		// after auth, so the requests of a user share a budget whatever their address
+		rateLimiter.Middleware,
+	}
+	if conf.IdempotencyTTL > 0 {
+		// after the rate limiter, so replayed retries count against the budget as well
+		idempotency := middlewares.NewIdempotency(conf.IdempotencyTTL, conf.MaxBodySize, conf.IdempotencyMaxKeys, conf.IdempotencyMaxBytes)
+		eventMiddlewares = append(eventMiddlewares, idempotency.Middleware)
+	}
+	middleware := middlewares.Chain(eventMiddlewares...)
+
+	handlers.NewEventHandler(router, eventService, middleware, conf.MaxBodySize)
+	handlers.NewStreamHandler(router, changeBus, middleware, conf.StreamHeartbeat)
+	if auditSink != nil {
+		handlers.NewAuditHandler(router, auditSink, middleware)
+	}
+
+	// calendar apps only know passwords, so CalDAV also takes the token as a Basic password
+	handlers.NewCalDAVHandler(router, eventService, middlewares.Chain(
+		middlewares.RequestIDMiddleware,
+		metrics.Middleware,
+		middlewares.RecoveryMiddleware,
+		middlewares.LoggingMiddleware,
+		middlewares.BasicAuthMiddleware([]byte(conf.JWTSecret)),
+		rateLimiter.Middleware,
+	))
+
+	// the API description is public, so it skips the auth middleware
+	handlers.NewDocsHandler(router, middlewares.Chain(
+		middlewares.RequestIDMiddleware,
+		metrics.Middleware,
+		middlewares.RecoveryMiddleware,
+		middlewares.LoggingMiddleware,
+		rateLimiter.Middleware,
+	))
 
 	server := http.Server{
-		Addr:    fmt.Sprintf(":%d", conf.Port),
-		Handler: router,
+		Addr:              fmt.Sprintf(":%d", conf.Port),
+		Handler:           router,
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+	}
+
+	// open streams would otherwise keep Shutdown waiting until its timeout
+	server.RegisterOnShutdown(changeBus.Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	grpcServer := rpc.NewServer(eventService, changeBus, []byte(conf.JWTSecret))
+	if conf.GRPCPort != 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", conf.GRPCPort))
+		if err != nil {
+			slog.Error("error starting grpc server", "error", err)
+			return
+		}
+
+		go func() {
+			serverErr <- grpcServer.Serve(listener)
+		}()
 	}
 
-	if err = server.ListenAndServe(); err != nil {
+	select {
+	case err = <-serverErr:
 		slog.Error("error starting server", "error", err)
 		return
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "timeout", conf.ShutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+
+	// stops accepting connections and waits for in-flight requests
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("error shutting down server", "error", err)
+	}
+
+	// the bus is closed by now, so watch calls have ended and only unary calls are waited for
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+}
+
+type eventRepository interface {
+	services.EventRepository
+	reminders.EventRepository
+	audit.Sink
+}
+
+func newEventRepository(conf *config.Config) (eventRepository, func(), error) {
+	switch conf.Storage {
+	case config.StorageSQL:
+		repo, err := repositories.NewSQLEventRepository(context.Background(), conf.DatabasePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repo, func() {
+			if err := repo.Close(); err != nil {
+				slog.Error("error closing database", "error", err)
+			}
+		}, nil
+	default:
+		if conf.DataDir == "" {
+			return repositories.NewEventRepository(), func() {}, nil
+		}
+
+		repo, err := repositories.NewDurableEventRepository(conf.DataDir, repositories.PersistenceOptions{
+			Sync:             repositories.SyncPolicy(conf.WALSync),
+			SyncInterval:     conf.WALSyncInterval,
+			SnapshotInterval: conf.SnapshotInterval,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repo, func() {
+			if err := repo.Close(); err != nil {
+				slog.Error("error closing event repository", "error", err)
+			}
+		}, nil
 	}
 }
+
+// newAuditSink returns nil if auditing is turned off.
+func newAuditSink(conf *config.Config, repo eventRepository) (audit.Sink, func(), error) {
+	switch conf.AuditSink {
+	case config.AuditFile:
+		sink, err := audit.NewFileSink(conf.AuditFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return sink, func() {
+			if err := sink.Close(); err != nil {
+				slog.Error("error closing audit log", "error", err)
+			}
+		}, nil
+	case config.AuditRepository:
+		return repo, func() {}, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
+
+func newRateLimiter(conf *config.Config) (*middlewares.RateLimiter, error) {
+	defaultLimit, err := middlewares.ParseLimit(conf.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]middlewares.Limit, len(conf.RateLimitRoutes))
+	for route, value := range conf.RateLimitRoutes {
+		if routes[route], err = middlewares.ParseLimit(value); err != nil {
+			return nil, fmt.Errorf("route %q: %w", route, err)
+		}
+	}
+
+	return middlewares.NewRateLimiter(defaultLimit, routes, conf.RateLimitIdle), nil
+}
+
+func newNotifier(conf *config.Config) reminders.Notifier {
+	notifiers := reminders.Notifiers{reminders.LogNotifier{}}
+
+	if conf.ReminderWebhookURL != "" {
+		notifiers = append(notifiers, reminders.NewWebhookNotifier(conf.ReminderWebhookURL, conf.ReminderWebhookTimeout))
+	}
+
+	return notifiers
+}